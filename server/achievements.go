@@ -31,6 +31,13 @@ func (p *Plugin) EnsureBadges() {
 			ImageType:   badgesmodel.ImageTypeAbsoluteURL,
 			Multiple:    false,
 		},
+		{
+			Name:        AchievementNameCourseGraduate,
+			Description: "Finish every lesson and pass every quiz of a course",
+			Image:       p.getStaticURL() + "/coursegraduate.png", // TODO use correct url
+			ImageType:   badgesmodel.ImageTypeAbsoluteURL,
+			Multiple:    false,
+		},
 	}
 
 	reqBody := badgesmodel.EnsureBadgesRequest{
@@ -13,6 +13,7 @@ import (
 
 	"github.com/gorilla/mux"
 	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/pkg/errors"
 )
 
 // HTTPHandlerFuncWithUser is http.HandleFunc but userID is already exported
@@ -61,6 +62,31 @@ func (p *Plugin) initializeAPI() {
 			Handler: p.dialogChangeType,
 			Method:  http.MethodPost,
 		},
+		{
+			Path:    DialogPathQuizDetails,
+			Handler: p.dialogQuizDetails,
+			Method:  http.MethodPost,
+		},
+		{
+			Path:    DialogPathQuizScope,
+			Handler: p.dialogQuizScope,
+			Method:  http.MethodPost,
+		},
+		{
+			Path:    DialogPathCourseScope,
+			Handler: p.dialogCourseScope,
+			Method:  http.MethodPost,
+		},
+		{
+			Path:    DialogPathQuizVisibility,
+			Handler: p.dialogQuizVisibility,
+			Method:  http.MethodPost,
+		},
+		{
+			Path:    DialogPathCourseVisibility,
+			Handler: p.dialogCourseVisibility,
+			Method:  http.MethodPost,
+		},
 		{
 			Path:    DialogPathDelete,
 			Handler: p.dialogDelete,
@@ -96,6 +122,16 @@ func (p *Plugin) initializeAPI() {
 			Handler: p.dialogAnswer,
 			Method:  http.MethodPost,
 		},
+		{
+			Path:    DialogPathSelectAnswers,
+			Handler: p.dialogSelectAnswers,
+			Method:  http.MethodPost,
+		},
+		{
+			Path:    DialogPathOrderAnswers,
+			Handler: p.dialogOrderAnswers,
+			Method:  http.MethodPost,
+		},
 		{
 			Path:    DialogPathNameCourse,
 			Handler: p.dialogNameCourse,
@@ -151,6 +187,41 @@ func (p *Plugin) initializeAPI() {
 			Handler: p.dialogLessonDelete,
 			Method:  http.MethodPost,
 		},
+		{
+			Path:    DialogPathQuizEditors,
+			Handler: p.dialogQuizEditors,
+			Method:  http.MethodPost,
+		},
+		{
+			Path:    DialogPathCourseEditors,
+			Handler: p.dialogCourseEditors,
+			Method:  http.MethodPost,
+		},
+		{
+			Path:    DialogPathEditQuiz,
+			Handler: p.dialogEditQuiz,
+			Method:  http.MethodPost,
+		},
+		{
+			Path:    DialogPathEditQuestion,
+			Handler: p.dialogEditQuestion,
+			Method:  http.MethodPost,
+		},
+		{
+			Path:    DialogPathExportQuiz,
+			Handler: p.dialogExportQuiz,
+			Method:  http.MethodPost,
+		},
+		{
+			Path:    DialogPathUpdateQuestion,
+			Handler: p.dialogUpdateQuestion,
+			Method:  http.MethodPost,
+		},
+		{
+			Path:    DialogPathCourseStart,
+			Handler: p.dialogCourseStart,
+			Method:  http.MethodPost,
+		},
 	}
 
 	for _, e := range dialogRouterEndpoints {
@@ -168,6 +239,36 @@ func (p *Plugin) initializeAPI() {
 			Handler: p.attachmentChangeType,
 			Method:  http.MethodPost,
 		},
+		{
+			Path:    AttachmentPathQuizDetails,
+			Handler: p.attachmentQuizDetails,
+			Method:  http.MethodPost,
+		},
+		{
+			Path:    AttachmentPathQuizScope,
+			Handler: p.attachmentQuizScope,
+			Method:  http.MethodPost,
+		},
+		{
+			Path:    AttachmentPathCourseScope,
+			Handler: p.attachmentCourseScope,
+			Method:  http.MethodPost,
+		},
+		{
+			Path:    AttachmentPathQuizVisibility,
+			Handler: p.attachmentQuizVisibility,
+			Method:  http.MethodPost,
+		},
+		{
+			Path:    AttachmentPathCourseVisibility,
+			Handler: p.attachmentCourseVisibility,
+			Method:  http.MethodPost,
+		},
+		{
+			Path:    AttachmentPathRestoreRevision,
+			Handler: p.attachmentRestoreRevision,
+			Method:  http.MethodPost,
+		},
 		{
 			Path:    AttachmentPathDelete,
 			Handler: p.attachmentDelete,
@@ -278,12 +379,70 @@ func (p *Plugin) initializeAPI() {
 			Handler: p.attachmentLessonDelete,
 			Method:  http.MethodPost,
 		},
+		{
+			Path:    AttachmentPathQuizEditors,
+			Handler: p.attachmentQuizEditors,
+			Method:  http.MethodPost,
+		},
+		{
+			Path:    AttachmentPathCourseEditors,
+			Handler: p.attachmentCourseEditors,
+			Method:  http.MethodPost,
+		},
+		{
+			Path:    AttachmentPathEditQuestion,
+			Handler: p.attachmentEditQuestion,
+			Method:  http.MethodPost,
+		},
+		{
+			Path:    AttachmentPathUpdateQuestion,
+			Handler: p.attachmentUpdateQuestion,
+			Method:  http.MethodPost,
+		},
+		{
+			Path:    AttachmentPathQuestionBack,
+			Handler: p.attachmentQuestionBack,
+			Method:  http.MethodPost,
+		},
+		{
+			Path:    AttachmentPathSetQuestionImage,
+			Handler: p.attachmentSetQuestionImage,
+			Method:  http.MethodPost,
+		},
+		{
+			Path:    AttachmentPathRemoveQuestionImage,
+			Handler: p.attachmentRemoveQuestionImage,
+			Method:  http.MethodPost,
+		},
+		{
+			Path:    AttachmentPathCourseLesson,
+			Handler: p.attachmentCourseLesson,
+			Method:  http.MethodPost,
+		},
+		{
+			Path:    AttachmentPathCourseQuiz,
+			Handler: p.attachmentCourseQuiz,
+			Method:  http.MethodPost,
+		},
+		{
+			Path:    AttachmentPathCourseFinish,
+			Handler: p.attachmentCourseFinish,
+			Method:  http.MethodPost,
+		},
+		{
+			Path:    AttachmentPathExportResults,
+			Handler: p.attachmentExportResults,
+			Method:  http.MethodPost,
+		},
 	}
 
 	for _, e := range attachmentRouterEndpoints {
 		attachmentRouter.HandleFunc(e.Path, p.extractUserMiddleWare(e.Handler, ResponseTypeDialog)).Methods(e.Method)
 	}
 
+	p.router.HandleFunc(ImagePath+"/{quizID}/{fileID}", p.extractUserMiddleWare(p.serveQuestionImage, ResponseTypePlain)).Methods(http.MethodGet)
+	p.router.HandleFunc(QuizSearchPath, p.extractUserMiddleWare(p.searchQuizzes, ResponseTypeJSON)).Methods(http.MethodGet)
+
 	p.router.PathPrefix(StaticPath).Handler(http.StripPrefix("/", http.FileServer(http.FS(staticAssets))))
 
 	p.router.PathPrefix("/").HandlerFunc(p.defaultHandler)
@@ -296,8 +455,8 @@ func (p *Plugin) defaultHandler(w http.ResponseWriter, r *http.Request) {
 
 func (p *Plugin) dialogNameQuiz(w http.ResponseWriter, r *http.Request, actingUserID string) {
 	req := model.SubmitDialogRequestFromJson(r.Body)
-	id := req.State
-	post, err := p.mm.Post.GetPost(id)
+	id, postID := getQuizIDAndPostIDFromState(req.State)
+	post, err := p.mm.Post.GetPost(postID)
 	if err != nil {
 		dialogError(w, err.Error(), nil)
 		return
@@ -318,13 +477,14 @@ func (p *Plugin) dialogNameQuiz(w http.ResponseWriter, r *http.Request, actingUs
 		dialogError(w, err.Error(), nil)
 		return
 	}
-	if q == nil {
-		dialogError(w, "quiz not found", nil)
+
+	if !p.canEditQuiz(q, actingUserID) {
+		dialogError(w, ErrNotQuizEditor.Error(), nil)
 		return
 	}
 
 	q.Name = name
-	err = p.store.StoreQuiz(q)
+	err = p.storeQuizRevision(q, actingUserID)
 	if err != nil {
 		dialogError(w, err.Error(), nil)
 		return
@@ -341,8 +501,8 @@ func (p *Plugin) dialogNameQuiz(w http.ResponseWriter, r *http.Request, actingUs
 
 func (p *Plugin) dialogChangeType(w http.ResponseWriter, r *http.Request, actingUserID string) {
 	req := model.SubmitDialogRequestFromJson(r.Body)
-	id := req.State
-	post, err := p.mm.Post.GetPost(id)
+	id, postID := getQuizIDAndPostIDFromState(req.State)
+	post, err := p.mm.Post.GetPost(postID)
 	if err != nil {
 		dialogError(w, err.Error(), nil)
 		return
@@ -362,13 +522,71 @@ func (p *Plugin) dialogChangeType(w http.ResponseWriter, r *http.Request, acting
 		dialogError(w, err.Error(), nil)
 		return
 	}
-	if q == nil {
-		dialogError(w, "quiz not found", nil)
+
+	if !p.canEditQuiz(q, actingUserID) {
+		dialogError(w, ErrNotQuizEditor.Error(), nil)
 		return
 	}
 
 	q.Type = QuizType(qType)
-	err = p.store.StoreQuiz(q)
+	scoring, _ := req.Submission[DialogSubmissionFieldMultiSelectScore].(string)
+	if scoring != "" {
+		q.MultiSelectScoring = MultiSelectScoring(scoring)
+	}
+	if q.MultiSelectScoring == "" && q.Type == QuizTypeMultiSelect {
+		q.MultiSelectScoring = MultiSelectScoringAllOrNothing
+	}
+
+	err = p.storeQuizRevision(q, actingUserID)
+	if err != nil {
+		dialogError(w, err.Error(), nil)
+		return
+	}
+
+	model.ParseSlackAttachment(post, p.CreateAttachmentFromQuiz(q))
+	err = p.mm.Post.UpdatePost(post)
+	if err != nil {
+		dialogError(w, err.Error(), nil)
+		return
+	}
+	dialogOK(w)
+}
+
+func (p *Plugin) dialogQuizDetails(w http.ResponseWriter, r *http.Request, actingUserID string) {
+	req := model.SubmitDialogRequestFromJson(r.Body)
+	id, postID := getQuizIDAndPostIDFromState(req.State)
+	post, err := p.mm.Post.GetPost(postID)
+	if err != nil {
+		dialogError(w, err.Error(), nil)
+		return
+	}
+
+	difficulty, _ := req.Submission[DialogSubmissionFieldDifficulty].(string)
+	if !QuizDifficulty(difficulty).IsValid() {
+		errors := map[string]string{
+			DialogSubmissionFieldDifficulty: "Invalid difficulty",
+		}
+		dialogError(w, "Invalid value", errors)
+		return
+	}
+
+	q, err := p.store.GetQuiz(id)
+	if err != nil {
+		dialogError(w, err.Error(), nil)
+		return
+	}
+
+	if !p.canEditQuiz(q, actingUserID) {
+		dialogError(w, ErrNotQuizEditor.Error(), nil)
+		return
+	}
+
+	category, _ := req.Submission[DialogSubmissionFieldCategory].(string)
+	tags, _ := req.Submission[DialogSubmissionFieldTags].(string)
+	q.Category = strings.TrimSpace(category)
+	q.Tags = parseTags(tags)
+	q.Difficulty = QuizDifficulty(difficulty)
+	err = p.storeQuizRevision(q, actingUserID)
 	if err != nil {
 		dialogError(w, err.Error(), nil)
 		return
@@ -385,18 +603,32 @@ func (p *Plugin) dialogChangeType(w http.ResponseWriter, r *http.Request, acting
 
 func (p *Plugin) dialogDelete(w http.ResponseWriter, r *http.Request, actingUserID string) {
 	req := model.SubmitDialogRequestFromJson(r.Body)
-	id := req.State
-	err := p.mm.Post.DeletePost(id)
+	id, postID := getQuizIDAndPostIDFromState(req.State)
+
+	q, err := p.store.GetQuiz(id)
+	if err != nil {
+		dialogError(w, err.Error(), nil)
+		return
+	}
+
+	if !p.canEditQuiz(q, actingUserID) {
+		dialogError(w, ErrNotQuizEditor.Error(), nil)
+		return
+	}
+
+	err = p.mm.Post.DeletePost(postID)
 	if err != nil {
 		dialogError(w, err.Error(), nil)
 		return
 	}
 
+	images := p.getQuizImageFileIDs(q)
 	err = p.store.DeleteQuiz(id)
 	if err != nil {
 		dialogError(w, err.Error(), nil)
 		return
 	}
+	p.deleteQuestionImages(images)
 
 	post := &model.Post{
 		UserId:    p.BotUserID,
@@ -410,72 +642,42 @@ func (p *Plugin) dialogDelete(w http.ResponseWriter, r *http.Request, actingUser
 
 func (p *Plugin) dialogAddQuestion(w http.ResponseWriter, r *http.Request, actingUserID string) {
 	req := model.SubmitDialogRequestFromJson(r.Body)
-	id := req.State
-	post, err := p.mm.Post.GetPost(id)
+	id, postID := getQuizIDAndPostIDFromState(req.State)
+	post, err := p.mm.Post.GetPost(postID)
 	if err != nil {
 		dialogError(w, err.Error(), nil)
 		return
 	}
 
-	question, ok := req.Submission[DialogSubmissionFieldQuestion].(string)
-	question = strings.TrimSpace(question)
-	if !ok || question == "" {
-		errors := map[string]string{
-			DialogSubmissionFieldQuestion: "Could not get question",
-		}
-		dialogError(w, "Missing some value", errors)
-		return
-	}
-	answer, ok := req.Submission[DialogSubmissionFieldAnswer].(string)
-	answer = strings.TrimSpace(answer)
-	if !ok || answer == "" {
-		errors := map[string]string{
-			DialogSubmissionFieldAnswer: "Could not get answer",
-		}
-		dialogError(w, "Missing some value", errors)
-		return
-	}
-
 	q, err := p.store.GetQuiz(id)
 	if err != nil {
 		dialogError(w, err.Error(), nil)
 		return
 	}
-	if q == nil {
-		dialogError(w, "quiz not found", nil)
+
+	if !p.canEditQuiz(q, actingUserID) {
+		dialogError(w, ErrNotQuizEditor.Error(), nil)
 		return
 	}
 
-	wrongAnsers := []string{}
-	if q.Type == QuizTypeMultipleChoice {
-		for i := 0; i < IncorrectAnswerCount; i++ {
-			fieldName := DialogSubmissionFieldWrongAnswer + strconv.Itoa(i)
-			wrongAnswer, ok := req.Submission[fieldName].(string)
-			wrongAnswer = strings.TrimSpace(wrongAnswer)
-			if !ok || wrongAnswer == "" {
-				errors := map[string]string{
-					fieldName: "Could not get answer",
-				}
-				dialogError(w, "Missing some value", errors)
-				return
-			}
-			wrongAnsers = append(wrongAnsers, wrongAnswer)
-		}
+	questionType := getQuestionTypeFromState(req.State)
+	if questionType == "" {
+		questionType = q.Type
 	}
 
-	newQuestion := Question{
-		ID:               model.NewId(),
-		Question:         question,
-		CorrectAnswer:    answer,
-		IncorrectAnswers: wrongAnsers,
+	newQuestion, errors := getQuestionFromSubmission(questionType, req.Submission)
+	if errors != nil {
+		dialogError(w, "Missing some value", errors)
+		return
 	}
+	newQuestion.ID = model.NewId()
 
 	if q.Questions == nil {
 		q.Questions = make([]Question, 0, 1)
 	}
 	q.Questions = append(q.Questions, newQuestion)
 
-	err = p.store.StoreQuiz(q)
+	err = p.storeQuizRevision(q, actingUserID)
 	if err != nil {
 		dialogError(w, err.Error(), nil)
 		return
@@ -492,8 +694,8 @@ func (p *Plugin) dialogAddQuestion(w http.ResponseWriter, r *http.Request, actin
 
 func (p *Plugin) dialogRemoveQuestions(w http.ResponseWriter, r *http.Request, actingUserID string) {
 	req := model.SubmitDialogRequestFromJson(r.Body)
-	id := req.State
-	post, err := p.mm.Post.GetPost(id)
+	id, postID := getQuizIDAndPostIDFromState(req.State)
+	post, err := p.mm.Post.GetPost(postID)
 	if err != nil {
 		dialogError(w, err.Error(), nil)
 		return
@@ -504,8 +706,9 @@ func (p *Plugin) dialogRemoveQuestions(w http.ResponseWriter, r *http.Request, a
 		dialogError(w, err.Error(), nil)
 		return
 	}
-	if q == nil {
-		dialogError(w, "quiz not found", nil)
+
+	if !p.canEditQuiz(q, actingUserID) {
+		dialogError(w, ErrNotQuizEditor.Error(), nil)
 		return
 	}
 
@@ -523,7 +726,7 @@ func (p *Plugin) dialogRemoveQuestions(w http.ResponseWriter, r *http.Request, a
 		}
 	}
 
-	err = p.store.StoreQuiz(q)
+	err = p.storeQuizRevision(q, actingUserID)
 	if err != nil {
 		dialogError(w, err.Error(), nil)
 		return
@@ -584,7 +787,7 @@ func (p *Plugin) dialogGameStart(w http.ResponseWriter, r *http.Request, actingU
 		return
 	}
 
-	if scoring != string(ScoringTypeAll) && scoring != string(ScoringTypeFirst) {
+	if scoring != string(ScoringTypeAll) && scoring != string(ScoringTypeFirst) && scoring != string(ScoringTypeSpeed) {
 		errors := map[string]string{
 			DialogSubmissionFieldGameScoring: "Scoring type not recognized",
 		}
@@ -603,13 +806,59 @@ func (p *Plugin) dialogGameStart(w http.ResponseWriter, r *http.Request, actingU
 
 	nQuestions := int(nQuestionsFloat)
 
+	timeLimitFloat, ok := req.Submission[DialogSubmissionFieldTimeLimit].(float64)
+	if !ok {
+		timeLimitFloat = 0
+	}
+
+	timeLimit := int(timeLimitFloat)
+	if timeLimit != 0 && (timeLimit < MinTimeLimit || timeLimit > MaxTimeLimit) {
+		errors := map[string]string{
+			DialogSubmissionFieldTimeLimit: fmt.Sprintf("The time limit must be 0 or between %d and %d seconds", MinTimeLimit, MaxTimeLimit),
+		}
+		dialogError(w, "Wrong value", errors)
+		return
+	}
+
 	quiz, err := p.store.GetQuiz(quizID)
 	if err != nil {
 		dialogError(w, err.Error(), nil)
 		return
 	}
 
+	if !p.canStartQuiz(quiz, actingUserID, req.TeamId, req.ChannelId) {
+		errors := map[string]string{
+			DialogSubmissionFieldGameQuiz: "You cannot start this quiz",
+		}
+		dialogError(w, "You cannot start this quiz", errors)
+		return
+	}
+
+	game, err := newGame(quiz, actingUserID, GameType(gameType), ScoringType(scoring), nQuestions)
+	if err != nil {
+		dialogError(w, err.Error(), nil)
+		return
+	}
+
+	if game.Type == GameTypeParty {
+		game.TimeLimit = timeLimit
+		game.TeamID = req.TeamId
+	}
+
+	err = p.startGame(game, req.ChannelId)
+	if err != nil {
+		dialogError(w, err.Error(), nil)
+		return
+	}
+	dialogOK(w)
+}
+
+// newGame creates a game with nQuestions random questions of the quiz.
+func newGame(quiz *Quiz, gm string, gameType GameType, scoring ScoringType, nQuestions int) (*Game, error) {
 	validQuestions := quiz.ValidQuestions()
+	if validQuestions == 0 {
+		return nil, errors.New("the quiz has no valid questions")
+	}
 
 	if nQuestions <= 0 {
 		nQuestions = validQuestions
@@ -619,13 +868,10 @@ func (p *Plugin) dialogGameStart(w http.ResponseWriter, r *http.Request, actingU
 		nQuestions = validQuestions
 	}
 
-	questions := quiz.Questions
-	if quiz.Type == QuizTypeMultipleChoice {
-		questions = []Question{}
-		for _, question := range quiz.Questions {
-			if len(question.IncorrectAnswers) >= IncorrectAnswerCount {
-				questions = append(questions, question)
-			}
+	questions := []Question{}
+	for _, question := range quiz.Questions {
+		if question.Validate() == nil {
+			questions = append(questions, question)
 		}
 	}
 
@@ -634,49 +880,64 @@ func (p *Plugin) dialogGameStart(w http.ResponseWriter, r *http.Request, actingU
 
 	game := &Game{
 		Quiz:               *quiz,
-		GM:                 actingUserID,
-		Score:              map[string]int{},
-		Type:               GameType(gameType),
-		ScoringType:        ScoringType(scoring),
+		GM:                 gm,
+		Score:              map[string]float64{},
+		Type:               gameType,
+		ScoringType:        scoring,
 		RemainingQuestions: questions[:nQuestions],
 		NQuestions:         nQuestions,
 		AlreadyAnswered:    map[string]bool{},
+		StartedAt:          model.GetMillis(),
 	}
 
-	if quiz.Type == QuizTypeMultipleChoice {
-		game.CurrentAnswers, game.CorrectAnswer = getRandomAnswers(game.RemainingQuestions[0])
-	}
+	game.shuffleAnswers()
+
+	return game, nil
+}
+
+// startGame posts the first question of the game and stores it. Solo games are played
+// in the bot DM with the GM, and party games in channelID.
+func (p *Plugin) startGame(game *Game, channelID string) error {
+	game.startQuestionClock()
 
 	post := &model.Post{
 		Message: "New quiz",
 	}
 
 	model.ParseSlackAttachment(post, p.GameAttachment(game))
-	if gameType == string(GameTypeSolo) {
-		err = p.mm.Post.DM(p.BotUserID, actingUserID, post)
+	if game.Type == GameTypeSolo {
+		err := p.mm.Post.DM(p.BotUserID, game.GM, post)
 		if err != nil {
-			dialogError(w, err.Error(), nil)
-			return
+			return err
 		}
 	} else {
-		post.ChannelId = req.ChannelId
+		post.ChannelId = channelID
 		post.UserId = p.BotUserID
-		err = p.mm.Post.CreatePost(post)
+		err := p.mm.Post.CreatePost(post)
 		if err != nil {
-			dialogError(w, err.Error(), nil)
-			return
+			return err
 		}
 	}
 
 	game.RootPostID = post.Id
 	game.CurrentPostID = post.Id
+	game.ChannelID = post.ChannelId
+
+	// The image URL of the question holds the game ID, only known once it is posted.
+	if game.RemainingQuestions[0].ImageFileID != "" {
+		model.ParseSlackAttachment(post, p.GameAttachment(game))
+		err := p.mm.Post.UpdatePost(post)
+		if err != nil {
+			p.mm.Log.Debug("Cannot show the image of the first question", "gameID", game.RootPostID, "err", err)
+		}
+	}
 
-	err = p.store.StoreGame(game)
+	err := p.store.StoreGame(game)
 	if err != nil {
-		dialogError(w, err.Error(), nil)
-		return
+		return err
 	}
-	dialogOK(w)
+
+	return p.scheduleQuestionTimer(game.RootPostID)
 }
 
 func (p *Plugin) dialogScore(w http.ResponseWriter, r *http.Request, actingUserID string) {
@@ -703,65 +964,181 @@ func (p *Plugin) dialogAnswer(w http.ResponseWriter, r *http.Request, actingUser
 		return
 	}
 
-	g, err := p.store.GetGame(id)
+	user, err := p.mm.User.Get(actingUserID)
 	if err != nil {
 		dialogError(w, err.Error(), nil)
 		return
 	}
 
-	if g == nil {
-		dialogError(w, "game not found", nil)
-		return
-	}
-
-	if qID != g.RemainingQuestions[0].ID {
-		dialogError(w, "this question has been already passed", nil)
-		return
-	}
-
-	user, err := p.mm.User.Get(actingUserID)
+	correct := false
+	g, err := p.store.UpdateGame(id, func(g *Game) error {
+		if current := g.CurrentQuestion(); current != nil {
+			correct = p.getAnswerMatcher().Match(current, answer)
+		}
+		g.AddPlayer(user.Id, user.Username)
+		return g.RecordAnswer(qID, user.Username, answer, correct)
+	})
 	if err != nil {
 		dialogError(w, err.Error(), nil)
 		return
 	}
 
-	if g.AlreadyAnswered[user.Username] {
-		dialogError(w, "you already tried to answer this question", nil)
+	if g == nil {
+		dialogError(w, "game not found", nil)
 		return
 	}
 
-	g.AlreadyAnswered[user.Username] = true
-
 	responseMessage := "Your answer is incorrect."
-	if answer == g.RemainingQuestions[0].CorrectAnswer {
+	if correct {
 		responseMessage = "You are correct!"
-		g.Score[user.Username] += 1
-		if g.ScoringType == ScoringTypeFirst && len(g.AlreadyAnswered) == 1 {
-			g.Score[user.Username] += 2
-		}
-		g.RightPlayers = append(g.RightPlayers, user.Username)
 	}
 
-	responsePost := &model.Post{
-		UserId:    p.BotUserID,
-		Message:   responseMessage,
-		ChannelId: req.ChannelId,
-	}
+	p.respondToDialogAnswer(w, g, id, qID, req.ChannelId, actingUserID, responseMessage)
+}
 
-	post, err := p.mm.Post.GetPost(g.CurrentPostID)
-	if err != nil {
-		dialogError(w, err.Error(), nil)
-		return
-	}
+func (p *Plugin) dialogSelectAnswers(w http.ResponseWriter, r *http.Request, actingUserID string) {
+	req := model.SubmitDialogRequestFromJson(r.Body)
+	state := strings.Split(req.State, ",")
+	if len(state) != 2 {
+		dialogError(w, "wrong state", nil)
+		return
+	}
+	id := state[0]
+	qID := state[1]
+
+	selected := []int{}
+	for field, value := range req.Submission {
+		if checked, _ := value.(bool); !checked || !strings.HasPrefix(field, DialogSubmissionFieldGameOption) {
+			continue
+		}
+
+		i, err := strconv.Atoi(strings.TrimPrefix(field, DialogSubmissionFieldGameOption))
+		if err == nil {
+			selected = append(selected, i)
+		}
+	}
+	sort.Ints(selected)
+
+	if len(selected) == 0 {
+		dialogError(w, "Select at least one option", nil)
+		return
+	}
+
+	user, err := p.mm.User.Get(actingUserID)
+	if err != nil {
+		dialogError(w, err.Error(), nil)
+		return
+	}
+
+	credit := 0.0
+	g, err := p.store.UpdateGame(id, func(g *Game) error {
+		g.AddPlayer(user.Id, user.Username)
+		var recordErr error
+		credit, recordErr = g.RecordSelectedAnswers(qID, user.Username, selected)
+		return recordErr
+	})
+	if err != nil {
+		dialogError(w, err.Error(), nil)
+		return
+	}
+
+	if g == nil {
+		dialogError(w, "game not found", nil)
+		return
+	}
+
+	responseMessage := "Your answer is incorrect."
+	switch {
+	case credit >= 1:
+		responseMessage = "You are correct!"
+	case credit > 0:
+		responseMessage = "Your answer is partially correct."
+	}
+
+	p.respondToDialogAnswer(w, g, id, qID, req.ChannelId, actingUserID, responseMessage)
+}
+
+func (p *Plugin) dialogOrderAnswers(w http.ResponseWriter, r *http.Request, actingUserID string) {
+	req := model.SubmitDialogRequestFromJson(r.Body)
+	state := strings.Split(req.State, ",")
+	if len(state) != 2 {
+		dialogError(w, "wrong state", nil)
+		return
+	}
+	id := state[0]
+	qID := state[1]
+
+	order := []int{}
+	seen := map[int]bool{}
+	for i := 0; ; i++ {
+		fieldName := DialogSubmissionFieldGamePosition + strconv.Itoa(i)
+		value, ok := req.Submission[fieldName].(string)
+		if !ok {
+			break
+		}
+
+		position, err := strconv.Atoi(value)
+		if err != nil {
+			dialogError(w, "Missing some value", map[string]string{fieldName: "Could not get the item"})
+			return
+		}
+		if seen[position] {
+			dialogError(w, "Every item must appear once", map[string]string{fieldName: "This item is repeated"})
+			return
+		}
+		seen[position] = true
+		order = append(order, position)
+	}
+
+	user, err := p.mm.User.Get(actingUserID)
+	if err != nil {
+		dialogError(w, err.Error(), nil)
+		return
+	}
+
+	correct := false
+	g, err := p.store.UpdateGame(id, func(g *Game) error {
+		g.AddPlayer(user.Id, user.Username)
+		var recordErr error
+		correct, recordErr = g.RecordOrderedAnswer(qID, user.Username, order)
+		return recordErr
+	})
+	if err != nil {
+		dialogError(w, err.Error(), nil)
+		return
+	}
+
+	if g == nil {
+		dialogError(w, "game not found", nil)
+		return
+	}
+
+	responseMessage := "Your answer is incorrect."
+	if correct {
+		responseMessage = "You are correct!"
+	}
+
+	p.respondToDialogAnswer(w, g, id, qID, req.ChannelId, actingUserID, responseMessage)
+}
+
+// respondToDialogAnswer refreshes the question post of party games, or passes to the
+// next question in solo games, and tells the user how the answer went.
+func (p *Plugin) respondToDialogAnswer(w http.ResponseWriter, g *Game, id, qID, channelID, actingUserID, message string) {
+	responsePost := &model.Post{
+		UserId:    p.BotUserID,
+		Message:   message,
+		ChannelId: channelID,
+	}
 
 	if g.Type == GameTypeParty {
-		model.ParseSlackAttachment(post, p.GameAttachment(g))
-		err = p.mm.Post.UpdatePost(post)
+		post, err := p.mm.Post.GetPost(g.CurrentPostID)
 		if err != nil {
 			dialogError(w, err.Error(), nil)
 			return
 		}
-		err = p.store.StoreGame(g)
+
+		model.ParseSlackAttachment(post, p.GameAttachment(g))
+		err = p.mm.Post.UpdatePost(post)
 		if err != nil {
 			dialogError(w, err.Error(), nil)
 			return
@@ -771,14 +1148,7 @@ func (p *Plugin) dialogAnswer(w http.ResponseWriter, r *http.Request, actingUser
 		return
 	}
 
-	model.ParseSlackAttachment(post, p.GameSolutionAttachment(g))
-	err = p.mm.Post.UpdatePost(post)
-	if err != nil {
-		dialogError(w, err.Error(), nil)
-		return
-	}
-
-	err = p.handleNextQuestion(g, req.ChannelId, actingUserID)
+	err := p.handleNextQuestion(id, qID, channelID, actingUserID)
 	if err != nil {
 		dialogError(w, err.Error(), nil)
 		return
@@ -812,8 +1182,9 @@ func (p *Plugin) dialogNameCourse(w http.ResponseWriter, r *http.Request, acting
 		dialogError(w, err.Error(), nil)
 		return
 	}
-	if c == nil {
-		dialogError(w, "course not found", nil)
+
+	if !p.canEditCourse(c, actingUserID) {
+		dialogError(w, ErrNotCourseEditor.Error(), nil)
 		return
 	}
 
@@ -857,8 +1228,9 @@ func (p *Plugin) dialogCourseDescription(w http.ResponseWriter, r *http.Request,
 		dialogError(w, err.Error(), nil)
 		return
 	}
-	if c == nil {
-		dialogError(w, "course not found", nil)
+
+	if !p.canEditCourse(c, actingUserID) {
+		dialogError(w, ErrNotCourseEditor.Error(), nil)
 		return
 	}
 
@@ -881,7 +1253,19 @@ func (p *Plugin) dialogCourseDescription(w http.ResponseWriter, r *http.Request,
 func (p *Plugin) dialogCourseDelete(w http.ResponseWriter, r *http.Request, actingUserID string) {
 	req := model.SubmitDialogRequestFromJson(r.Body)
 	id := req.State
-	err := p.mm.Post.DeletePost(id)
+
+	c, err := p.store.GetCourse(id)
+	if err != nil {
+		dialogError(w, err.Error(), nil)
+		return
+	}
+
+	if !p.canEditCourse(c, actingUserID) {
+		dialogError(w, ErrNotCourseEditor.Error(), nil)
+		return
+	}
+
+	err = p.mm.Post.DeletePost(id)
 	if err != nil {
 		dialogError(w, err.Error(), nil)
 		return
@@ -937,8 +1321,9 @@ func (p *Plugin) dialogAddLesson(w http.ResponseWriter, r *http.Request, actingU
 		dialogError(w, err.Error(), nil)
 		return
 	}
-	if c == nil {
-		dialogError(w, "course not found", nil)
+
+	if !p.canEditCourse(c, actingUserID) {
+		dialogError(w, ErrNotCourseEditor.Error(), nil)
 		return
 	}
 
@@ -994,8 +1379,9 @@ func (p *Plugin) dialogEditLesson(w http.ResponseWriter, r *http.Request, acting
 		dialogError(w, err.Error(), nil)
 		return
 	}
-	if c == nil {
-		dialogError(w, "course not found", nil)
+
+	if !p.canEditCourse(c, actingUserID) {
+		dialogError(w, ErrNotCourseEditor.Error(), nil)
 		return
 	}
 
@@ -1040,8 +1426,9 @@ func (p *Plugin) dialogNameLesson(w http.ResponseWriter, r *http.Request, acting
 		dialogError(w, err.Error(), nil)
 		return
 	}
-	if c == nil {
-		dialogError(w, "course not found", nil)
+
+	if !p.canEditCourse(c, actingUserID) {
+		dialogError(w, ErrNotCourseEditor.Error(), nil)
 		return
 	}
 
@@ -1090,8 +1477,9 @@ func (p *Plugin) dialogLessonIntroduction(w http.ResponseWriter, r *http.Request
 		dialogError(w, err.Error(), nil)
 		return
 	}
-	if c == nil {
-		dialogError(w, "course not found", nil)
+
+	if !p.canEditCourse(c, actingUserID) {
+		dialogError(w, ErrNotCourseEditor.Error(), nil)
 		return
 	}
 
@@ -1170,8 +1558,9 @@ func (p *Plugin) dialogAddResource(w http.ResponseWriter, r *http.Request, actin
 		dialogError(w, err.Error(), nil)
 		return
 	}
-	if c == nil {
-		dialogError(w, "course not found", nil)
+
+	if !p.canEditCourse(c, actingUserID) {
+		dialogError(w, ErrNotCourseEditor.Error(), nil)
 		return
 	}
 
@@ -1243,16 +1632,17 @@ func (p *Plugin) dialogAddQuizResource(w http.ResponseWriter, r *http.Request, a
 		return
 	}
 
-	quiz, err := p.store.GetQuiz(quizID)
-	if err != nil {
-		dialogError(w, err.Error(), nil)
-		return
-	}
-	if quiz == nil {
+	_, err = p.store.GetQuiz(quizID)
+	if isNotFound(err) {
 		errors := map[string]string{
 			DialogSubmissionFieldQuiz: "Quiz not found",
 		}
-		dialogError(w, "quiz not found", errors)
+		dialogError(w, err.Error(), errors)
+		return
+	}
+	if err != nil {
+		dialogError(w, err.Error(), nil)
+		return
 	}
 
 	c, err := p.store.GetCourse(id)
@@ -1260,8 +1650,9 @@ func (p *Plugin) dialogAddQuizResource(w http.ResponseWriter, r *http.Request, a
 		dialogError(w, err.Error(), nil)
 		return
 	}
-	if c == nil {
-		dialogError(w, "course not found", nil)
+
+	if !p.canEditCourse(c, actingUserID) {
+		dialogError(w, ErrNotCourseEditor.Error(), nil)
 		return
 	}
 
@@ -1308,8 +1699,9 @@ func (p *Plugin) dialogRemoveResources(w http.ResponseWriter, r *http.Request, a
 		dialogError(w, err.Error(), nil)
 		return
 	}
-	if c == nil {
-		dialogError(w, "course not found", nil)
+
+	if !p.canEditCourse(c, actingUserID) {
+		dialogError(w, ErrNotCourseEditor.Error(), nil)
 		return
 	}
 
@@ -1371,8 +1763,9 @@ func (p *Plugin) dialogLessonDelete(w http.ResponseWriter, r *http.Request, acti
 		dialogError(w, err.Error(), nil)
 		return
 	}
-	if c == nil {
-		dialogError(w, "course not found", nil)
+
+	if !p.canEditCourse(c, actingUserID) {
+		dialogError(w, ErrNotCourseEditor.Error(), nil)
 		return
 	}
 
@@ -1399,498 +1792,526 @@ func (p *Plugin) dialogLessonDelete(w http.ResponseWriter, r *http.Request, acti
 	dialogOK(w)
 }
 
-func (p *Plugin) attachmentNameQuiz(w http.ResponseWriter, r *http.Request, actingUserID string) {
-	req := model.PostActionIntegrationRequestFromJson(r.Body)
-	id := getQuizIDFromPostActionRequest(req)
+func (p *Plugin) dialogQuizEditors(w http.ResponseWriter, r *http.Request, actingUserID string) {
+	req := model.SubmitDialogRequestFromJson(r.Body)
+	id := req.State
 
 	q, err := p.store.GetQuiz(id)
 	if err != nil {
-		attachmentError(w, err.Error())
+		dialogError(w, err.Error(), nil)
 		return
 	}
 
-	defaultName := ""
-	if q != nil {
-		defaultName = q.Name
+	if !p.canManageQuizEditors(q, actingUserID) {
+		dialogError(w, "only the creator of the quiz can change its editors", nil)
+		return
 	}
 
-	err = p.mm.Frontend.OpenInteractiveDialog(model.OpenDialogRequest{
-		TriggerId: req.TriggerId,
-		URL:       p.getDialogURL() + DialogPathNameQuiz,
-		Dialog: model.Dialog{
-			Title:            "Name quiz",
-			IntroductionText: "Write the name of your Quiz",
-			SubmitLabel:      "Submit",
-			Elements: []model.DialogElement{
-				{
-					DisplayName: "Quiz name",
-					Name:        DialogSubmissionFieldName,
-					Type:        DialogTypeText,
-					Default:     defaultName,
-				},
-			},
-			State: id,
-		},
-	})
+	usernames, _ := req.Submission[DialogSubmissionFieldEditors].(string)
+	editors, err := p.getUserIDsFromUsernames(usernames)
 	if err != nil {
-		attachmentError(w, err.Error())
+		errors := map[string]string{
+			DialogSubmissionFieldEditors: err.Error(),
+		}
+		dialogError(w, "Invalid value", errors)
 		return
 	}
-	attachmentOK(w, "")
+
+	q.Editors = editors
+	err = p.storeQuizRevision(q, actingUserID)
+	if err != nil {
+		dialogError(w, err.Error(), nil)
+		return
+	}
+
+	dialogOK(w)
 }
 
-func (p *Plugin) attachmentChangeType(w http.ResponseWriter, r *http.Request, actingUserID string) {
-	req := model.PostActionIntegrationRequestFromJson(r.Body)
-	id := getQuizIDFromPostActionRequest(req)
+func (p *Plugin) dialogQuizScope(w http.ResponseWriter, r *http.Request, actingUserID string) {
+	req := model.SubmitDialogRequestFromJson(r.Body)
+	id, postID := getQuizIDAndPostIDFromState(req.State)
+	post, err := p.mm.Post.GetPost(postID)
+	if err != nil {
+		dialogError(w, err.Error(), nil)
+		return
+	}
 
 	q, err := p.store.GetQuiz(id)
 	if err != nil {
-		attachmentError(w, err.Error())
+		dialogError(w, err.Error(), nil)
 		return
 	}
-	if q == nil {
-		attachmentError(w, "quiz not found")
+
+	if !p.canEditQuiz(q, actingUserID) {
+		dialogError(w, ErrNotQuizEditor.Error(), nil)
 		return
 	}
 
-	dr := model.OpenDialogRequest{
-		TriggerId: req.TriggerId,
-		URL:       p.getDialogURL() + DialogPathChangeType,
-		Dialog: model.Dialog{
-			Title:            "Select type",
-			IntroductionText: "Select the type of quiz you want to create",
-			SubmitLabel:      "Submit",
-			Elements: []model.DialogElement{
-				{
-					DisplayName: "Quiz type",
-					Name:        DialogSubmissionFieldType,
-					Type:        DialogTypeSelect,
-					Default:     string(q.Type),
-					Options: []*model.PostActionOptions{
-						{
-							Text:  "Single answer",
-							Value: string(QuizTypeSingleAnswer),
-						},
-						{
-							Text:  "Multiple choice",
-							Value: string(QuizTypeMultipleChoice),
-						},
-					},
-				},
-			},
-			State: id,
-		},
+	if q.TeamID == "" {
+		q.TeamID = req.TeamId
 	}
 
-	if q.Type != "" {
-		dr.Dialog.IntroductionText += "\n\nWARNING: Changing the type may render some questions invalid. The questions will not be lost, but will not appear in the quiz."
+	names, _ := req.Submission[DialogSubmissionFieldChannels].(string)
+	channelIDs, err := p.getChannelIDsFromNames(q.TeamID, names)
+	if err != nil {
+		errors := map[string]string{
+			DialogSubmissionFieldChannels: err.Error(),
+		}
+		dialogError(w, "Invalid value", errors)
+		return
 	}
-	err = p.mm.Frontend.OpenInteractiveDialog(dr)
+
+	q.ChannelIDs = channelIDs
+	err = p.storeQuizRevision(q, actingUserID)
 	if err != nil {
-		attachmentError(w, err.Error())
+		dialogError(w, err.Error(), nil)
 		return
 	}
-	attachmentOK(w, "")
-}
 
-func (p *Plugin) attachmentDelete(w http.ResponseWriter, r *http.Request, actingUserID string) {
-	req := model.PostActionIntegrationRequestFromJson(r.Body)
-	id := getQuizIDFromPostActionRequest(req)
-	err := p.mm.Frontend.OpenInteractiveDialog(model.OpenDialogRequest{
-		TriggerId: req.TriggerId,
-		URL:       p.getDialogURL() + DialogPathDelete,
-		Dialog: model.Dialog{
-			Title:            "Cancel creation",
-			IntroductionText: "Are you sure you want to cancel the creation of this quiz? All changes will be lost.",
-			SubmitLabel:      "Delete",
-			State:            id,
-		},
-	})
+	model.ParseSlackAttachment(post, p.CreateAttachmentFromQuiz(q))
+	err = p.mm.Post.UpdatePost(post)
 	if err != nil {
-		attachmentError(w, err.Error())
+		dialogError(w, err.Error(), nil)
 		return
 	}
-	attachmentOK(w, "")
+	dialogOK(w)
 }
 
-func (p *Plugin) attachmentAddQuestion(w http.ResponseWriter, r *http.Request, actingUserID string) {
-	req := model.PostActionIntegrationRequestFromJson(r.Body)
-	id := getQuizIDFromPostActionRequest(req)
+func (p *Plugin) dialogQuizVisibility(w http.ResponseWriter, r *http.Request, actingUserID string) {
+	req := model.SubmitDialogRequestFromJson(r.Body)
+	id, postID := getQuizIDAndPostIDFromState(req.State)
+	post, err := p.mm.Post.GetPost(postID)
+	if err != nil {
+		dialogError(w, err.Error(), nil)
+		return
+	}
 
 	q, err := p.store.GetQuiz(id)
 	if err != nil {
-		attachmentError(w, err.Error())
+		dialogError(w, err.Error(), nil)
 		return
 	}
-	if q == nil {
-		attachmentError(w, "quiz not found")
+
+	if !p.canManageQuizEditors(q, actingUserID) {
+		dialogError(w, "only the creator of the quiz can change its visibility", nil)
 		return
 	}
 
-	wrongAnswerElements := []model.DialogElement{}
-	if q.Type == QuizTypeMultipleChoice {
-		for i := 0; i < IncorrectAnswerCount; i++ {
-			e := model.DialogElement{
-				DisplayName: "Incorrect Answer",
-				Name:        DialogSubmissionFieldWrongAnswer + strconv.Itoa(i),
-				Type:        DialogTypeText,
-			}
-			wrongAnswerElements = append(wrongAnswerElements, e)
-		}
+	visibility, sharedWith, sharedGroups, errors := p.getVisibilityFromSubmission(req.Submission)
+	if errors != nil {
+		dialogError(w, "Invalid value", errors)
+		return
 	}
 
-	dr := model.OpenDialogRequest{
-		TriggerId: req.TriggerId,
-		URL:       p.getDialogURL() + DialogPathAddQuestion,
-		Dialog: model.Dialog{
-			Title:            "Add Question",
-			IntroductionText: "Write the question to add",
-			SubmitLabel:      "Add",
-			Elements: []model.DialogElement{
-				{
-					DisplayName: "Question",
-					Name:        DialogSubmissionFieldQuestion,
-					Type:        DialogTypeText,
-				},
-				{
-					DisplayName: "Answer",
-					Name:        DialogSubmissionFieldAnswer,
-					Type:        DialogTypeText,
-				},
-			},
-			State: id,
-		},
+	q.Visibility = visibility
+	q.SharedWith = sharedWith
+	q.SharedGroups = sharedGroups
+	err = p.storeQuizRevision(q, actingUserID)
+	if err != nil {
+		dialogError(w, err.Error(), nil)
+		return
 	}
 
-	dr.Dialog.Elements = append(dr.Dialog.Elements, wrongAnswerElements...)
-	err = p.mm.Frontend.OpenInteractiveDialog(dr)
+	model.ParseSlackAttachment(post, p.CreateAttachmentFromQuiz(q))
+	err = p.mm.Post.UpdatePost(post)
 	if err != nil {
-		attachmentError(w, err.Error())
+		dialogError(w, err.Error(), nil)
 		return
 	}
-	attachmentOK(w, "")
+	dialogOK(w)
 }
 
-func (p *Plugin) attachmentReviewQuestions(w http.ResponseWriter, r *http.Request, actingUserID string) {
-	req := model.PostActionIntegrationRequestFromJson(r.Body)
-	id := getQuizIDFromPostActionRequest(req)
+func (p *Plugin) dialogCourseEditors(w http.ResponseWriter, r *http.Request, actingUserID string) {
+	req := model.SubmitDialogRequestFromJson(r.Body)
+	id := req.State
 
-	q, err := p.store.GetQuiz(id)
+	c, err := p.store.GetCourse(id)
 	if err != nil {
-		attachmentError(w, err.Error())
+		dialogError(w, err.Error(), nil)
 		return
 	}
-	if q == nil {
-		attachmentError(w, "quiz not found")
+
+	if !p.canManageCourseEditors(c, actingUserID) {
+		dialogError(w, "only the creator of the course can change its editors", nil)
 		return
 	}
 
-	questions := ""
-	for _, question := range q.Questions {
-		questions += "\n\n" + Separator
-		if q.Type == QuizTypeMultipleChoice && len(question.IncorrectAnswers) < IncorrectAnswerCount {
-			questions += "\nWARNING: Invalid question\n"
-		}
-		questions += fmt.Sprintf("\nQuestion: %s\n\nCorrect Answer: %s", question.Question, question.CorrectAnswer)
-		if q.Type == QuizTypeMultipleChoice {
-			questions += "\n\nIncorrect Answers:"
-			for _, answer := range question.IncorrectAnswers {
-				questions += "\n\n" + answer
-			}
+	usernames, _ := req.Submission[DialogSubmissionFieldEditors].(string)
+	editors, err := p.getUserIDsFromUsernames(usernames)
+	if err != nil {
+		errors := map[string]string{
+			DialogSubmissionFieldEditors: err.Error(),
 		}
+		dialogError(w, "Invalid value", errors)
+		return
 	}
 
-	err = p.mm.Frontend.OpenInteractiveDialog(model.OpenDialogRequest{
-		TriggerId: req.TriggerId,
-		URL:       p.getDialogURL() + DialogPathReviewQuestions,
-		Dialog: model.Dialog{
-			Title:            "Review questions",
-			IntroductionText: "These are all the questions registered:\n" + questions,
-			SubmitLabel:      "OK",
-			State:            id,
-		},
-	})
+	c.Editors = editors
+	err = p.store.StoreCourse(c)
 	if err != nil {
-		attachmentError(w, err.Error())
+		dialogError(w, err.Error(), nil)
 		return
 	}
-	attachmentOK(w, "")
+
+	dialogOK(w)
 }
 
-func (p *Plugin) attachmentRemoveQuestions(w http.ResponseWriter, r *http.Request, actingUserID string) {
-	req := model.PostActionIntegrationRequestFromJson(r.Body)
-	id := getQuizIDFromPostActionRequest(req)
+func (p *Plugin) dialogCourseScope(w http.ResponseWriter, r *http.Request, actingUserID string) {
+	req := model.SubmitDialogRequestFromJson(r.Body)
+	id := req.State
+	post, err := p.mm.Post.GetPost(id)
+	if err != nil {
+		dialogError(w, err.Error(), nil)
+		return
+	}
 
-	q, err := p.store.GetQuiz(id)
+	c, err := p.store.GetCourse(id)
 	if err != nil {
-		attachmentError(w, err.Error())
+		dialogError(w, err.Error(), nil)
 		return
 	}
-	if q == nil {
-		attachmentError(w, "quiz not found")
+
+	if !p.canEditCourse(c, actingUserID) {
+		dialogError(w, ErrNotCourseEditor.Error(), nil)
 		return
 	}
 
-	elements := []model.DialogElement{}
-	for _, question := range q.Questions {
-		element := model.DialogElement{
-			DisplayName: question.Question,
-			Name:        question.ID,
-			Type:        DialogTypeBool,
-			Optional:    true,
-		}
-		text := ""
-		if q.Type == QuizTypeMultipleChoice && len(question.IncorrectAnswers) < IncorrectAnswerCount {
-			text += "WARNING: Invalid question; "
-		}
-		text += fmt.Sprintf("Correct Answer: %s", question.CorrectAnswer)
-		if q.Type == QuizTypeMultipleChoice {
-			text += "; Incorrect Answers:"
-			firstRun := true
-			for _, answer := range question.IncorrectAnswers {
-				if !firstRun {
-					text += ";"
-				}
-				text += " " + answer
-				firstRun = false
-			}
-		}
-		element.HelpText = text
-		elements = append(elements, element)
+	if c.TeamID == "" {
+		c.TeamID = req.TeamId
 	}
 
-	err = p.mm.Frontend.OpenInteractiveDialog(model.OpenDialogRequest{
-		TriggerId: req.TriggerId,
-		URL:       p.getDialogURL() + DialogPathRemoveQuestion,
-		Dialog: model.Dialog{
-			Title:            "Remove questions",
-			IntroductionText: "Select the questions to delete.",
-			SubmitLabel:      "Remove selected",
-			State:            id,
-			Elements:         elements,
-		},
-	})
+	names, _ := req.Submission[DialogSubmissionFieldChannels].(string)
+	channelIDs, err := p.getChannelIDsFromNames(c.TeamID, names)
 	if err != nil {
-		attachmentError(w, err.Error())
+		errors := map[string]string{
+			DialogSubmissionFieldChannels: err.Error(),
+		}
+		dialogError(w, "Invalid value", errors)
 		return
 	}
-	attachmentOK(w, "")
-}
-
-func (p *Plugin) attachmentSave(w http.ResponseWriter, r *http.Request, actingUserID string) {
-	req := model.PostActionIntegrationRequestFromJson(r.Body)
-	id := getQuizIDFromPostActionRequest(req)
 
-	q, err := p.store.GetQuiz(id)
+	c.ChannelIDs = channelIDs
+	err = p.store.StoreCourse(c)
 	if err != nil {
-		attachmentError(w, err.Error())
+		dialogError(w, err.Error(), nil)
 		return
 	}
-	if q == nil {
-		attachmentError(w, "quiz not found")
+
+	model.ParseSlackAttachment(post, p.CreateAttachmentFromCourse(c))
+	err = p.mm.Post.UpdatePost(post)
+	if err != nil {
+		dialogError(w, err.Error(), nil)
 		return
 	}
+	dialogOK(w)
+}
 
-	if q.ValidQuestions() == 0 {
-		attachmentError(w, "cannot save a quiz with no valid questions")
+func (p *Plugin) dialogCourseVisibility(w http.ResponseWriter, r *http.Request, actingUserID string) {
+	req := model.SubmitDialogRequestFromJson(r.Body)
+	id := req.State
+	post, err := p.mm.Post.GetPost(id)
+	if err != nil {
+		dialogError(w, err.Error(), nil)
 		return
 	}
 
-	err = p.store.AddAvailableQuiz(q)
+	c, err := p.store.GetCourse(id)
 	if err != nil {
-		attachmentError(w, err.Error())
+		dialogError(w, err.Error(), nil)
 		return
 	}
 
-	p.GrantBadge(AchievementNameContentCreator, actingUserID)
-
-	resp := model.PostActionIntegrationResponse{
-		Update: &model.Post{
-			Message: fmt.Sprintf("Quiz `%s` saved and ready to use.", q.Name),
-			Props:   model.StringInterface{},
-		},
+	if !p.canManageCourseEditors(c, actingUserID) {
+		dialogError(w, "only the creator of the course can change its visibility", nil)
+		return
 	}
-	_, _ = w.Write(resp.ToJson())
-}
-
-func (p *Plugin) attachmentAnswer(w http.ResponseWriter, r *http.Request, actingUserID string) {
-	req := model.PostActionIntegrationRequestFromJson(r.Body)
-	id := getGameIDFromPostActionRequest(req)
 
-	g, err := p.store.GetGame(id)
-	if err != nil {
-		attachmentError(w, err.Error())
+	visibility, sharedWith, sharedGroups, errors := p.getVisibilityFromSubmission(req.Submission)
+	if errors != nil {
+		dialogError(w, "Invalid value", errors)
 		return
 	}
 
-	if g == nil {
-		attachmentError(w, "game not found")
+	c.Visibility = visibility
+	c.SharedWith = sharedWith
+	c.SharedGroups = sharedGroups
+	err = p.store.StoreCourse(c)
+	if err != nil {
+		dialogError(w, err.Error(), nil)
 		return
 	}
 
-	qID, ok := req.Context[AttachmentContextFieldQuestionID].(string)
-	if !ok || id == "" {
-		attachmentError(w, "cannot find question ID")
+	model.ParseSlackAttachment(post, p.CreateAttachmentFromCourse(c))
+	err = p.mm.Post.UpdatePost(post)
+	if err != nil {
+		dialogError(w, err.Error(), nil)
 		return
 	}
+	dialogOK(w)
+}
 
-	if qID != g.RemainingQuestions[0].ID {
-		attachmentError(w, "mismatch between post and internal state")
+func (p *Plugin) dialogEditQuiz(w http.ResponseWriter, r *http.Request, actingUserID string) {
+	req := model.SubmitDialogRequestFromJson(r.Body)
+
+	quizID, ok := req.Submission[DialogSubmissionFieldQuiz].(string)
+	quizID = strings.TrimSpace(quizID)
+	if !ok || quizID == "" {
+		errors := map[string]string{
+			DialogSubmissionFieldQuiz: "Could not get quiz",
+		}
+		dialogError(w, "Missing some value", errors)
 		return
 	}
 
-	user, err := p.mm.User.Get(actingUserID)
+	q, err := p.store.GetQuiz(quizID)
 	if err != nil {
-		attachmentError(w, err.Error())
+		dialogError(w, err.Error(), nil)
 		return
 	}
 
-	if g.AlreadyAnswered[user.Username] {
-		attachmentError(w, "you already tried to answer this question")
+	if !p.canEditQuiz(q, actingUserID) {
+		dialogError(w, ErrNotQuizEditor.Error(), nil)
 		return
 	}
 
-	err = p.mm.Frontend.OpenInteractiveDialog(model.OpenDialogRequest{
-		TriggerId: req.TriggerId,
-		URL:       p.getDialogURL() + DialogPathAnswer,
-		Dialog: model.Dialog{
-			Title:            "Answer",
-			IntroductionText: g.RemainingQuestions[0].Question,
-			SubmitLabel:      "Submit",
-			Elements: []model.DialogElement{
-				{
-					DisplayName: "Your answer",
-					Name:        DialogSubmissionFieldGameAnswer,
-					Type:        DialogTypeText,
-				},
-			},
-			State: strings.Join([]string{id, qID}, ","),
-		},
-	})
+	post := &model.Post{
+		Message: "Editing quiz",
+	}
+	model.ParseSlackAttachment(post, p.CreateAttachmentFromQuiz(q))
+
+	err = p.mm.Post.DM(p.BotUserID, actingUserID, post)
 	if err != nil {
-		attachmentError(w, err.Error())
+		dialogError(w, err.Error(), nil)
 		return
 	}
-	attachmentOK(w, "")
+
+	dialogOK(w)
 }
 
-func (p *Plugin) attachmentSelectAnswer(w http.ResponseWriter, r *http.Request, actingUserID string) {
-	req := model.PostActionIntegrationRequestFromJson(r.Body)
-	id := getGameIDFromPostActionRequest(req)
+func (p *Plugin) dialogExportQuiz(w http.ResponseWriter, r *http.Request, actingUserID string) {
+	req := model.SubmitDialogRequestFromJson(r.Body)
 
-	g, err := p.store.GetGame(id)
-	if err != nil {
-		attachmentError(w, err.Error())
+	quizID, ok := req.Submission[DialogSubmissionFieldQuiz].(string)
+	quizID = strings.TrimSpace(quizID)
+	if !ok || quizID == "" {
+		errors := map[string]string{
+			DialogSubmissionFieldQuiz: "Could not get quiz",
+		}
+		dialogError(w, "Missing some value", errors)
 		return
 	}
 
-	if g == nil {
-		attachmentError(w, "game not found")
+	q, err := p.store.GetQuiz(quizID)
+	if err != nil {
+		dialogError(w, err.Error(), nil)
 		return
 	}
 
-	qID, ok := req.Context[AttachmentContextFieldQuestionID].(string)
-	if !ok || id == "" {
-		attachmentError(w, "cannot find question ID")
+	err = p.exportQuiz(q, actingUserID)
+	if err != nil {
+		dialogError(w, err.Error(), nil)
 		return
 	}
 
-	if qID != g.RemainingQuestions[0].ID {
-		attachmentError(w, "mismatch between post and internal state")
-		return
-	}
+	dialogOK(w)
+}
 
-	user, err := p.mm.User.Get(actingUserID)
-	if err != nil {
-		attachmentError(w, err.Error())
-		return
+// exportQuiz sends the quiz as JSON and CSV files to the user. Only editors can export
+// a quiz, since the export includes the correct answers.
+func (p *Plugin) exportQuiz(q *Quiz, userID string) error {
+	if !p.canEditQuiz(q, userID) {
+		return ErrNotQuizEditor
 	}
 
-	if g.AlreadyAnswered[user.Username] {
-		attachmentError(w, "you already tried to answer this question")
-		return
+	files, err := getQuizExportFiles(q)
+	if err != nil {
+		return err
 	}
 
-	g.AlreadyAnswered[user.Username] = true
+	return p.sendExportFiles(userID, fmt.Sprintf("Export of the quiz `%s`", q.Name), files)
+}
 
-	correctAnswer, ok := req.Context[AttachmentContextFieldCorrect].(bool)
-	if !ok {
-		attachmentError(w, "cannot find whether is the correct answer")
+func (p *Plugin) dialogEditQuestion(w http.ResponseWriter, r *http.Request, actingUserID string) {
+	req := model.SubmitDialogRequestFromJson(r.Body)
+	id, postID := getQuizIDAndPostIDFromState(req.State)
+	post, err := p.mm.Post.GetPost(postID)
+	if err != nil {
+		dialogError(w, err.Error(), nil)
 		return
 	}
 
-	responseMessage := "Your answer is incorrect."
-	if correctAnswer {
-
-		responseMessage = "You are correct!"
-		g.Score[user.Username] += 1
-		if g.ScoringType == ScoringTypeFirst && len(g.AlreadyAnswered) == 1 {
-			g.Score[user.Username] += 2
+	questionID, ok := req.Submission[DialogSubmissionFieldQuestion].(string)
+	if !ok || questionID == "" {
+		errors := map[string]string{
+			DialogSubmissionFieldQuestion: "Invalid question",
 		}
-		g.RightPlayers = append(g.RightPlayers, user.Username)
+		dialogError(w, "Missing some value", errors)
+		return
 	}
 
-	post, err := p.mm.Post.GetPost(req.PostId)
+	q, err := p.store.GetQuiz(id)
 	if err != nil {
-		attachmentError(w, err.Error())
+		dialogError(w, err.Error(), nil)
 		return
 	}
 
-	if g.Type == GameTypeParty {
-		model.ParseSlackAttachment(post, p.GameAttachment(g))
-		err = p.mm.Post.UpdatePost(post)
-		if err != nil {
-			attachmentError(w, err.Error())
-			return
-		}
-		err = p.store.StoreGame(g)
-		if err != nil {
-			attachmentError(w, err.Error())
-			return
+	if !p.canEditQuiz(q, actingUserID) {
+		dialogError(w, ErrNotQuizEditor.Error(), nil)
+		return
+	}
+
+	model.ParseSlackAttachment(post, p.CreateQuestionAttachmentFromQuiz(q, questionID))
+	err = p.mm.Post.UpdatePost(post)
+	if err != nil {
+		dialogError(w, err.Error(), nil)
+		return
+	}
+	dialogOK(w)
+}
+
+func (p *Plugin) dialogUpdateQuestion(w http.ResponseWriter, r *http.Request, actingUserID string) {
+	req := model.SubmitDialogRequestFromJson(r.Body)
+	id, postID := getQuizIDAndPostIDFromState(req.State)
+	questionID := getQuestionIDFromState(req.State)
+	post, err := p.mm.Post.GetPost(postID)
+	if err != nil {
+		dialogError(w, err.Error(), nil)
+		return
+	}
+
+	q, err := p.store.GetQuiz(id)
+	if err != nil {
+		dialogError(w, err.Error(), nil)
+		return
+	}
+
+	if !p.canEditQuiz(q, actingUserID) {
+		dialogError(w, ErrNotQuizEditor.Error(), nil)
+		return
+	}
+
+	index := -1
+	for i, question := range q.Questions {
+		if question.ID == questionID {
+			index = i
+			break
 		}
-		attachmentOK(w, responseMessage)
+	}
+
+	if index < 0 {
+		dialogError(w, "Cannot find this question. Please hit the back button.", nil)
+		return
+	}
+
+	updatedQuestion, errors := getQuestionFromSubmission(q.Questions[index].Type, req.Submission)
+	if errors != nil {
+		dialogError(w, "Missing some value", errors)
 		return
 	}
+	updatedQuestion.ID = questionID
+	updatedQuestion.ImageFileID = q.Questions[index].ImageFileID
+	q.Questions[index] = updatedQuestion
 
-	model.ParseSlackAttachment(post, p.GameSolutionAttachment(g))
+	err = p.storeQuizRevision(q, actingUserID)
+	if err != nil {
+		dialogError(w, err.Error(), nil)
+		return
+	}
+
+	model.ParseSlackAttachment(post, p.CreateQuestionAttachmentFromQuiz(q, questionID))
 	err = p.mm.Post.UpdatePost(post)
 	if err != nil {
-		attachmentError(w, err.Error())
+		dialogError(w, err.Error(), nil)
+		return
+	}
+	dialogOK(w)
+}
+
+func (p *Plugin) dialogCourseStart(w http.ResponseWriter, r *http.Request, actingUserID string) {
+	req := model.SubmitDialogRequestFromJson(r.Body)
+
+	courseID, ok := req.Submission[DialogSubmissionFieldCourse].(string)
+	courseID = strings.TrimSpace(courseID)
+	if !ok || courseID == "" {
+		errors := map[string]string{
+			DialogSubmissionFieldCourse: "Could not get course",
+		}
+		dialogError(w, "Missing some value", errors)
 		return
 	}
 
-	err = p.handleNextQuestion(g, req.ChannelId, actingUserID)
+	c, err := p.store.GetCourse(courseID)
 	if err != nil {
-		attachmentError(w, err.Error())
+		dialogError(w, err.Error(), nil)
 		return
 	}
 
-	attachmentOK(w, responseMessage)
+	if !p.canTakeCourse(c, actingUserID, req.TeamId) {
+		errors := map[string]string{
+			DialogSubmissionFieldCourse: "You cannot take this course",
+		}
+		dialogError(w, "You cannot take this course", errors)
+		return
+	}
+
+	if len(c.Lessons) == 0 {
+		dialogError(w, "the course has no lessons", nil)
+		return
+	}
+
+	index := 0
+	progress, err := p.store.GetCourseProgress(actingUserID, c.ID)
+	if err != nil {
+		dialogError(w, err.Error(), nil)
+		return
+	}
+	message := fmt.Sprintf("Welcome to the course `%s`!\n\n%s", c.Name, c.Description)
+	if progress != nil && !progress.Completed && progress.CurrentLesson > 0 && progress.CurrentLesson < len(c.Lessons) {
+		index = progress.CurrentLesson
+		message = fmt.Sprintf("Welcome back to the course `%s`! Let's continue where you left off.", c.Name)
+	}
+
+	err = p.postCourseLesson(c, index, actingUserID, message)
+	if err != nil {
+		dialogError(w, err.Error(), nil)
+		return
+	}
+	dialogOK(w)
 }
 
-func (p *Plugin) attachmentScore(w http.ResponseWriter, r *http.Request, actingUserID string) {
+func (p *Plugin) attachmentNameQuiz(w http.ResponseWriter, r *http.Request, actingUserID string) {
 	req := model.PostActionIntegrationRequestFromJson(r.Body)
-	id := getGameIDFromPostActionRequest(req)
+	id := getQuizIDFromPostActionRequest(req)
 
-	g, err := p.store.GetGame(id)
+	q, err := p.store.GetQuiz(id)
 	if err != nil {
 		attachmentError(w, err.Error())
 		return
 	}
 
-	if g == nil {
-		attachmentError(w, "game not found")
+	if !p.canEditQuiz(q, actingUserID) {
+		attachmentError(w, ErrNotQuizEditor.Error())
 		return
 	}
 
+	defaultName := q.Name
+
 	err = p.mm.Frontend.OpenInteractiveDialog(model.OpenDialogRequest{
 		TriggerId: req.TriggerId,
-		URL:       p.getDialogURL() + DialogPathScore,
+		URL:       p.getDialogURL() + DialogPathNameQuiz,
 		Dialog: model.Dialog{
-			Title:            "Score",
-			IntroductionText: getScores(g),
-			SubmitLabel:      "OK",
-			State:            id,
+			Title:            "Name quiz",
+			IntroductionText: "Write the name of your Quiz",
+			SubmitLabel:      "Submit",
+			Elements: []model.DialogElement{
+				{
+					DisplayName: "Quiz name",
+					Name:        DialogSubmissionFieldName,
+					Type:        DialogTypeText,
+					Default:     defaultName,
+				},
+			},
+			State: getQuizDialogState(id, req.PostId),
 		},
 	})
 	if err != nil {
@@ -1900,112 +2321,1519 @@ func (p *Plugin) attachmentScore(w http.ResponseWriter, r *http.Request, actingU
 	attachmentOK(w, "")
 }
 
-func (p *Plugin) attachmentNext(w http.ResponseWriter, r *http.Request, actingUserID string) {
+func (p *Plugin) attachmentQuizDetails(w http.ResponseWriter, r *http.Request, actingUserID string) {
 	req := model.PostActionIntegrationRequestFromJson(r.Body)
-	id := getGameIDFromPostActionRequest(req)
+	id := getQuizIDFromPostActionRequest(req)
 
-	g, err := p.store.GetGame(id)
+	q, err := p.store.GetQuiz(id)
 	if err != nil {
 		attachmentError(w, err.Error())
 		return
 	}
 
-	if g == nil {
-		attachmentError(w, "game not found")
+	if !p.canEditQuiz(q, actingUserID) {
+		attachmentError(w, ErrNotQuizEditor.Error())
 		return
 	}
 
-	if g.GM != actingUserID {
-		attachmentError(w, "only the person who created the quiz can pass to the next question")
+	err = p.mm.Frontend.OpenInteractiveDialog(model.OpenDialogRequest{
+		TriggerId: req.TriggerId,
+		URL:       p.getDialogURL() + DialogPathQuizDetails,
+		Dialog: model.Dialog{
+			Title:            "Quiz details",
+			IntroductionText: "The details help people find the quiz with `/quiz list` and `/quiz start`.",
+			SubmitLabel:      "Submit",
+			Elements: []model.DialogElement{
+				{
+					DisplayName: "Category",
+					Name:        DialogSubmissionFieldCategory,
+					Type:        DialogTypeText,
+					Default:     q.Category,
+					Optional:    true,
+				},
+				{
+					DisplayName: "Tags",
+					Name:        DialogSubmissionFieldTags,
+					Type:        DialogTypeText,
+					Default:     strings.Join(q.Tags, ", "),
+					HelpText:    "Separated by commas, like: history, europe.",
+					Optional:    true,
+				},
+				{
+					DisplayName: "Difficulty",
+					Name:        DialogSubmissionFieldDifficulty,
+					Type:        DialogTypeSelect,
+					Default:     string(q.Difficulty),
+					Optional:    true,
+					Options: []*model.PostActionOptions{
+						{Text: "Easy", Value: string(QuizDifficultyEasy)},
+						{Text: "Medium", Value: string(QuizDifficultyMedium)},
+						{Text: "Hard", Value: string(QuizDifficultyHard)},
+					},
+				},
+			},
+			State: getQuizDialogState(id, req.PostId),
+		},
+	})
+	if err != nil {
+		attachmentError(w, err.Error())
 		return
 	}
+	attachmentOK(w, "")
+}
 
-	qID, ok := req.Context[AttachmentContextFieldQuestionID].(string)
-	if !ok || id == "" {
-		attachmentError(w, "cannot find question ID")
+func (p *Plugin) attachmentChangeType(w http.ResponseWriter, r *http.Request, actingUserID string) {
+	req := model.PostActionIntegrationRequestFromJson(r.Body)
+	id := getQuizIDFromPostActionRequest(req)
+
+	q, err := p.store.GetQuiz(id)
+	if err != nil {
+		attachmentError(w, err.Error())
 		return
 	}
 
-	if qID != g.RemainingQuestions[0].ID {
-		attachmentError(w, "mismatch between post and internal state")
+	if !p.canEditQuiz(q, actingUserID) {
+		attachmentError(w, ErrNotQuizEditor.Error())
 		return
 	}
 
-	post, err := p.mm.Post.GetPost(req.PostId)
+	dr := model.OpenDialogRequest{
+		TriggerId: req.TriggerId,
+		URL:       p.getDialogURL() + DialogPathChangeType,
+		Dialog: model.Dialog{
+			Title:            "Select type",
+			IntroductionText: "Select the default type of the questions of the quiz. Each question can still have a different type.",
+			SubmitLabel:      "Submit",
+			Elements: []model.DialogElement{
+				{
+					DisplayName: "Default question type",
+					Name:        DialogSubmissionFieldType,
+					Type:        DialogTypeSelect,
+					Default:     string(q.Type),
+					Options:     getQuizTypeOptions(),
+				},
+				{
+					DisplayName: "Multi-select scoring",
+					Name:        DialogSubmissionFieldMultiSelectScore,
+					Type:        DialogTypeSelect,
+					Default:     string(q.MultiSelectScoring),
+					HelpText:    "Only used by multi-select questions.",
+					Optional:    true,
+					Options: []*model.PostActionOptions{
+						{
+							Text:  "All or nothing",
+							Value: string(MultiSelectScoringAllOrNothing),
+						},
+						{
+							Text:  "Partial credit",
+							Value: string(MultiSelectScoringPartial),
+						},
+					},
+				},
+			},
+			State: getQuizDialogState(id, req.PostId),
+		},
+	}
+
+	err = p.mm.Frontend.OpenInteractiveDialog(dr)
 	if err != nil {
 		attachmentError(w, err.Error())
 		return
 	}
+	attachmentOK(w, "")
+}
 
-	model.ParseSlackAttachment(post, p.GameSolutionAttachment(g))
-	err = p.mm.Post.UpdatePost(post)
+func (p *Plugin) attachmentDelete(w http.ResponseWriter, r *http.Request, actingUserID string) {
+	req := model.PostActionIntegrationRequestFromJson(r.Body)
+	id := getQuizIDFromPostActionRequest(req)
+
+	q, err := p.store.GetQuiz(id)
 	if err != nil {
 		attachmentError(w, err.Error())
 		return
 	}
 
-	err = p.handleNextQuestion(g, req.ChannelId, actingUserID)
+	if !p.canEditQuiz(q, actingUserID) {
+		attachmentError(w, ErrNotQuizEditor.Error())
+		return
+	}
+
+	dr := model.OpenDialogRequest{
+		TriggerId: req.TriggerId,
+		URL:       p.getDialogURL() + DialogPathDelete,
+		Dialog: model.Dialog{
+			Title:            "Cancel creation",
+			IntroductionText: "Are you sure you want to cancel the creation of this quiz? All changes will be lost.",
+			SubmitLabel:      "Delete",
+			State:            getQuizDialogState(id, req.PostId),
+		},
+	}
+
+	if p.isAvailableQuiz(id) {
+		dr.Dialog.Title = "Delete quiz"
+		dr.Dialog.IntroductionText = "This quiz is already saved. Are you sure you want to delete it? It will not be available anymore."
+	}
+
+	err = p.mm.Frontend.OpenInteractiveDialog(dr)
 	if err != nil {
 		attachmentError(w, err.Error())
 		return
 	}
-
 	attachmentOK(w, "")
 }
 
-func (p *Plugin) handleNextQuestion(g *Game, channelID, actingUserID string) error {
-	post := &model.Post{
-		UserId:    p.BotUserID,
-		ChannelId: channelID,
-		RootId:    g.RootPostID,
-		Message:   "Next question!",
-	}
+func (p *Plugin) attachmentAddQuestion(w http.ResponseWriter, r *http.Request, actingUserID string) {
+	req := model.PostActionIntegrationRequestFromJson(r.Body)
+	id := getQuizIDFromPostActionRequest(req)
 
-	g.RemainingQuestions = g.RemainingQuestions[1:]
+	q, err := p.store.GetQuiz(id)
+	if err != nil {
+		attachmentError(w, err.Error())
+		return
+	}
 
-	if len(g.RemainingQuestions) == 0 {
-		post.Message = "Quiz finished!"
-		model.ParseSlackAttachment(post, p.GameEndAttachment(g))
-		err := p.mm.Post.CreatePost(post)
-		if err != nil {
-			return err
-		}
+	if !p.canEditQuiz(q, actingUserID) {
+		attachmentError(w, ErrNotQuizEditor.Error())
+		return
+	}
 
-		if g.Type == GameTypeSolo {
-			p.GrantBadge(AchievementNameHardWorker, actingUserID)
-		}
+	questionType := q.Type
+	if selected, _ := req.Context[AttachmentContextFieldSelected].(string); selected != "" {
+		questionType = QuizType(selected)
+	}
 
-		if g.Type == GameTypeParty {
-			rows := getScoreRows(g)
-			if len(rows) > 0 {
-				user, err := p.mm.User.GetByUsername(rows[0].name)
-				if err == nil {
-					p.GrantBadge(AchievementNameWinner, user.Id)
-				}
-			}
-		}
-		return p.store.DeleteGame(g.RootPostID)
+	if !questionType.IsValid() {
+		attachmentError(w, "select the type of the question")
+		return
+	}
 
+	dr := model.OpenDialogRequest{
+		TriggerId: req.TriggerId,
+		URL:       p.getDialogURL() + DialogPathAddQuestion,
+		Dialog: model.Dialog{
+			Title:            "Add Question",
+			IntroductionText: fmt.Sprintf("Write the question to add. Type: %s", getQuizTypeName(questionType)),
+			SubmitLabel:      "Add",
+			Elements:         getQuestionDialogElements(&Question{Type: questionType}),
+			State:            getQuestionDialogState(id, req.PostId, "", questionType),
+		},
 	}
 
-	g.CurrentAnswers, g.CorrectAnswer = getRandomAnswers(g.RemainingQuestions[0])
-	g.AlreadyAnswered = map[string]bool{}
-	g.RightPlayers = []string{}
+	err = p.mm.Frontend.OpenInteractiveDialog(dr)
+	if err != nil {
+		attachmentError(w, err.Error())
+		return
+	}
+	attachmentOK(w, "")
+}
 
-	model.ParseSlackAttachment(post, p.GameAttachment(g))
-	err := p.mm.Post.CreatePost(post)
+func (p *Plugin) attachmentReviewQuestions(w http.ResponseWriter, r *http.Request, actingUserID string) {
+	req := model.PostActionIntegrationRequestFromJson(r.Body)
+	id := getQuizIDFromPostActionRequest(req)
+
+	q, err := p.store.GetQuiz(id)
+	if err != nil {
+		attachmentError(w, err.Error())
+		return
+	}
+
+	if !p.canEditQuiz(q, actingUserID) {
+		attachmentError(w, ErrNotQuizEditor.Error())
+		return
+	}
+
+	questions := ""
+	for _, question := range q.Questions {
+		questions += "\n\n" + Separator
+		if err := question.Validate(); err != nil {
+			questions += "\nWARNING: Invalid question: " + err.Error() + "\n"
+		}
+		questions += fmt.Sprintf("\nQuestion: %s\n\nType: %s\n\nCorrect Answer: %s", question.Question, question.Type, getCorrectAnswerText(&question))
+		if question.Type.HasOptions() {
+			questions += "\n\nIncorrect Answers:"
+			for _, answer := range question.IncorrectAnswers {
+				questions += "\n\n" + answer
+			}
+		}
+		if question.Explanation != "" {
+			questions += "\n\nExplanation: " + question.Explanation
+		}
+		if question.ReferenceLink != "" {
+			questions += "\n\nReference link: " + question.ReferenceLink
+		}
+	}
+
+	err = p.mm.Frontend.OpenInteractiveDialog(model.OpenDialogRequest{
+		TriggerId: req.TriggerId,
+		URL:       p.getDialogURL() + DialogPathReviewQuestions,
+		Dialog: model.Dialog{
+			Title:            "Review questions",
+			IntroductionText: "These are all the questions registered:\n" + questions,
+			SubmitLabel:      "OK",
+			State:            getQuizDialogState(id, req.PostId),
+		},
+	})
+	if err != nil {
+		attachmentError(w, err.Error())
+		return
+	}
+	attachmentOK(w, "")
+}
+
+func (p *Plugin) attachmentRemoveQuestions(w http.ResponseWriter, r *http.Request, actingUserID string) {
+	req := model.PostActionIntegrationRequestFromJson(r.Body)
+	id := getQuizIDFromPostActionRequest(req)
+
+	q, err := p.store.GetQuiz(id)
+	if err != nil {
+		attachmentError(w, err.Error())
+		return
+	}
+
+	if !p.canEditQuiz(q, actingUserID) {
+		attachmentError(w, ErrNotQuizEditor.Error())
+		return
+	}
+
+	elements := []model.DialogElement{}
+	for _, question := range q.Questions {
+		element := model.DialogElement{
+			DisplayName: question.Question,
+			Name:        question.ID,
+			Type:        DialogTypeBool,
+			Optional:    true,
+		}
+		text := ""
+		if question.Validate() != nil {
+			text += "WARNING: Invalid question; "
+		}
+		text += fmt.Sprintf("Type: %s; Correct Answer: %s", question.Type, getCorrectAnswerText(&question))
+		if question.Type.HasOptions() {
+			text += "; Incorrect Answers:"
+			firstRun := true
+			for _, answer := range question.IncorrectAnswers {
+				if !firstRun {
+					text += ";"
+				}
+				text += " " + answer
+				firstRun = false
+			}
+		}
+		element.HelpText = text
+		elements = append(elements, element)
+	}
+
+	err = p.mm.Frontend.OpenInteractiveDialog(model.OpenDialogRequest{
+		TriggerId: req.TriggerId,
+		URL:       p.getDialogURL() + DialogPathRemoveQuestion,
+		Dialog: model.Dialog{
+			Title:            "Remove questions",
+			IntroductionText: "Select the questions to delete.",
+			SubmitLabel:      "Remove selected",
+			State:            getQuizDialogState(id, req.PostId),
+			Elements:         elements,
+		},
+	})
+	if err != nil {
+		attachmentError(w, err.Error())
+		return
+	}
+	attachmentOK(w, "")
+}
+
+func (p *Plugin) attachmentEditQuestion(w http.ResponseWriter, r *http.Request, actingUserID string) {
+	req := model.PostActionIntegrationRequestFromJson(r.Body)
+	id := getQuizIDFromPostActionRequest(req)
+
+	q, err := p.store.GetQuiz(id)
+	if err != nil {
+		attachmentError(w, err.Error())
+		return
+	}
+
+	if !p.canEditQuiz(q, actingUserID) {
+		attachmentError(w, ErrNotQuizEditor.Error())
+		return
+	}
+
+	element := model.DialogElement{
+		DisplayName: "Question",
+		Name:        DialogSubmissionFieldQuestion,
+		Type:        DialogTypeSelect,
+		Options:     []*model.PostActionOptions{},
+	}
+	for _, question := range q.Questions {
+		element.Options = append(element.Options, &model.PostActionOptions{
+			Text:  question.Question,
+			Value: question.ID,
+		})
+	}
+
+	err = p.mm.Frontend.OpenInteractiveDialog(model.OpenDialogRequest{
+		TriggerId: req.TriggerId,
+		URL:       p.getDialogURL() + DialogPathEditQuestion,
+		Dialog: model.Dialog{
+			Title:            "Edit question",
+			IntroductionText: "Select the question to edit.",
+			SubmitLabel:      "Edit",
+			State:            getQuizDialogState(id, req.PostId),
+			Elements:         []model.DialogElement{element},
+		},
+	})
+	if err != nil {
+		attachmentError(w, err.Error())
+		return
+	}
+	attachmentOK(w, "")
+}
+
+func (p *Plugin) attachmentUpdateQuestion(w http.ResponseWriter, r *http.Request, actingUserID string) {
+	req := model.PostActionIntegrationRequestFromJson(r.Body)
+	id := getQuizIDFromPostActionRequest(req)
+
+	qID, ok := req.Context[AttachmentContextFieldQuestionID].(string)
+	if !ok || qID == "" {
+		attachmentError(w, "cannot find question ID")
+		return
+	}
+
+	q, err := p.store.GetQuiz(id)
+	if err != nil {
+		attachmentError(w, err.Error())
+		return
+	}
+
+	if !p.canEditQuiz(q, actingUserID) {
+		attachmentError(w, ErrNotQuizEditor.Error())
+		return
+	}
+
+	var question *Question
+	for i := range q.Questions {
+		if q.Questions[i].ID == qID {
+			question = &q.Questions[i]
+			break
+		}
+	}
+
+	if question == nil {
+		attachmentError(w, "Cannot find this question. Please hit the back button.")
+		return
+	}
+
+	err = p.mm.Frontend.OpenInteractiveDialog(model.OpenDialogRequest{
+		TriggerId: req.TriggerId,
+		URL:       p.getDialogURL() + DialogPathUpdateQuestion,
+		Dialog: model.Dialog{
+			Title:            "Edit question",
+			IntroductionText: "Update the question",
+			SubmitLabel:      "Save",
+			Elements:         getQuestionDialogElements(question),
+			State:            getQuestionDialogState(id, req.PostId, qID, question.Type),
+		},
+	})
+	if err != nil {
+		attachmentError(w, err.Error())
+		return
+	}
+	attachmentOK(w, "")
+}
+
+func (p *Plugin) attachmentQuestionBack(w http.ResponseWriter, r *http.Request, actingUserID string) {
+	req := model.PostActionIntegrationRequestFromJson(r.Body)
+	id := getQuizIDFromPostActionRequest(req)
+
+	q, err := p.store.GetQuiz(id)
+	if err != nil {
+		attachmentError(w, err.Error())
+		return
+	}
+
+	if !p.canEditQuiz(q, actingUserID) {
+		attachmentError(w, ErrNotQuizEditor.Error())
+		return
+	}
+
+	post, err := p.mm.Post.GetPost(req.PostId)
+	if err != nil {
+		attachmentError(w, err.Error())
+		return
+	}
+
+	model.ParseSlackAttachment(post, p.CreateAttachmentFromQuiz(q))
+	err = p.mm.Post.UpdatePost(post)
+	if err != nil {
+		attachmentError(w, err.Error())
+		return
+	}
+	attachmentOK(w, "")
+}
+
+func (p *Plugin) attachmentSetQuestionImage(w http.ResponseWriter, r *http.Request, actingUserID string) {
+	p.updateQuestionImage(w, r, actingUserID, true)
+}
+
+func (p *Plugin) attachmentRemoveQuestionImage(w http.ResponseWriter, r *http.Request, actingUserID string) {
+	p.updateQuestionImage(w, r, actingUserID, false)
+}
+
+// updateQuestionImage sets the last image the user uploaded to the conversation as the
+// image of the question, or removes the image of the question.
+func (p *Plugin) updateQuestionImage(w http.ResponseWriter, r *http.Request, actingUserID string, set bool) {
+	req := model.PostActionIntegrationRequestFromJson(r.Body)
+	id := getQuizIDFromPostActionRequest(req)
+
+	qID, ok := req.Context[AttachmentContextFieldQuestionID].(string)
+	if !ok || qID == "" {
+		attachmentError(w, "cannot find question ID")
+		return
+	}
+
+	q, err := p.store.GetQuiz(id)
+	if err != nil {
+		attachmentError(w, err.Error())
+		return
+	}
+
+	if !p.canEditQuiz(q, actingUserID) {
+		attachmentError(w, ErrNotQuizEditor.Error())
+		return
+	}
+
+	var question *Question
+	for i := range q.Questions {
+		if q.Questions[i].ID == qID {
+			question = &q.Questions[i]
+			break
+		}
+	}
+
+	if question == nil {
+		attachmentError(w, "Cannot find this question. Please hit the back button.")
+		return
+	}
+
+	imageFileID := ""
+	if set {
+		fileID, err := p.getLastUploadedFileID(actingUserID, req.ChannelId)
+		if err != nil {
+			attachmentError(w, err.Error())
+			return
+		}
+		if fileID == "" {
+			attachmentError(w, "Upload the image to this conversation first")
+			return
+		}
+
+		imageFileID, err = p.copyQuestionImage(fileID, actingUserID, req.ChannelId)
+		if err != nil {
+			attachmentError(w, err.Error())
+			return
+		}
+	}
+
+	// The previous image is kept, since older revisions of the quiz may be restored.
+	question.ImageFileID = imageFileID
+	err = p.storeQuizRevision(q, actingUserID)
+	if err != nil {
+		p.deleteQuestionImage(imageFileID)
+		attachmentError(w, err.Error())
+		return
+	}
+
+	post, err := p.mm.Post.GetPost(req.PostId)
+	if err != nil {
+		attachmentError(w, err.Error())
+		return
+	}
+
+	model.ParseSlackAttachment(post, p.CreateQuestionAttachmentFromQuiz(q, qID))
+	err = p.mm.Post.UpdatePost(post)
+	if err != nil {
+		attachmentError(w, err.Error())
+		return
+	}
+	attachmentOK(w, "")
+}
+
+func (p *Plugin) attachmentSave(w http.ResponseWriter, r *http.Request, actingUserID string) {
+	req := model.PostActionIntegrationRequestFromJson(r.Body)
+	id := getQuizIDFromPostActionRequest(req)
+
+	q, err := p.store.GetQuiz(id)
+	if err != nil {
+		attachmentError(w, err.Error())
+		return
+	}
+
+	if !p.canEditQuiz(q, actingUserID) {
+		attachmentError(w, ErrNotQuizEditor.Error())
+		return
+	}
+
+	if q.ValidQuestions() == 0 {
+		attachmentError(w, "cannot save a quiz with no valid questions")
+		return
+	}
+
+	if q.TeamID == "" && req.TeamId != "" {
+		q.TeamID = req.TeamId
+		err = p.storeQuizRevision(q, actingUserID)
+		if err != nil {
+			attachmentError(w, err.Error())
+			return
+		}
+	}
+
+	err = p.store.AddAvailableQuiz(q)
+	if err != nil {
+		attachmentError(w, err.Error())
+		return
+	}
+
+	p.GrantBadge(AchievementNameContentCreator, actingUserID)
+
+	resp := model.PostActionIntegrationResponse{
+		Update: &model.Post{
+			Message: fmt.Sprintf("Quiz `%s` saved and ready to use by %s.", q.Name, p.getScopeText(q.TeamID, q.ChannelIDs)),
+			Props:   model.StringInterface{},
+		},
+	}
+	_, _ = w.Write(resp.ToJson())
+}
+
+func (p *Plugin) attachmentAnswer(w http.ResponseWriter, r *http.Request, actingUserID string) {
+	req := model.PostActionIntegrationRequestFromJson(r.Body)
+	id := getGameIDFromPostActionRequest(req)
+
+	g, err := p.store.GetGame(id)
+	if err != nil {
+		attachmentError(w, err.Error())
+		return
+	}
+
+	if g == nil {
+		attachmentError(w, "game not found")
+		return
+	}
+
+	qID, ok := req.Context[AttachmentContextFieldQuestionID].(string)
+	if !ok || id == "" {
+		attachmentError(w, "cannot find question ID")
+		return
+	}
+
+	if qID != g.RemainingQuestions[0].ID {
+		attachmentError(w, "mismatch between post and internal state")
+		return
+	}
+
+	user, err := p.mm.User.Get(actingUserID)
+	if err != nil {
+		attachmentError(w, err.Error())
+		return
+	}
+
+	if g.AlreadyAnswered[user.Username] {
+		attachmentError(w, "you already tried to answer this question")
+		return
+	}
+
+	dr := model.OpenDialogRequest{
+		TriggerId: req.TriggerId,
+		URL:       p.getDialogURL() + DialogPathAnswer,
+		Dialog: model.Dialog{
+			Title:            "Answer",
+			IntroductionText: g.RemainingQuestions[0].Question,
+			SubmitLabel:      "Submit",
+			Elements: []model.DialogElement{
+				{
+					DisplayName: "Your answer",
+					Name:        DialogSubmissionFieldGameAnswer,
+					Type:        DialogTypeText,
+				},
+			},
+			State: strings.Join([]string{id, qID}, ","),
+		},
+	}
+
+	switch currentQuestion := g.RemainingQuestions[0]; currentQuestion.Type {
+	case QuizTypeNumeric:
+		if currentQuestion.Unit != "" {
+			dr.Dialog.Elements[0].HelpText = "Answer in " + currentQuestion.Unit + "."
+		}
+	case QuizTypeOrdering:
+		dr.URL = p.getDialogURL() + DialogPathOrderAnswers
+		dr.Dialog.IntroductionText += "\n\nPut the items in order."
+		options := []*model.PostActionOptions{}
+		for i, item := range g.CurrentAnswers {
+			options = append(options, &model.PostActionOptions{
+				Text:  item,
+				Value: strconv.Itoa(i),
+			})
+		}
+		dr.Dialog.Elements = []model.DialogElement{}
+		for i := range g.CurrentAnswers {
+			dr.Dialog.Elements = append(dr.Dialog.Elements, model.DialogElement{
+				DisplayName: fmt.Sprintf("Position %d", i+1),
+				Name:        DialogSubmissionFieldGamePosition + strconv.Itoa(i),
+				Type:        DialogTypeSelect,
+				Options:     options,
+			})
+		}
+	case QuizTypeMultiSelect:
+		dr.URL = p.getDialogURL() + DialogPathSelectAnswers
+		dr.Dialog.IntroductionText += "\n\nSelect every correct option."
+		dr.Dialog.Elements = []model.DialogElement{}
+		for i, answer := range g.CurrentAnswers {
+			dr.Dialog.Elements = append(dr.Dialog.Elements, model.DialogElement{
+				DisplayName: fmt.Sprintf("Option %d", i+1),
+				Name:        DialogSubmissionFieldGameOption + strconv.Itoa(i),
+				Type:        DialogTypeBool,
+				Placeholder: answer,
+				Optional:    true,
+			})
+		}
+	}
+
+	err = p.mm.Frontend.OpenInteractiveDialog(dr)
+	if err != nil {
+		attachmentError(w, err.Error())
+		return
+	}
+	attachmentOK(w, "")
+}
+
+func (p *Plugin) attachmentSelectAnswer(w http.ResponseWriter, r *http.Request, actingUserID string) {
+	req := model.PostActionIntegrationRequestFromJson(r.Body)
+	id := getGameIDFromPostActionRequest(req)
+
+	qID, ok := req.Context[AttachmentContextFieldQuestionID].(string)
+	if !ok || id == "" {
+		attachmentError(w, "cannot find question ID")
+		return
+	}
+
+	answer, ok := req.Context[AttachmentContextFieldAnswer].(float64)
+	if !ok {
+		attachmentError(w, "cannot find the selected answer")
+		return
+	}
+
+	user, err := p.mm.User.Get(actingUserID)
+	if err != nil {
+		attachmentError(w, err.Error())
+		return
+	}
+
+	correctAnswer := false
+	g, err := p.store.UpdateGame(id, func(g *Game) error {
+		correctAnswer = int(answer) == g.CorrectAnswer
+		answerText := ""
+		if int(answer) >= 0 && int(answer) < len(g.CurrentAnswers) {
+			answerText = g.CurrentAnswers[int(answer)]
+		}
+		g.AddPlayer(user.Id, user.Username)
+		return g.RecordAnswer(qID, user.Username, answerText, correctAnswer)
+	})
+	if err != nil {
+		attachmentError(w, err.Error())
+		return
+	}
+
+	if g == nil {
+		attachmentError(w, "game not found")
+		return
+	}
+
+	responseMessage := "Your answer is incorrect."
+	if correctAnswer {
+		responseMessage = "You are correct!"
+	}
+
+	if g.Type == GameTypeParty {
+		post, err := p.mm.Post.GetPost(req.PostId)
+		if err != nil {
+			attachmentError(w, err.Error())
+			return
+		}
+
+		model.ParseSlackAttachment(post, p.GameAttachment(g))
+		err = p.mm.Post.UpdatePost(post)
+		if err != nil {
+			attachmentError(w, err.Error())
+			return
+		}
+		attachmentOK(w, responseMessage)
+		return
+	}
+
+	err = p.handleNextQuestion(id, qID, req.ChannelId, actingUserID)
+	if err != nil {
+		attachmentError(w, err.Error())
+		return
+	}
+
+	attachmentOK(w, responseMessage)
+}
+
+func (p *Plugin) attachmentScore(w http.ResponseWriter, r *http.Request, actingUserID string) {
+	req := model.PostActionIntegrationRequestFromJson(r.Body)
+	id := getGameIDFromPostActionRequest(req)
+
+	g, err := p.store.GetGame(id)
+	if err != nil {
+		attachmentError(w, err.Error())
+		return
+	}
+
+	if g == nil {
+		attachmentError(w, "game not found")
+		return
+	}
+
+	err = p.mm.Frontend.OpenInteractiveDialog(model.OpenDialogRequest{
+		TriggerId: req.TriggerId,
+		URL:       p.getDialogURL() + DialogPathScore,
+		Dialog: model.Dialog{
+			Title:            "Score",
+			IntroductionText: getScores(g),
+			SubmitLabel:      "OK",
+			State:            id,
+		},
+	})
+	if err != nil {
+		attachmentError(w, err.Error())
+		return
+	}
+	attachmentOK(w, "")
+}
+
+func (p *Plugin) attachmentNext(w http.ResponseWriter, r *http.Request, actingUserID string) {
+	req := model.PostActionIntegrationRequestFromJson(r.Body)
+	id := getGameIDFromPostActionRequest(req)
+
+	g, err := p.store.GetGame(id)
+	if err != nil {
+		attachmentError(w, err.Error())
+		return
+	}
+
+	if g == nil {
+		attachmentError(w, "game not found")
+		return
+	}
+
+	if g.GM != actingUserID {
+		attachmentError(w, "only the person who created the quiz can pass to the next question")
+		return
+	}
+
+	qID, ok := req.Context[AttachmentContextFieldQuestionID].(string)
+	if !ok || id == "" {
+		attachmentError(w, "cannot find question ID")
+		return
+	}
+
+	if qID != g.RemainingQuestions[0].ID {
+		attachmentError(w, "mismatch between post and internal state")
+		return
+	}
+
+	err = p.handleNextQuestion(id, qID, req.ChannelId, actingUserID)
+	if err != nil {
+		attachmentError(w, err.Error())
+		return
+	}
+
+	attachmentOK(w, "")
+}
+
+// handleNextQuestion reveals the solution of the question with questionID and moves
+// the game to the next question, or finishes it if there are no more questions.
+func (p *Plugin) handleNextQuestion(gameID, questionID, channelID, actingUserID string) error {
+	var solved Game
+	g, err := p.store.UpdateGame(gameID, func(g *Game) error {
+		current := g.CurrentQuestion()
+		if current == nil || current.ID != questionID {
+			return ErrQuestionPassed
+		}
+
+		g.PassedQuestions = append(g.PassedQuestions, *current)
+		solved = *g
+		g.RemainingQuestions = g.RemainingQuestions[1:]
+		if len(g.RemainingQuestions) == 0 {
+			return nil
+		}
+
+		g.shuffleAnswers()
+		g.AlreadyAnswered = map[string]bool{}
+		g.RightPlayers = []string{}
+		g.startQuestionClock()
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if g == nil {
+		return errors.New("game not found")
+	}
+
+	solutionPost, err := p.mm.Post.GetPost(solved.CurrentPostID)
+	if err != nil {
+		return err
+	}
+
+	model.ParseSlackAttachment(solutionPost, p.GameSolutionAttachment(&solved))
+	err = p.mm.Post.UpdatePost(solutionPost)
+	if err != nil {
+		return err
+	}
+
+	post := &model.Post{
+		UserId:    p.BotUserID,
+		ChannelId: channelID,
+		RootId:    g.RootPostID,
+		Message:   "Next question!",
+	}
+
+	if len(g.RemainingQuestions) == 0 {
+		post.Message = "Quiz finished!"
+		model.ParseSlackAttachment(post, p.GameEndAttachment(g))
+		err = p.mm.Post.CreatePost(post)
+		if err != nil {
+			return err
+		}
+
+		if g.Type == GameTypeSolo {
+			p.GrantBadge(AchievementNameHardWorker, actingUserID)
+		}
+
+		if g.CourseID != "" {
+			err = p.recordCourseQuizScore(g)
+			if err != nil {
+				p.mm.Log.Debug("Cannot record course quiz score", "err", err)
+			}
+		}
+
+		if g.Type == GameTypeParty {
+			rows := getScoreRows(g)
+			if len(rows) > 0 {
+				user, err := p.mm.User.GetByUsername(rows[0].name)
+				if err == nil {
+					p.GrantBadge(AchievementNameWinner, user.Id)
+				}
+			}
+		}
+
+		result := NewGameResult(g)
+		err = p.store.AddGameResult(result)
+		if err != nil {
+			p.mm.Log.Debug("Cannot store the game results", "gameID", g.RootPostID, "err", err)
+		}
+		p.updateLeaderboards(result)
+		return p.store.DeleteGame(g.RootPostID)
+	}
+
+	model.ParseSlackAttachment(post, p.GameAttachment(g))
+	err = p.mm.Post.CreatePost(post)
+	if err != nil {
+		return err
+	}
+
+	_, err = p.store.UpdateGame(gameID, func(g *Game) error {
+		g.CurrentPostID = post.Id
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return p.scheduleQuestionTimer(gameID)
+}
+
+func (p *Plugin) attachmentNameCourse(w http.ResponseWriter, r *http.Request, actingUserID string) {
+	req := model.PostActionIntegrationRequestFromJson(r.Body)
+	id := getCourseIDFromPostActionRequest(req)
+
+	c, err := p.store.GetCourse(id)
+	if err != nil {
+		attachmentError(w, err.Error())
+		return
+	}
+
+	if !p.canEditCourse(c, actingUserID) {
+		attachmentError(w, ErrNotCourseEditor.Error())
+		return
+	}
+
+	defaultName := c.Name
+
+	err = p.mm.Frontend.OpenInteractiveDialog(model.OpenDialogRequest{
+		TriggerId: req.TriggerId,
+		URL:       p.getDialogURL() + DialogPathNameCourse,
+		Dialog: model.Dialog{
+			Title:            "Name course",
+			IntroductionText: "Write the name of your course",
+			SubmitLabel:      "Submit",
+			Elements: []model.DialogElement{
+				{
+					DisplayName: "Course name",
+					Name:        DialogSubmissionFieldName,
+					Type:        DialogTypeText,
+					Default:     defaultName,
+				},
+			},
+			State: id,
+		},
+	})
+	if err != nil {
+		attachmentError(w, err.Error())
+		return
+	}
+	attachmentOK(w, "")
+}
+
+func (p *Plugin) attachmentCourseDescription(w http.ResponseWriter, r *http.Request, actingUserID string) {
+	req := model.PostActionIntegrationRequestFromJson(r.Body)
+	id := getCourseIDFromPostActionRequest(req)
+
+	c, err := p.store.GetCourse(id)
+	if err != nil {
+		attachmentError(w, err.Error())
+		return
+	}
+
+	if !p.canEditCourse(c, actingUserID) {
+		attachmentError(w, ErrNotCourseEditor.Error())
+		return
+	}
+
+	defaultDescription := c.Description
+
+	err = p.mm.Frontend.OpenInteractiveDialog(model.OpenDialogRequest{
+		TriggerId: req.TriggerId,
+		URL:       p.getDialogURL() + DialogPathCourseDescription,
+		Dialog: model.Dialog{
+			Title:            "Add course description",
+			IntroductionText: "Write the description of your course",
+			SubmitLabel:      "Submit",
+			Elements: []model.DialogElement{
+				{
+					DisplayName: "Course description",
+					Name:        DialogSubmissionFieldDescription,
+					Type:        DialogTypeText,
+					Default:     defaultDescription,
+				},
+			},
+			State: id,
+		},
+	})
+	if err != nil {
+		attachmentError(w, err.Error())
+		return
+	}
+	attachmentOK(w, "")
+}
+
+func (p *Plugin) attachmentCourseDelete(w http.ResponseWriter, r *http.Request, actingUserID string) {
+	req := model.PostActionIntegrationRequestFromJson(r.Body)
+	id := getCourseIDFromPostActionRequest(req)
+
+	c, err := p.store.GetCourse(id)
+	if err != nil {
+		attachmentError(w, err.Error())
+		return
+	}
+
+	if !p.canEditCourse(c, actingUserID) {
+		attachmentError(w, ErrNotCourseEditor.Error())
+		return
+	}
+
+	err = p.mm.Frontend.OpenInteractiveDialog(model.OpenDialogRequest{
+		TriggerId: req.TriggerId,
+		URL:       p.getDialogURL() + DialogPathCourseDelete,
+		Dialog: model.Dialog{
+			Title:            "Cancel creation",
+			IntroductionText: "Are you sure you want to cancel the creation of this course? All changes will be lost.",
+			SubmitLabel:      "Delete",
+			State:            id,
+		},
+	})
+	if err != nil {
+		attachmentError(w, err.Error())
+		return
+	}
+	attachmentOK(w, "")
+}
+
+func (p *Plugin) attachmentSaveCourse(w http.ResponseWriter, r *http.Request, actingUserID string) {
+	req := model.PostActionIntegrationRequestFromJson(r.Body)
+	id := getCourseIDFromPostActionRequest(req)
+
+	c, err := p.store.GetCourse(id)
+	if err != nil {
+		attachmentError(w, err.Error())
+		return
+	}
+
+	if !p.canEditCourse(c, actingUserID) {
+		attachmentError(w, ErrNotCourseEditor.Error())
+		return
+	}
+
+	if len(c.Lessons) == 0 {
+		attachmentError(w, "cannot save a course with no lessons")
+		return
+	}
+
+	if c.TeamID == "" && req.TeamId != "" {
+		c.TeamID = req.TeamId
+		err = p.store.StoreCourse(c)
+		if err != nil {
+			attachmentError(w, err.Error())
+			return
+		}
+	}
+
+	err = p.store.AddAvailableCourse(c)
+	if err != nil {
+		attachmentError(w, err.Error())
+		return
+	}
+
+	resp := model.PostActionIntegrationResponse{
+		Update: &model.Post{
+			Message: fmt.Sprintf("Course `%s` saved and ready to use by %s.", c.Name, p.getScopeText(c.TeamID, c.ChannelIDs)),
+			Props:   model.StringInterface{},
+		},
+	}
+	_, _ = w.Write(resp.ToJson())
+}
+
+func (p *Plugin) attachmentAddLesson(w http.ResponseWriter, r *http.Request, actingUserID string) {
+	req := model.PostActionIntegrationRequestFromJson(r.Body)
+	id := getCourseIDFromPostActionRequest(req)
+
+	c, err := p.store.GetCourse(id)
+	if err != nil {
+		attachmentError(w, err.Error())
+		return
+	}
+
+	if !p.canEditCourse(c, actingUserID) {
+		attachmentError(w, ErrNotCourseEditor.Error())
+		return
+	}
+
+	dr := model.OpenDialogRequest{
+		TriggerId: req.TriggerId,
+		URL:       p.getDialogURL() + DialogPathAddLesson,
+		Dialog: model.Dialog{
+			Title:            "Add a lesson",
+			IntroductionText: "Write the lesson basic information to add",
+			SubmitLabel:      "Add",
+			Elements: []model.DialogElement{
+				{
+					DisplayName: "Name",
+					Name:        DialogSubmissionFieldName,
+					Type:        DialogTypeText,
+				},
+				{
+					DisplayName: "Description",
+					Name:        DialogSubmissionFieldDescription,
+					Type:        DialogTypeText,
+				},
+			},
+			State: id,
+		},
+	}
+
+	err = p.mm.Frontend.OpenInteractiveDialog(dr)
+	if err != nil {
+		attachmentError(w, err.Error())
+		return
+	}
+	attachmentOK(w, "")
+}
+
+func (p *Plugin) attachmentEditLesson(w http.ResponseWriter, r *http.Request, actingUserID string) {
+	req := model.PostActionIntegrationRequestFromJson(r.Body)
+	id := getCourseIDFromPostActionRequest(req)
+
+	c, err := p.store.GetCourse(id)
+	if err != nil {
+		attachmentError(w, err.Error())
+		return
+	}
+
+	if !p.canEditCourse(c, actingUserID) {
+		attachmentError(w, ErrNotCourseEditor.Error())
+		return
+	}
+
+	element := model.DialogElement{
+		DisplayName: "Lesson",
+		Name:        DialogSubmissionFieldLesson,
+		Type:        "select",
+		Options:     []*model.PostActionOptions{},
+	}
+	for i, lesson := range c.Lessons {
+		option := &model.PostActionOptions{
+			Text:  lesson.Name,
+			Value: strconv.Itoa(i),
+		}
+		element.Options = append(element.Options, option)
+	}
+
+	err = p.mm.Frontend.OpenInteractiveDialog(model.OpenDialogRequest{
+		TriggerId: req.TriggerId,
+		URL:       p.getDialogURL() + DialogPathEditLesson,
+		Dialog: model.Dialog{
+			Title:            "Edit lessons",
+			IntroductionText: "Select the lesson to edit.",
+			SubmitLabel:      "Edit",
+			State:            id,
+			Elements:         []model.DialogElement{element},
+		},
+	})
+	if err != nil {
+		attachmentError(w, err.Error())
+		return
+	}
+	attachmentOK(w, "")
+}
+
+func (p *Plugin) attachmentNameLesson(w http.ResponseWriter, r *http.Request, actingUserID string) {
+	req := model.PostActionIntegrationRequestFromJson(r.Body)
+	id := getCourseIDFromPostActionRequest(req)
+	index := getLessonIndexFromPostActionRequest(req)
+
+	c, err := p.store.GetCourse(id)
+	if err != nil {
+		attachmentError(w, err.Error())
+		return
+	}
+
+	if !p.canEditCourse(c, actingUserID) {
+		attachmentError(w, ErrNotCourseEditor.Error())
+		return
+	}
+
+	if index < 0 || index >= len(c.Lessons) {
+		attachmentError(w, "Cannot find this lesson. Please hit the back button.")
+		return
+	}
+
+	lesson := c.Lessons[index]
+
+	defaultName := lesson.Name
+
+	err = p.mm.Frontend.OpenInteractiveDialog(model.OpenDialogRequest{
+		TriggerId: req.TriggerId,
+		URL:       p.getDialogURL() + DialogPathNameLesson,
+		Dialog: model.Dialog{
+			Title:            "Name lesson",
+			IntroductionText: "Write the name of your lesson",
+			SubmitLabel:      "Submit",
+			Elements: []model.DialogElement{
+				{
+					DisplayName: "Lesson name",
+					Name:        DialogSubmissionFieldName,
+					Type:        DialogTypeText,
+					Default:     defaultName,
+				},
+			},
+			State: getLessonDialogState(id, index),
+		},
+	})
+	if err != nil {
+		attachmentError(w, err.Error())
+		return
+	}
+	attachmentOK(w, "")
+}
+
+func (p *Plugin) attachmentLessonIntroduction(w http.ResponseWriter, r *http.Request, actingUserID string) {
+	req := model.PostActionIntegrationRequestFromJson(r.Body)
+	id := getCourseIDFromPostActionRequest(req)
+	index := getLessonIndexFromPostActionRequest(req)
+
+	c, err := p.store.GetCourse(id)
+	if err != nil {
+		attachmentError(w, err.Error())
+		return
+	}
+
+	if !p.canEditCourse(c, actingUserID) {
+		attachmentError(w, ErrNotCourseEditor.Error())
+		return
+	}
+
+	if index < 0 || index >= len(c.Lessons) {
+		attachmentError(w, "Cannot find this lesson. Please hit the back button.")
+		return
+	}
+
+	lesson := c.Lessons[index]
+
+	defaultIntroduction := lesson.Introduction
+
+	err = p.mm.Frontend.OpenInteractiveDialog(model.OpenDialogRequest{
+		TriggerId: req.TriggerId,
+		URL:       p.getDialogURL() + DialogPathLessonIntroduction,
+		Dialog: model.Dialog{
+			Title:            "Name lesson",
+			IntroductionText: "Write the introduction of your lesson",
+			SubmitLabel:      "Submit",
+			Elements: []model.DialogElement{
+				{
+					DisplayName: "Lesson introduction",
+					Name:        DialogSubmissionFieldDescription,
+					Type:        DialogTypeText,
+					Default:     defaultIntroduction,
+				},
+			},
+			State: getLessonDialogState(id, index),
+		},
+	})
+	if err != nil {
+		attachmentError(w, err.Error())
+		return
+	}
+	attachmentOK(w, "")
+}
+
+func (p *Plugin) attachmentAddResource(w http.ResponseWriter, r *http.Request, actingUserID string) {
+	req := model.PostActionIntegrationRequestFromJson(r.Body)
+	id := getCourseIDFromPostActionRequest(req)
+	index := getLessonIndexFromPostActionRequest(req)
+
+	c, err := p.store.GetCourse(id)
+	if err != nil {
+		attachmentError(w, err.Error())
+		return
+	}
+
+	if !p.canEditCourse(c, actingUserID) {
+		attachmentError(w, ErrNotCourseEditor.Error())
+		return
+	}
+
+	if index < 0 || index >= len(c.Lessons) {
+		attachmentError(w, "Cannot find this lesson. Please hit the back button.")
+		return
+	}
+
+	err = p.mm.Frontend.OpenInteractiveDialog(model.OpenDialogRequest{
+		TriggerId: req.TriggerId,
+		URL:       p.getDialogURL() + DialogPathAddResource,
+		Dialog: model.Dialog{
+			Title:            "Add resource",
+			IntroductionText: "Fill the resource information",
+			SubmitLabel:      "Submit",
+			Elements: []model.DialogElement{
+				{
+					DisplayName: "Resource name",
+					Name:        DialogSubmissionFieldName,
+					Type:        DialogTypeText,
+				},
+				{
+					DisplayName: "Resource pretext",
+					Name:        DialogSubmissionFieldDescription,
+					Type:        DialogTypeText,
+				},
+				{
+					DisplayName: "Resource type",
+					Name:        DialogSubmissionFieldType,
+					Type:        DialogTypeSelect,
+					Options: []*model.PostActionOptions{
+						{
+							Text:  "Video",
+							Value: string(ResourceTypeVideo),
+						},
+						{
+							Text:  "Text",
+							Value: string(ResourceTypeText),
+						},
+						{
+							Text:  "Link",
+							Value: string(ResourceTypeLink),
+						},
+					},
+				},
+				{
+					DisplayName: "Resource content",
+					Name:        DialogSubmissionFieldContent,
+					Type:        DialogTypeText,
+				},
+			},
+			State: getLessonDialogState(id, index),
+		},
+	})
+	if err != nil {
+		attachmentError(w, err.Error())
+		return
+	}
+	attachmentOK(w, "")
+}
+
+func (p *Plugin) attachmentAddQuizResource(w http.ResponseWriter, r *http.Request, actingUserID string) {
+	req := model.PostActionIntegrationRequestFromJson(r.Body)
+	id := getCourseIDFromPostActionRequest(req)
+	index := getLessonIndexFromPostActionRequest(req)
+
+	c, err := p.store.GetCourse(id)
+	if err != nil {
+		attachmentError(w, err.Error())
+		return
+	}
+
+	if !p.canEditCourse(c, actingUserID) {
+		attachmentError(w, ErrNotCourseEditor.Error())
+		return
+	}
+
+	if index < 0 || index >= len(c.Lessons) {
+		attachmentError(w, "Cannot find this lesson. Please hit the back button.")
+		return
+	}
+
+	quizOptions := []*model.PostActionOptions{}
+	qq := p.getAvailableQuizzes(actingUserID, c.TeamID)
+
+	if len(qq) == 0 {
+		attachmentError(w, "No quizzes available to add.")
+		return
+	}
+
+	for _, q := range qq {
+		quizOptions = append(quizOptions, &model.PostActionOptions{Text: q.Name, Value: q.ID})
+	}
+
+	err = p.mm.Frontend.OpenInteractiveDialog(model.OpenDialogRequest{
+		TriggerId: req.TriggerId,
+		URL:       p.getDialogURL() + DialogPathAddQuizResource,
+		Dialog: model.Dialog{
+			Title:            "Add resource",
+			IntroductionText: "Fill the resource information",
+			SubmitLabel:      "Submit",
+			Elements: []model.DialogElement{
+				{
+					DisplayName: "Resource name",
+					Name:        DialogSubmissionFieldName,
+					Type:        DialogTypeText,
+				},
+				{
+					DisplayName: "Resource pretext",
+					Name:        DialogSubmissionFieldDescription,
+					Type:        DialogTypeText,
+				},
+				{
+					DisplayName: "Quiz",
+					Name:        DialogSubmissionFieldQuiz,
+					Type:        DialogTypeSelect,
+					Options:     quizOptions,
+				},
+			},
+			State: getLessonDialogState(id, index),
+		},
+	})
+	if err != nil {
+		attachmentError(w, err.Error())
+		return
+	}
+	attachmentOK(w, "")
+}
+
+func (p *Plugin) attachmentRemoveResources(w http.ResponseWriter, r *http.Request, actingUserID string) {
+	req := model.PostActionIntegrationRequestFromJson(r.Body)
+	id := getQuizIDFromPostActionRequest(req)
+	index := getLessonIndexFromPostActionRequest(req)
+
+	c, err := p.store.GetCourse(id)
+	if err != nil {
+		attachmentError(w, err.Error())
+		return
+	}
+
+	if !p.canEditCourse(c, actingUserID) {
+		attachmentError(w, ErrNotCourseEditor.Error())
+		return
+	}
+
+	if index < 0 || index >= len(c.Lessons) {
+		attachmentError(w, "Cannot find this lesson. Please hit the back button.")
+		return
+	}
+
+	lesson := c.Lessons[index]
+
+	elements := []model.DialogElement{}
+	for i, resource := range lesson.Resources {
+		element := model.DialogElement{
+			DisplayName: resource.Name,
+			Name:        strconv.Itoa(i),
+			Type:        DialogTypeBool,
+			Optional:    true,
+		}
+		elements = append(elements, element)
+	}
+
+	err = p.mm.Frontend.OpenInteractiveDialog(model.OpenDialogRequest{
+		TriggerId: req.TriggerId,
+		URL:       p.getDialogURL() + DialogPathRemoveResources,
+		Dialog: model.Dialog{
+			Title:            "Remove resources",
+			IntroductionText: "Select the resources to delete.",
+			SubmitLabel:      "Remove selected",
+			State:            getLessonDialogState(id, index),
+			Elements:         elements,
+		},
+	})
+	if err != nil {
+		attachmentError(w, err.Error())
+		return
+	}
+	attachmentOK(w, "")
+}
+
+func (p *Plugin) attachmentLessonBack(w http.ResponseWriter, r *http.Request, actingUserID string) {
+	req := model.PostActionIntegrationRequestFromJson(r.Body)
+	id := getCourseIDFromPostActionRequest(req)
+
+	c, err := p.store.GetCourse(id)
+	if err != nil {
+		attachmentError(w, err.Error())
+		return
+	}
+
+	if !p.canEditCourse(c, actingUserID) {
+		attachmentError(w, ErrNotCourseEditor.Error())
+		return
+	}
+
+	post, err := p.mm.Post.GetPost(id)
+	if err != nil {
+		attachmentError(w, err.Error())
+		return
+	}
+
+	model.ParseSlackAttachment(post, p.CreateAttachmentFromCourse(c))
+	err = p.mm.Post.UpdatePost(post)
 	if err != nil {
-		return err
+		attachmentError(w, err.Error())
+		return
 	}
-
-	g.CurrentPostID = post.Id
-
-	return p.store.StoreGame(g)
+	attachmentOK(w, "")
 }
 
-func (p *Plugin) attachmentNameCourse(w http.ResponseWriter, r *http.Request, actingUserID string) {
+func (p *Plugin) attachmentLessonDelete(w http.ResponseWriter, r *http.Request, actingUserID string) {
 	req := model.PostActionIntegrationRequestFromJson(r.Body)
 	id := getCourseIDFromPostActionRequest(req)
+	index := getLessonIndexFromPostActionRequest(req)
 
 	c, err := p.store.GetCourse(id)
 	if err != nil {
@@ -2013,27 +3841,19 @@ func (p *Plugin) attachmentNameCourse(w http.ResponseWriter, r *http.Request, ac
 		return
 	}
 
-	defaultName := ""
-	if c != nil {
-		defaultName = c.Name
+	if !p.canEditCourse(c, actingUserID) {
+		attachmentError(w, ErrNotCourseEditor.Error())
+		return
 	}
 
 	err = p.mm.Frontend.OpenInteractiveDialog(model.OpenDialogRequest{
 		TriggerId: req.TriggerId,
-		URL:       p.getDialogURL() + DialogPathNameCourse,
+		URL:       p.getDialogURL() + DialogPathLessonDelete,
 		Dialog: model.Dialog{
-			Title:            "Name course",
-			IntroductionText: "Write the name of your course",
-			SubmitLabel:      "Submit",
-			Elements: []model.DialogElement{
-				{
-					DisplayName: "Course name",
-					Name:        DialogSubmissionFieldName,
-					Type:        DialogTypeText,
-					Default:     defaultName,
-				},
-			},
-			State: id,
+			Title:            "Delete lesson",
+			IntroductionText: "Are you sure you want to delete this lesson? All changes will be lost.",
+			SubmitLabel:      "Delete",
+			State:            getLessonDialogState(id, index),
 		},
 	})
 	if err != nil {
@@ -2043,34 +3863,36 @@ func (p *Plugin) attachmentNameCourse(w http.ResponseWriter, r *http.Request, ac
 	attachmentOK(w, "")
 }
 
-func (p *Plugin) attachmentCourseDescription(w http.ResponseWriter, r *http.Request, actingUserID string) {
+func (p *Plugin) attachmentQuizEditors(w http.ResponseWriter, r *http.Request, actingUserID string) {
 	req := model.PostActionIntegrationRequestFromJson(r.Body)
-	id := getCourseIDFromPostActionRequest(req)
+	id := getQuizIDFromPostActionRequest(req)
 
-	c, err := p.store.GetCourse(id)
+	q, err := p.store.GetQuiz(id)
 	if err != nil {
 		attachmentError(w, err.Error())
 		return
 	}
 
-	defaultDescription := ""
-	if c != nil {
-		defaultDescription = c.Description
+	if !p.canManageQuizEditors(q, actingUserID) {
+		attachmentError(w, "only the creator of the quiz can change its editors")
+		return
 	}
 
 	err = p.mm.Frontend.OpenInteractiveDialog(model.OpenDialogRequest{
 		TriggerId: req.TriggerId,
-		URL:       p.getDialogURL() + DialogPathCourseDescription,
+		URL:       p.getDialogURL() + DialogPathQuizEditors,
 		Dialog: model.Dialog{
-			Title:            "Add course description",
-			IntroductionText: "Write the description of your course",
+			Title:            "Quiz editors",
+			IntroductionText: "Editors can modify the quiz the same way you do.",
 			SubmitLabel:      "Submit",
 			Elements: []model.DialogElement{
 				{
-					DisplayName: "Course description",
-					Name:        DialogSubmissionFieldDescription,
+					DisplayName: "Editors",
+					Name:        DialogSubmissionFieldEditors,
 					Type:        DialogTypeText,
-					Default:     defaultDescription,
+					HelpText:    "Usernames separated by spaces, e.g. @alice @bob",
+					Default:     p.getUsernamesFromUserIDs(q.Editors),
+					Optional:    true,
 				},
 			},
 			State: id,
@@ -2083,17 +3905,39 @@ func (p *Plugin) attachmentCourseDescription(w http.ResponseWriter, r *http.Requ
 	attachmentOK(w, "")
 }
 
-func (p *Plugin) attachmentCourseDelete(w http.ResponseWriter, r *http.Request, actingUserID string) {
+func (p *Plugin) attachmentQuizScope(w http.ResponseWriter, r *http.Request, actingUserID string) {
 	req := model.PostActionIntegrationRequestFromJson(r.Body)
-	id := getCourseIDFromPostActionRequest(req)
-	err := p.mm.Frontend.OpenInteractiveDialog(model.OpenDialogRequest{
+	id := getQuizIDFromPostActionRequest(req)
+
+	q, err := p.store.GetQuiz(id)
+	if err != nil {
+		attachmentError(w, err.Error())
+		return
+	}
+
+	if !p.canEditQuiz(q, actingUserID) {
+		attachmentError(w, ErrNotQuizEditor.Error())
+		return
+	}
+
+	err = p.mm.Frontend.OpenInteractiveDialog(model.OpenDialogRequest{
 		TriggerId: req.TriggerId,
-		URL:       p.getDialogURL() + DialogPathCourseDelete,
+		URL:       p.getDialogURL() + DialogPathQuizScope,
 		Dialog: model.Dialog{
-			Title:            "Cancel creation",
-			IntroductionText: "Are you sure you want to cancel the creation of this course? All changes will be lost.",
-			SubmitLabel:      "Delete",
-			State:            id,
+			Title:            "Quiz scope",
+			IntroductionText: getScopeIntroduction("quiz", q.TeamID),
+			SubmitLabel:      "Submit",
+			Elements: []model.DialogElement{
+				{
+					DisplayName: "Channels",
+					Name:        DialogSubmissionFieldChannels,
+					Type:        DialogTypeText,
+					HelpText:    "Channel names separated by spaces, e.g. ~onboarding ~sales. Leave it empty to make it available to the whole team.",
+					Default:     p.getChannelNamesFromIDs(q.ChannelIDs),
+					Optional:    true,
+				},
+			},
+			State: getQuizDialogState(id, req.PostId),
 		},
 	})
 	if err != nil {
@@ -2103,41 +3947,40 @@ func (p *Plugin) attachmentCourseDelete(w http.ResponseWriter, r *http.Request,
 	attachmentOK(w, "")
 }
 
-func (p *Plugin) attachmentSaveCourse(w http.ResponseWriter, r *http.Request, actingUserID string) {
+func (p *Plugin) attachmentQuizVisibility(w http.ResponseWriter, r *http.Request, actingUserID string) {
 	req := model.PostActionIntegrationRequestFromJson(r.Body)
-	id := getCourseIDFromPostActionRequest(req)
+	id := getQuizIDFromPostActionRequest(req)
 
-	c, err := p.store.GetCourse(id)
+	q, err := p.store.GetQuiz(id)
 	if err != nil {
 		attachmentError(w, err.Error())
 		return
 	}
-	if c == nil {
-		attachmentError(w, "course not found")
-		return
-	}
 
-	if len(c.Lessons) == 0 {
-		attachmentError(w, "cannot save a course with no lessons")
+	if !p.canManageQuizEditors(q, actingUserID) {
+		attachmentError(w, "only the creator of the quiz can change its visibility")
 		return
 	}
 
-	err = p.store.AddAvailableCourse(c)
+	err = p.mm.Frontend.OpenInteractiveDialog(model.OpenDialogRequest{
+		TriggerId: req.TriggerId,
+		URL:       p.getDialogURL() + DialogPathQuizVisibility,
+		Dialog: model.Dialog{
+			Title:            "Quiz visibility",
+			IntroductionText: "Choose who can start the quiz once it is saved. Editors can always start it.",
+			SubmitLabel:      "Submit",
+			Elements:         p.getVisibilityDialogElements(q.Visibility, q.SharedWith, q.SharedGroups),
+			State:            getQuizDialogState(id, req.PostId),
+		},
+	})
 	if err != nil {
 		attachmentError(w, err.Error())
 		return
 	}
-
-	resp := model.PostActionIntegrationResponse{
-		Update: &model.Post{
-			Message: fmt.Sprintf("Course `%s` saved and ready to use.", c.Name),
-			Props:   model.StringInterface{},
-		},
-	}
-	_, _ = w.Write(resp.ToJson())
+	attachmentOK(w, "")
 }
 
-func (p *Plugin) attachmentAddLesson(w http.ResponseWriter, r *http.Request, actingUserID string) {
+func (p *Plugin) attachmentCourseEditors(w http.ResponseWriter, r *http.Request, actingUserID string) {
 	req := model.PostActionIntegrationRequestFromJson(r.Body)
 	id := getCourseIDFromPostActionRequest(req)
 
@@ -2146,35 +3989,32 @@ func (p *Plugin) attachmentAddLesson(w http.ResponseWriter, r *http.Request, act
 		attachmentError(w, err.Error())
 		return
 	}
-	if c == nil {
-		attachmentError(w, "course not found")
+
+	if !p.canManageCourseEditors(c, actingUserID) {
+		attachmentError(w, "only the creator of the course can change its editors")
 		return
 	}
 
-	dr := model.OpenDialogRequest{
+	err = p.mm.Frontend.OpenInteractiveDialog(model.OpenDialogRequest{
 		TriggerId: req.TriggerId,
-		URL:       p.getDialogURL() + DialogPathAddLesson,
+		URL:       p.getDialogURL() + DialogPathCourseEditors,
 		Dialog: model.Dialog{
-			Title:            "Add a lesson",
-			IntroductionText: "Write the lesson basic information to add",
-			SubmitLabel:      "Add",
+			Title:            "Course editors",
+			IntroductionText: "Editors can modify the course the same way you do.",
+			SubmitLabel:      "Submit",
 			Elements: []model.DialogElement{
 				{
-					DisplayName: "Name",
-					Name:        DialogSubmissionFieldName,
-					Type:        DialogTypeText,
-				},
-				{
-					DisplayName: "Description",
-					Name:        DialogSubmissionFieldDescription,
+					DisplayName: "Editors",
+					Name:        DialogSubmissionFieldEditors,
 					Type:        DialogTypeText,
+					HelpText:    "Usernames separated by spaces, e.g. @alice @bob",
+					Default:     p.getUsernamesFromUserIDs(c.Editors),
+					Optional:    true,
 				},
 			},
 			State: id,
 		},
-	}
-
-	err = p.mm.Frontend.OpenInteractiveDialog(dr)
+	})
 	if err != nil {
 		attachmentError(w, err.Error())
 		return
@@ -2182,7 +4022,7 @@ func (p *Plugin) attachmentAddLesson(w http.ResponseWriter, r *http.Request, act
 	attachmentOK(w, "")
 }
 
-func (p *Plugin) attachmentEditLesson(w http.ResponseWriter, r *http.Request, actingUserID string) {
+func (p *Plugin) attachmentCourseScope(w http.ResponseWriter, r *http.Request, actingUserID string) {
 	req := model.PostActionIntegrationRequestFromJson(r.Body)
 	id := getCourseIDFromPostActionRequest(req)
 
@@ -2191,34 +4031,30 @@ func (p *Plugin) attachmentEditLesson(w http.ResponseWriter, r *http.Request, ac
 		attachmentError(w, err.Error())
 		return
 	}
-	if c == nil {
-		attachmentError(w, "quiz not found")
-		return
-	}
 
-	element := model.DialogElement{
-		DisplayName: "Lesson",
-		Name:        DialogSubmissionFieldLesson,
-		Type:        "select",
-		Options:     []*model.PostActionOptions{},
-	}
-	for i, lesson := range c.Lessons {
-		option := &model.PostActionOptions{
-			Text:  lesson.Name,
-			Value: strconv.Itoa(i),
-		}
-		element.Options = append(element.Options, option)
+	if !p.canEditCourse(c, actingUserID) {
+		attachmentError(w, ErrNotCourseEditor.Error())
+		return
 	}
 
 	err = p.mm.Frontend.OpenInteractiveDialog(model.OpenDialogRequest{
 		TriggerId: req.TriggerId,
-		URL:       p.getDialogURL() + DialogPathEditLesson,
+		URL:       p.getDialogURL() + DialogPathCourseScope,
 		Dialog: model.Dialog{
-			Title:            "Edit lessons",
-			IntroductionText: "Select the lesson to edit.",
-			SubmitLabel:      "Edit",
-			State:            id,
-			Elements:         []model.DialogElement{element},
+			Title:            "Course scope",
+			IntroductionText: getScopeIntroduction("course", c.TeamID),
+			SubmitLabel:      "Submit",
+			Elements: []model.DialogElement{
+				{
+					DisplayName: "Channels",
+					Name:        DialogSubmissionFieldChannels,
+					Type:        DialogTypeText,
+					HelpText:    "Channel names separated by spaces, e.g. ~onboarding ~sales. Leave it empty to make it available to the whole team.",
+					Default:     p.getChannelNamesFromIDs(c.ChannelIDs),
+					Optional:    true,
+				},
+			},
+			State: id,
 		},
 	})
 	if err != nil {
@@ -2228,10 +4064,9 @@ func (p *Plugin) attachmentEditLesson(w http.ResponseWriter, r *http.Request, ac
 	attachmentOK(w, "")
 }
 
-func (p *Plugin) attachmentNameLesson(w http.ResponseWriter, r *http.Request, actingUserID string) {
+func (p *Plugin) attachmentCourseVisibility(w http.ResponseWriter, r *http.Request, actingUserID string) {
 	req := model.PostActionIntegrationRequestFromJson(r.Body)
 	id := getCourseIDFromPostActionRequest(req)
-	index := getLessonIndexFromPostActionRequest(req)
 
 	c, err := p.store.GetCourse(id)
 	if err != nil {
@@ -2239,34 +4074,20 @@ func (p *Plugin) attachmentNameLesson(w http.ResponseWriter, r *http.Request, ac
 		return
 	}
 
-	if index < 0 || index >= len(c.Lessons) {
-		attachmentError(w, "Cannot find this lesson. Please hit the back button.")
+	if !p.canManageCourseEditors(c, actingUserID) {
+		attachmentError(w, "only the creator of the course can change its visibility")
 		return
 	}
 
-	lesson := c.Lessons[index]
-
-	defaultName := ""
-	if c != nil {
-		defaultName = lesson.Name
-	}
-
 	err = p.mm.Frontend.OpenInteractiveDialog(model.OpenDialogRequest{
 		TriggerId: req.TriggerId,
-		URL:       p.getDialogURL() + DialogPathNameLesson,
+		URL:       p.getDialogURL() + DialogPathCourseVisibility,
 		Dialog: model.Dialog{
-			Title:            "Name lesson",
-			IntroductionText: "Write the name of your lesson",
+			Title:            "Course visibility",
+			IntroductionText: "Choose who can take the course once it is saved. Editors can always take it.",
 			SubmitLabel:      "Submit",
-			Elements: []model.DialogElement{
-				{
-					DisplayName: "Lesson name",
-					Name:        DialogSubmissionFieldName,
-					Type:        DialogTypeText,
-					Default:     defaultName,
-				},
-			},
-			State: getLessonDialogState(id, index),
+			Elements:         p.getVisibilityDialogElements(c.Visibility, c.SharedWith, c.SharedGroups),
+			State:            id,
 		},
 	})
 	if err != nil {
@@ -2276,55 +4097,64 @@ func (p *Plugin) attachmentNameLesson(w http.ResponseWriter, r *http.Request, ac
 	attachmentOK(w, "")
 }
 
-func (p *Plugin) attachmentLessonIntroduction(w http.ResponseWriter, r *http.Request, actingUserID string) {
+func (p *Plugin) attachmentRestoreRevision(w http.ResponseWriter, r *http.Request, actingUserID string) {
 	req := model.PostActionIntegrationRequestFromJson(r.Body)
-	id := getCourseIDFromPostActionRequest(req)
-	index := getLessonIndexFromPostActionRequest(req)
+	id := getQuizIDFromPostActionRequest(req)
 
-	c, err := p.store.GetCourse(id)
+	q, err := p.store.GetQuiz(id)
 	if err != nil {
 		attachmentError(w, err.Error())
 		return
 	}
 
-	if index < 0 || index >= len(c.Lessons) {
-		attachmentError(w, "Cannot find this lesson. Please hit the back button.")
+	if !p.canEditQuiz(q, actingUserID) {
+		attachmentError(w, ErrNotQuizEditor.Error())
 		return
 	}
 
-	lesson := c.Lessons[index]
+	selected, _ := req.Context[AttachmentContextFieldSelected].(string)
+	number, err := strconv.Atoi(selected)
+	if err != nil {
+		attachmentError(w, "select the revision to restore")
+		return
+	}
 
-	defaultIntroduction := ""
-	if c != nil {
-		defaultIntroduction = lesson.Introduction
+	if number == q.Revision {
+		attachmentError(w, fmt.Sprintf("revision #%d is already the current one", number))
+		return
 	}
 
-	err = p.mm.Frontend.OpenInteractiveDialog(model.OpenDialogRequest{
-		TriggerId: req.TriggerId,
-		URL:       p.getDialogURL() + DialogPathLessonIntroduction,
-		Dialog: model.Dialog{
-			Title:            "Name lesson",
-			IntroductionText: "Write the introduction of your lesson",
-			SubmitLabel:      "Submit",
-			Elements: []model.DialogElement{
-				{
-					DisplayName: "Lesson introduction",
-					Name:        DialogSubmissionFieldDescription,
-					Type:        DialogTypeText,
-					Default:     defaultIntroduction,
-				},
-			},
-			State: getLessonDialogState(id, index),
-		},
-	})
+	revision, err := p.store.GetQuizRevision(id, number)
 	if err != nil {
 		attachmentError(w, err.Error())
 		return
 	}
-	attachmentOK(w, "")
+	if revision == nil {
+		attachmentError(w, "revision not found")
+		return
+	}
+
+	restoreQuizRevision(q, &revision.Quiz)
+	err = p.storeQuizRevision(q, actingUserID)
+	if err != nil {
+		attachmentError(w, err.Error())
+		return
+	}
+
+	post := &model.Post{
+		Message: fmt.Sprintf("Restored revision #%d of the quiz", number),
+	}
+	model.ParseSlackAttachment(post, p.CreateAttachmentFromQuiz(q))
+
+	err = p.mm.Post.DM(p.BotUserID, actingUserID, post)
+	if err != nil {
+		p.mm.Log.Debug("Cannot send the restored quiz", "id", id, "err", err)
+	}
+
+	attachmentOK(w, fmt.Sprintf("Revision #%d restored as revision #%d. The bot sent you the quiz to review it.", number, q.Revision))
 }
 
-func (p *Plugin) attachmentAddResource(w http.ResponseWriter, r *http.Request, actingUserID string) {
+func (p *Plugin) attachmentCourseLesson(w http.ResponseWriter, r *http.Request, actingUserID string) {
 	req := model.PostActionIntegrationRequestFromJson(r.Body)
 	id := getCourseIDFromPostActionRequest(req)
 	index := getLessonIndexFromPostActionRequest(req)
@@ -2335,57 +4165,17 @@ func (p *Plugin) attachmentAddResource(w http.ResponseWriter, r *http.Request, a
 		return
 	}
 
-	if index < 0 || index >= len(c.Lessons) {
-		attachmentError(w, "Cannot find this lesson. Please hit the back button.")
+	if !p.canTakeCourse(c, actingUserID, req.TeamId) {
+		attachmentError(w, "you cannot take this course")
 		return
 	}
-
-	err = p.mm.Frontend.OpenInteractiveDialog(model.OpenDialogRequest{
-		TriggerId: req.TriggerId,
-		URL:       p.getDialogURL() + DialogPathAddResource,
-		Dialog: model.Dialog{
-			Title:            "Add resource",
-			IntroductionText: "Fill the resource information",
-			SubmitLabel:      "Submit",
-			Elements: []model.DialogElement{
-				{
-					DisplayName: "Resource name",
-					Name:        DialogSubmissionFieldName,
-					Type:        DialogTypeText,
-				},
-				{
-					DisplayName: "Resource pretext",
-					Name:        DialogSubmissionFieldDescription,
-					Type:        DialogTypeText,
-				},
-				{
-					DisplayName: "Resource type",
-					Name:        DialogSubmissionFieldType,
-					Type:        DialogTypeSelect,
-					Options: []*model.PostActionOptions{
-						{
-							Text:  "Video",
-							Value: string(ResourceTypeVideo),
-						},
-						{
-							Text:  "Text",
-							Value: string(ResourceTypeText),
-						},
-						{
-							Text:  "Link",
-							Value: string(ResourceTypeLink),
-						},
-					},
-				},
-				{
-					DisplayName: "Resource content",
-					Name:        DialogSubmissionFieldContent,
-					Type:        DialogTypeText,
-				},
-			},
-			State: getLessonDialogState(id, index),
-		},
-	})
+
+	if index < 0 || index >= len(c.Lessons) {
+		attachmentError(w, "Cannot find this lesson. The course may have changed.")
+		return
+	}
+
+	err = p.postCourseLesson(c, index, actingUserID, "")
 	if err != nil {
 		attachmentError(w, err.Error())
 		return
@@ -2393,10 +4183,11 @@ func (p *Plugin) attachmentAddResource(w http.ResponseWriter, r *http.Request, a
 	attachmentOK(w, "")
 }
 
-func (p *Plugin) attachmentAddQuizResource(w http.ResponseWriter, r *http.Request, actingUserID string) {
+func (p *Plugin) attachmentCourseQuiz(w http.ResponseWriter, r *http.Request, actingUserID string) {
 	req := model.PostActionIntegrationRequestFromJson(r.Body)
 	id := getCourseIDFromPostActionRequest(req)
 	index := getLessonIndexFromPostActionRequest(req)
+	resourceIndex := getResourceIndexFromPostActionRequest(req)
 
 	c, err := p.store.GetCourse(id)
 	if err != nil {
@@ -2404,51 +4195,38 @@ func (p *Plugin) attachmentAddQuizResource(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	if !p.canTakeCourse(c, actingUserID, req.TeamId) {
+		attachmentError(w, "you cannot take this course")
+		return
+	}
+
 	if index < 0 || index >= len(c.Lessons) {
-		attachmentError(w, "Cannot find this lesson. Please hit the back button.")
+		attachmentError(w, "Cannot find this lesson. The course may have changed.")
 		return
 	}
 
-	quizOptions := []*model.PostActionOptions{}
-	qq := p.store.GetAvailableQuizes()
+	lesson := c.Lessons[index]
+	if resourceIndex < 0 || resourceIndex >= len(lesson.Resources) || lesson.Resources[resourceIndex].Type != string(ResourceTypeQuiz) {
+		attachmentError(w, "Cannot find this quiz. The course may have changed.")
+		return
+	}
 
-	if len(qq) == 0 {
-		attachmentError(w, "No quizzes available to add.")
+	quiz, err := p.store.GetQuiz(lesson.Resources[resourceIndex].Content)
+	if err != nil {
+		attachmentError(w, err.Error())
 		return
 	}
 
-	for _, q := range qq {
-		quizOptions = append(quizOptions, &model.PostActionOptions{Text: q.Name, Value: q.ID})
+	game, err := newGame(quiz, actingUserID, GameTypeSolo, ScoringTypeAll, 0)
+	if err != nil {
+		attachmentError(w, err.Error())
+		return
 	}
 
-	err = p.mm.Frontend.OpenInteractiveDialog(model.OpenDialogRequest{
-		TriggerId: req.TriggerId,
-		URL:       p.getDialogURL() + DialogPathAddQuizResource,
-		Dialog: model.Dialog{
-			Title:            "Add resource",
-			IntroductionText: "Fill the resource information",
-			SubmitLabel:      "Submit",
-			Elements: []model.DialogElement{
-				{
-					DisplayName: "Resource name",
-					Name:        DialogSubmissionFieldName,
-					Type:        DialogTypeText,
-				},
-				{
-					DisplayName: "Resource pretext",
-					Name:        DialogSubmissionFieldDescription,
-					Type:        DialogTypeText,
-				},
-				{
-					DisplayName: "Quiz",
-					Name:        DialogSubmissionFieldQuiz,
-					Type:        DialogTypeSelect,
-					Options:     quizOptions,
-				},
-			},
-			State: getLessonDialogState(id, index),
-		},
-	})
+	game.CourseID = c.ID
+	game.CourseResource = getCourseResourceKey(index, resourceIndex)
+
+	err = p.startGame(game, "")
 	if err != nil {
 		attachmentError(w, err.Error())
 		return
@@ -2456,9 +4234,9 @@ func (p *Plugin) attachmentAddQuizResource(w http.ResponseWriter, r *http.Reques
 	attachmentOK(w, "")
 }
 
-func (p *Plugin) attachmentRemoveResources(w http.ResponseWriter, r *http.Request, actingUserID string) {
+func (p *Plugin) attachmentCourseFinish(w http.ResponseWriter, r *http.Request, actingUserID string) {
 	req := model.PostActionIntegrationRequestFromJson(r.Body)
-	id := getQuizIDFromPostActionRequest(req)
+	id := getCourseIDFromPostActionRequest(req)
 	index := getLessonIndexFromPostActionRequest(req)
 
 	c, err := p.store.GetCourse(id)
@@ -2466,40 +4244,42 @@ func (p *Plugin) attachmentRemoveResources(w http.ResponseWriter, r *http.Reques
 		attachmentError(w, err.Error())
 		return
 	}
-	if c == nil {
-		attachmentError(w, "course not found")
+
+	if !p.canTakeCourse(c, actingUserID, req.TeamId) {
+		attachmentError(w, "you cannot take this course")
 		return
 	}
 
-	if index < 0 || index >= len(c.Lessons) {
-		attachmentError(w, "Cannot find this lesson. Please hit the back button.")
+	// The course can only be finished from its last lesson. Lessons added after the
+	// button was posted must still be taken.
+	if index < 0 || index != len(c.Lessons)-1 {
+		attachmentError(w, "Cannot find this lesson. The course may have changed.")
 		return
 	}
 
-	lesson := c.Lessons[index]
-
-	elements := []model.DialogElement{}
-	for i, resource := range lesson.Resources {
-		element := model.DialogElement{
-			DisplayName: resource.Name,
-			Name:        strconv.Itoa(i),
-			Type:        DialogTypeBool,
-			Optional:    true,
+	progress, err := p.updateCourseProgress(c, actingUserID, func(cp *CourseProgress) {
+		for i := 0; i <= index; i++ {
+			cp.CompletedLessons[i] = true
 		}
-		elements = append(elements, element)
+	})
+	if err != nil {
+		attachmentError(w, err.Error())
+		return
 	}
 
-	err = p.mm.Frontend.OpenInteractiveDialog(model.OpenDialogRequest{
-		TriggerId: req.TriggerId,
-		URL:       p.getDialogURL() + DialogPathRemoveResources,
-		Dialog: model.Dialog{
-			Title:            "Remove resources",
-			IntroductionText: "Select the resources to delete.",
-			SubmitLabel:      "Remove selected",
-			State:            getLessonDialogState(id, index),
-			Elements:         elements,
-		},
-	})
+	if progress.Completed {
+		attachmentOK(w, "")
+		return
+	}
+
+	post := &model.Post{
+		Message: fmt.Sprintf("You went through all the lessons of the course `%s`, but you still need to pass these quizzes to complete it:", c.Name),
+	}
+	for _, name := range progress.PendingQuizzes(c) {
+		post.Message += "\n- " + name
+	}
+
+	err = p.mm.Post.DM(p.BotUserID, actingUserID, post)
 	if err != nil {
 		attachmentError(w, err.Error())
 		return
@@ -2507,24 +4287,32 @@ func (p *Plugin) attachmentRemoveResources(w http.ResponseWriter, r *http.Reques
 	attachmentOK(w, "")
 }
 
-func (p *Plugin) attachmentLessonBack(w http.ResponseWriter, r *http.Request, actingUserID string) {
+func (p *Plugin) attachmentExportResults(w http.ResponseWriter, r *http.Request, actingUserID string) {
 	req := model.PostActionIntegrationRequestFromJson(r.Body)
-	id := getCourseIDFromPostActionRequest(req)
+	id := getGameIDFromPostActionRequest(req)
 
-	c, err := p.store.GetCourse(id)
+	result, err := p.store.GetGameResult(id)
 	if err != nil {
 		attachmentError(w, err.Error())
 		return
 	}
+	if result == nil {
+		attachmentError(w, "Cannot find the results of this game.")
+		return
+	}
 
-	post, err := p.mm.Post.GetPost(id)
+	if !p.canExportGameResult(result, actingUserID) {
+		attachmentError(w, "Only the GM and the quiz editors can export the results.")
+		return
+	}
+
+	files, err := getGameResultExportFiles(result)
 	if err != nil {
 		attachmentError(w, err.Error())
 		return
 	}
 
-	model.ParseSlackAttachment(post, p.CreateAttachmentFromCourse(c))
-	err = p.mm.Post.UpdatePost(post)
+	err = p.sendExportFiles(actingUserID, fmt.Sprintf("Results of the quiz `%s`", result.QuizName), files)
 	if err != nil {
 		attachmentError(w, err.Error())
 		return
@@ -2532,25 +4320,73 @@ func (p *Plugin) attachmentLessonBack(w http.ResponseWriter, r *http.Request, ac
 	attachmentOK(w, "")
 }
 
-func (p *Plugin) attachmentLessonDelete(w http.ResponseWriter, r *http.Request, actingUserID string) {
-	req := model.PostActionIntegrationRequestFromJson(r.Body)
-	id := getCourseIDFromPostActionRequest(req)
-	index := getLessonIndexFromPostActionRequest(req)
-	err := p.mm.Frontend.OpenInteractiveDialog(model.OpenDialogRequest{
-		TriggerId: req.TriggerId,
-		URL:       p.getDialogURL() + DialogPathLessonDelete,
-		Dialog: model.Dialog{
-			Title:            "Delete lesson",
-			IntroductionText: "Are you sure you want to delete this lesson? All changes will be lost.",
-			SubmitLabel:      "Delete",
-			State:            getLessonDialogState(id, index),
-		},
+func (p *Plugin) postCourseLesson(c *Course, index int, userID, message string) error {
+	_, err := p.updateCourseProgress(c, userID, func(cp *CourseProgress) {
+		cp.VisitLesson(c, index)
 	})
 	if err != nil {
-		attachmentError(w, err.Error())
-		return
+		return err
 	}
-	attachmentOK(w, "")
+
+	post := &model.Post{
+		Message: message,
+	}
+
+	model.ParseSlackAttachment(post, p.CourseLessonAttachments(c, index))
+	return p.mm.Post.DM(p.BotUserID, userID, post)
+}
+
+// updateCourseProgress applies update to the progress of the user on the course. The
+// first time the course is completed, the user is congratulated and granted a badge.
+func (p *Plugin) updateCourseProgress(c *Course, userID string, update func(cp *CourseProgress)) (*CourseProgress, error) {
+	justCompleted := false
+	progress, err := p.store.UpdateCourseProgress(userID, c.ID, func(cp *CourseProgress) error {
+		update(cp)
+		justCompleted = !cp.Completed && cp.IsComplete(c)
+		if justCompleted {
+			cp.Completed = true
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if justCompleted {
+		post := &model.Post{
+			Message: fmt.Sprintf("Congratulations! You completed the course `%s`.", c.Name),
+		}
+		err = p.mm.Post.DM(p.BotUserID, userID, post)
+		if err != nil {
+			p.mm.Log.Debug("Cannot send course completion message", "err", err)
+		}
+
+		p.GrantBadge(AchievementNameCourseGraduate, userID)
+	}
+
+	return progress, nil
+}
+
+// recordCourseQuizScore stores the result of a finished game started from a course quiz
+// resource in the progress of the player.
+func (p *Plugin) recordCourseQuizScore(g *Game) error {
+	c, err := p.store.GetCourse(g.CourseID)
+	if isNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	user, err := p.mm.User.Get(g.GM)
+	if err != nil {
+		return err
+	}
+
+	_, err = p.updateCourseProgress(c, g.GM, func(cp *CourseProgress) {
+		cp.RecordQuizScore(g.CourseResource, g.Score[user.Username], g.NQuestions)
+	})
+	return err
 }
 
 func getQuizIDFromPostActionRequest(req *model.PostActionIntegrationRequest) string {
@@ -2577,6 +4413,14 @@ func getLessonIndexFromPostActionRequest(req *model.PostActionIntegrationRequest
 	return int(id)
 }
 
+func getResourceIndexFromPostActionRequest(req *model.PostActionIntegrationRequest) int {
+	index, ok := req.Context[AttachmentContextFieldResource].(float64)
+	if !ok {
+		index = -1
+	}
+	return int(index)
+}
+
 func getGameIDFromPostActionRequest(req *model.PostActionIntegrationRequest) string {
 	id, ok := req.Context[AttachmentContextFieldGameID].(string)
 	if !ok || id == "" {
@@ -2585,6 +4429,315 @@ func getGameIDFromPostActionRequest(req *model.PostActionIntegrationRequest) str
 	return id
 }
 
+func getQuizTypeOptions() []*model.PostActionOptions {
+	out := []*model.PostActionOptions{}
+	for _, t := range []QuizType{QuizTypeSingleAnswer, QuizTypeMultipleChoice, QuizTypeMultiSelect, QuizTypeNumeric, QuizTypeOrdering} {
+		out = append(out, &model.PostActionOptions{
+			Text:  getQuizTypeName(t),
+			Value: string(t),
+		})
+	}
+
+	return out
+}
+
+func getQuizTypeName(t QuizType) string {
+	switch t {
+	case QuizTypeSingleAnswer:
+		return "Single answer"
+	case QuizTypeMultipleChoice:
+		return "Multiple choice"
+	case QuizTypeMultiSelect:
+		return "Multi-select"
+	case QuizTypeNumeric:
+		return "Numeric"
+	case QuizTypeOrdering:
+		return "Ordering"
+	}
+
+	return string(t)
+}
+
+func getQuestionDialogElements(question *Question) []model.DialogElement {
+	elements := []model.DialogElement{
+		{
+			DisplayName: "Question",
+			Name:        DialogSubmissionFieldQuestion,
+			Type:        DialogTypeText,
+			Default:     question.Question,
+		},
+		{
+			DisplayName: "Answer",
+			Name:        DialogSubmissionFieldAnswer,
+			Type:        DialogTypeText,
+			Default:     question.CorrectAnswer,
+		},
+	}
+
+	switch question.Type {
+	case QuizTypeNumeric:
+		elements[1].HelpText = "The correct number."
+		tolerance := ""
+		if question.Tolerance != 0 {
+			tolerance = strconv.FormatFloat(question.Tolerance, 'f', -1, 64)
+		}
+		elements = append(elements, model.DialogElement{
+			DisplayName: "Tolerance",
+			Name:        DialogSubmissionFieldTolerance,
+			Type:        DialogTypeText,
+			Default:     tolerance,
+			HelpText:    "How far answers can be from the correct number. For example, a tolerance of 0.5 accepts from 9.5 to 10.5 when the answer is 10.",
+			Optional:    true,
+		}, model.DialogElement{
+			DisplayName: "Unit",
+			Name:        DialogSubmissionFieldUnit,
+			Type:        DialogTypeText,
+			Default:     question.Unit,
+			HelpText:    "Shown to the players, who can write it after the number.",
+			Optional:    true,
+		})
+	case QuizTypeOrdering:
+		elements[1].DisplayName = "First item"
+		elements = append(elements, model.DialogElement{
+			DisplayName: "Next items",
+			Name:        DialogSubmissionFieldCorrectAnswers,
+			Type:        DialogTypeTextArea,
+			Default:     strings.Join(question.ExtraCorrectAnswers, "\n"),
+			HelpText:    fmt.Sprintf("One item per line, in order. Players see the items shuffled and have to put them back in order. Up to %d items in total.", MaxOrderingItems),
+		})
+	case QuizTypeMultiSelect:
+		elements = append(elements, model.DialogElement{
+			DisplayName: "Other correct answers",
+			Name:        DialogSubmissionFieldCorrectAnswers,
+			Type:        DialogTypeTextArea,
+			Default:     strings.Join(question.ExtraCorrectAnswers, "\n"),
+			HelpText:    "One answer per line. Players need to select all the correct answers.",
+			Optional:    true,
+		})
+	}
+
+	if question.Type.HasOptions() {
+		for i := 0; i < MaxIncorrectAnswers; i++ {
+			e := model.DialogElement{
+				DisplayName: "Incorrect Answer",
+				Name:        DialogSubmissionFieldWrongAnswer + strconv.Itoa(i),
+				Type:        DialogTypeText,
+				Optional:    i >= MinIncorrectAnswers || question.Type == QuizTypeMultiSelect,
+			}
+			if i == 0 && question.Type == QuizTypeMultiSelect {
+				e.HelpText = fmt.Sprintf("Fill up to %d options besides the correct answer, counting the other correct answers.", MaxIncorrectAnswers)
+			} else if i == 0 {
+				e.HelpText = fmt.Sprintf("Fill between %d and %d incorrect answers. Use one for true/false questions.", MinIncorrectAnswers, MaxIncorrectAnswers)
+			}
+			if i < len(question.IncorrectAnswers) {
+				e.Default = question.IncorrectAnswers[i]
+			}
+			elements = append(elements, e)
+		}
+	}
+
+	if question.Type == QuizTypeSingleAnswer {
+		elements = append(elements, model.DialogElement{
+			DisplayName: "Other accepted answers",
+			Name:        DialogSubmissionFieldAlternatives,
+			Type:        DialogTypeTextArea,
+			Default:     strings.Join(question.AlternativeAnswers, "\n"),
+			HelpText:    "One answer per line. Case, accents, punctuation and small typos are ignored when answers are checked.",
+			Optional:    true,
+		})
+	}
+
+	elements = append(elements, model.DialogElement{
+		DisplayName: "Explanation",
+		Name:        DialogSubmissionFieldExplanation,
+		Type:        DialogTypeTextArea,
+		Default:     question.Explanation,
+		HelpText:    "Shown with the correct answer once the question is over.",
+		Optional:    true,
+	}, model.DialogElement{
+		DisplayName: "Reference link",
+		Name:        DialogSubmissionFieldReferenceLink,
+		Type:        DialogTypeText,
+		SubType:     DialogSubtypeURL,
+		Default:     question.ReferenceLink,
+		HelpText:    "A link to learn more about the answer.",
+		Optional:    true,
+	})
+
+	return elements
+}
+
+// getQuestionFromSubmission reads the fields created by getQuestionDialogElements.
+// The returned errors are meant to be sent back to the dialog.
+func getQuestionFromSubmission(questionType QuizType, submission map[string]interface{}) (Question, map[string]string) {
+	question, ok := submission[DialogSubmissionFieldQuestion].(string)
+	question = strings.TrimSpace(question)
+	if !ok || question == "" {
+		return Question{}, map[string]string{
+			DialogSubmissionFieldQuestion: "Could not get question",
+		}
+	}
+
+	answer, ok := submission[DialogSubmissionFieldAnswer].(string)
+	answer = strings.TrimSpace(answer)
+	if !ok || answer == "" {
+		return Question{}, map[string]string{
+			DialogSubmissionFieldAnswer: "Could not get answer",
+		}
+	}
+
+	tolerance := 0.0
+	unit := ""
+	if questionType == QuizTypeNumeric {
+		if _, ok := parseAnswerNumber(answer); !ok {
+			return Question{}, map[string]string{
+				DialogSubmissionFieldAnswer: "The answer must be a number",
+			}
+		}
+
+		text, _ := submission[DialogSubmissionFieldTolerance].(string)
+		if text = strings.TrimSpace(text); text != "" {
+			tolerance, ok = parseAnswerNumber(text)
+			if !ok || tolerance < 0 {
+				return Question{}, map[string]string{
+					DialogSubmissionFieldTolerance: "The tolerance must be a positive number",
+				}
+			}
+		}
+
+		unit, _ = submission[DialogSubmissionFieldUnit].(string)
+		unit = strings.TrimSpace(unit)
+	}
+
+	seen := map[string]bool{strings.ToLower(answer): true}
+	correctAnswers := []string{}
+	if questionType == QuizTypeMultiSelect || questionType == QuizTypeOrdering {
+		text, _ := submission[DialogSubmissionFieldCorrectAnswers].(string)
+		for _, correctAnswer := range strings.Split(text, "\n") {
+			correctAnswer = strings.TrimSpace(correctAnswer)
+			if correctAnswer == "" {
+				continue
+			}
+			if seen[strings.ToLower(correctAnswer)] {
+				return Question{}, map[string]string{
+					DialogSubmissionFieldCorrectAnswers: fmt.Sprintf("The answer %q is repeated", correctAnswer),
+				}
+			}
+			seen[strings.ToLower(correctAnswer)] = true
+			correctAnswers = append(correctAnswers, correctAnswer)
+		}
+	}
+
+	if questionType == QuizTypeOrdering && (len(correctAnswers)+1 < MinOrderingItems || len(correctAnswers)+1 > MaxOrderingItems) {
+		return Question{}, map[string]string{
+			DialogSubmissionFieldCorrectAnswers: fmt.Sprintf("Write between %d and %d items in total", MinOrderingItems, MaxOrderingItems),
+		}
+	}
+
+	wrongAnswers := []string{}
+	if questionType.HasOptions() {
+		for i := 0; i < MaxIncorrectAnswers; i++ {
+			fieldName := DialogSubmissionFieldWrongAnswer + strconv.Itoa(i)
+			wrongAnswer, _ := submission[fieldName].(string)
+			wrongAnswer = strings.TrimSpace(wrongAnswer)
+			if wrongAnswer == "" {
+				continue
+			}
+			if seen[strings.ToLower(wrongAnswer)] {
+				return Question{}, map[string]string{
+					fieldName: "This answer is repeated",
+				}
+			}
+			seen[strings.ToLower(wrongAnswer)] = true
+			wrongAnswers = append(wrongAnswers, wrongAnswer)
+		}
+
+		if len(wrongAnswers)+len(correctAnswers) < MinIncorrectAnswers {
+			return Question{}, map[string]string{
+				DialogSubmissionFieldWrongAnswer + "0": fmt.Sprintf("Not enough incorrect answers, the minimum is %d", MinIncorrectAnswers),
+			}
+		}
+
+		if len(wrongAnswers)+len(correctAnswers) > MaxIncorrectAnswers {
+			return Question{}, map[string]string{
+				DialogSubmissionFieldCorrectAnswers: fmt.Sprintf("Too many options, the maximum besides the correct answer is %d", MaxIncorrectAnswers),
+			}
+		}
+	}
+
+	alternatives := []string{}
+	if questionType == QuizTypeSingleAnswer {
+		text, _ := submission[DialogSubmissionFieldAlternatives].(string)
+		for _, alternative := range strings.Split(text, "\n") {
+			alternative = strings.TrimSpace(alternative)
+			if alternative != "" && alternative != answer {
+				alternatives = append(alternatives, alternative)
+			}
+		}
+	}
+
+	explanation, _ := submission[DialogSubmissionFieldExplanation].(string)
+	referenceLink, _ := submission[DialogSubmissionFieldReferenceLink].(string)
+	referenceLink = strings.TrimSpace(referenceLink)
+	if err := validateReferenceLink(referenceLink); err != nil {
+		return Question{}, map[string]string{
+			DialogSubmissionFieldReferenceLink: "The link must start with http:// or https://",
+		}
+	}
+
+	return Question{
+		Type:                questionType,
+		Question:            question,
+		CorrectAnswer:       answer,
+		IncorrectAnswers:    wrongAnswers,
+		AlternativeAnswers:  alternatives,
+		ExtraCorrectAnswers: correctAnswers,
+		Tolerance:           tolerance,
+		Unit:                unit,
+		Explanation:         strings.TrimSpace(explanation),
+		ReferenceLink:       referenceLink,
+	}, nil
+}
+
+func getQuizDialogState(quizID, postID string) string {
+	return quizID + "," + postID
+}
+
+// getQuizIDAndPostIDFromState returns the quiz and the post holding its creation
+// attachment. Both are the same for quizzes being created for the first time.
+func getQuizIDAndPostIDFromState(state string) (string, string) {
+	parts := strings.Split(state, ",")
+	if len(parts) < 2 || parts[1] == "" {
+		return parts[0], parts[0]
+	}
+
+	return parts[0], parts[1]
+}
+
+// getQuestionDialogState returns the state of the dialogs to add or edit questions.
+// The question ID is empty when adding a question.
+func getQuestionDialogState(quizID, postID, questionID string, questionType QuizType) string {
+	return getQuizDialogState(quizID, postID) + "," + questionID + "," + string(questionType)
+}
+
+func getQuestionIDFromState(state string) string {
+	parts := strings.Split(state, ",")
+	if len(parts) < 3 {
+		return ""
+	}
+
+	return parts[2]
+}
+
+func getQuestionTypeFromState(state string) QuizType {
+	parts := strings.Split(state, ",")
+	if len(parts) < 4 {
+		return ""
+	}
+
+	return QuizType(parts[3])
+}
+
 func getLessonDialogState(cID string, index int) string {
 	return fmt.Sprintf("%s,%d", cID, index)
 }
@@ -0,0 +1,324 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateQuestion(t *testing.T) {
+	p, _ := newPermissionsTestPlugin(t)
+
+	body := dialogRequest(getQuestionDialogState(testQuizID, "postid", "questionid", QuizTypeMultipleChoice), map[string]interface{}{
+		DialogSubmissionFieldQuestion:          "Updated question",
+		DialogSubmissionFieldAnswer:            "Updated answer",
+		DialogSubmissionFieldWrongAnswer + "0": "d",
+		DialogSubmissionFieldWrongAnswer + "1": "e",
+		DialogSubmissionFieldWrongAnswer + "2": "f",
+	})
+	doRequest(p, DialogPath+DialogPathUpdateQuestion, "editor", body)
+
+	q, err := p.store.GetQuiz(testQuizID)
+	require.NoError(t, err)
+	require.Len(t, q.Questions, 1)
+	assert.Equal(t, Question{
+		ID:               "questionid",
+		Type:             QuizTypeMultipleChoice,
+		Question:         "Updated question",
+		CorrectAnswer:    "Updated answer",
+		IncorrectAnswers: []string{"d", "e", "f"},
+	}, q.Questions[0])
+}
+
+func TestUpdateQuestionIncorrectAnswers(t *testing.T) {
+	p, _ := newPermissionsTestPlugin(t)
+
+	body := dialogRequest(getQuestionDialogState(testQuizID, "postid", "questionid", QuizTypeMultipleChoice), map[string]interface{}{
+		DialogSubmissionFieldQuestion:          "Is the sun a star?",
+		DialogSubmissionFieldAnswer:            "True",
+		DialogSubmissionFieldWrongAnswer + "0": "False",
+		DialogSubmissionFieldWrongAnswer + "1": "",
+		DialogSubmissionFieldWrongAnswer + "2": " ",
+	})
+	doRequest(p, DialogPath+DialogPathUpdateQuestion, "editor", body)
+
+	q, err := p.store.GetQuiz(testQuizID)
+	require.NoError(t, err)
+	require.Len(t, q.Questions, 1)
+	assert.Equal(t, []string{"False"}, q.Questions[0].IncorrectAnswers)
+	assert.Equal(t, 1, q.ValidQuestions())
+
+	body = dialogRequest(getQuestionDialogState(testQuizID, "postid", "questionid", QuizTypeMultipleChoice), map[string]interface{}{
+		DialogSubmissionFieldWrongAnswer + "0": "",
+		DialogSubmissionFieldWrongAnswer + "1": "",
+		DialogSubmissionFieldWrongAnswer + "2": "",
+	})
+	assert.Contains(t, doRequest(p, DialogPath+DialogPathUpdateQuestion, "editor", body), "Not enough incorrect answers")
+
+	body = dialogRequest(getQuestionDialogState(testQuizID, "postid", "questionid", QuizTypeMultipleChoice), map[string]interface{}{
+		DialogSubmissionFieldWrongAnswer + "1": "new answer",
+	})
+	assert.Contains(t, doRequest(p, DialogPath+DialogPathUpdateQuestion, "editor", body), "This answer is repeated")
+
+	q, err = p.store.GetQuiz(testQuizID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"False"}, q.Questions[0].IncorrectAnswers)
+}
+
+func TestUpdateQuestionMultiSelect(t *testing.T) {
+	p, _ := newPermissionsTestPlugin(t)
+
+	q, err := p.store.GetQuiz(testQuizID)
+	require.NoError(t, err)
+	q.Questions[0].Type = QuizTypeMultiSelect
+	require.NoError(t, p.store.StoreQuiz(q))
+
+	body := dialogRequest(getQuestionDialogState(testQuizID, "postid", "questionid", QuizTypeMultiSelect), map[string]interface{}{
+		DialogSubmissionFieldQuestion:          "Which are primes?",
+		DialogSubmissionFieldAnswer:            "2",
+		DialogSubmissionFieldCorrectAnswers:    "3\n\n 5 \n",
+		DialogSubmissionFieldWrongAnswer + "0": "",
+		DialogSubmissionFieldWrongAnswer + "1": "4",
+		DialogSubmissionFieldWrongAnswer + "2": "",
+	})
+	doRequest(p, DialogPath+DialogPathUpdateQuestion, "editor", body)
+
+	q, err = p.store.GetQuiz(testQuizID)
+	require.NoError(t, err)
+	require.Len(t, q.Questions, 1)
+	assert.Equal(t, []string{"2", "3", "5"}, q.Questions[0].CorrectOptions())
+	assert.Equal(t, []string{"4"}, q.Questions[0].IncorrectAnswers)
+	assert.Equal(t, 1, q.ValidQuestions())
+
+	body = dialogRequest(getQuestionDialogState(testQuizID, "postid", "questionid", QuizTypeMultiSelect), map[string]interface{}{
+		DialogSubmissionFieldCorrectAnswers: "x",
+	})
+	assert.Contains(t, doRequest(p, DialogPath+DialogPathUpdateQuestion, "editor", body), "is repeated")
+}
+
+func TestNewGameMixedQuestionTypes(t *testing.T) {
+	q := &Quiz{
+		Type: QuizTypeMultipleChoice,
+		Questions: []Question{
+			{ID: "q1", Type: QuizTypeSingleAnswer, Question: "Capital of France?", CorrectAnswer: "Paris"},
+			{ID: "q2", Type: QuizTypeMultipleChoice, Question: "Capital of Spain?", CorrectAnswer: "Madrid", IncorrectAnswers: []string{"Sevilla"}},
+			{ID: "q3", Type: QuizTypeMultipleChoice, Question: "Capital of Italy?", CorrectAnswer: "Rome"},
+		},
+	}
+
+	g, err := newGame(q, "gm", GameTypeSolo, ScoringTypeAll, 0)
+	require.NoError(t, err)
+	require.Len(t, g.RemainingQuestions, 2)
+
+	for g.RemainingQuestions[0].Type != QuizTypeSingleAnswer {
+		g.RemainingQuestions = append(g.RemainingQuestions[1:], g.RemainingQuestions[0])
+	}
+	g.shuffleAnswers()
+	assert.Empty(t, g.CurrentAnswers)
+
+	g.RemainingQuestions = g.RemainingQuestions[1:]
+	g.shuffleAnswers()
+	assert.Len(t, g.CurrentAnswers, 2)
+}
+
+func TestGetQuestionFromSubmissionNewTypes(t *testing.T) {
+	question, errors := getQuestionFromSubmission(QuizTypeNumeric, map[string]interface{}{
+		DialogSubmissionFieldQuestion:  "Speed of light?",
+		DialogSubmissionFieldAnswer:    "300,000",
+		DialogSubmissionFieldTolerance: "1000",
+		DialogSubmissionFieldUnit:      " km/s ",
+	})
+	require.Nil(t, errors)
+	assert.Equal(t, 1000.0, question.Tolerance)
+	assert.Equal(t, "km/s", question.Unit)
+	assert.NoError(t, question.Validate())
+
+	_, errors = getQuestionFromSubmission(QuizTypeNumeric, map[string]interface{}{
+		DialogSubmissionFieldQuestion: "Speed of light?",
+		DialogSubmissionFieldAnswer:   "fast",
+	})
+	assert.Contains(t, errors, DialogSubmissionFieldAnswer)
+
+	question, errors = getQuestionFromSubmission(QuizTypeOrdering, map[string]interface{}{
+		DialogSubmissionFieldQuestion:       "Order the planets by distance to the sun",
+		DialogSubmissionFieldAnswer:         "Mercury",
+		DialogSubmissionFieldCorrectAnswers: "Venus\n Earth \n\nMars",
+	})
+	require.Nil(t, errors)
+	assert.Equal(t, []string{"Mercury", "Venus", "Earth", "Mars"}, question.CorrectOptions())
+	assert.NoError(t, question.Validate())
+
+	_, errors = getQuestionFromSubmission(QuizTypeOrdering, map[string]interface{}{
+		DialogSubmissionFieldQuestion: "Order the planets by distance to the sun",
+		DialogSubmissionFieldAnswer:   "Mercury",
+	})
+	assert.Contains(t, errors, DialogSubmissionFieldCorrectAnswers)
+}
+
+func TestGetQuestionFromSubmissionExplanation(t *testing.T) {
+	question, errors := getQuestionFromSubmission(QuizTypeSingleAnswer, map[string]interface{}{
+		DialogSubmissionFieldQuestion:      "Capital of France?",
+		DialogSubmissionFieldAnswer:        "Paris",
+		DialogSubmissionFieldExplanation:   " Paris has been the capital since 987. ",
+		DialogSubmissionFieldReferenceLink: " https://en.wikipedia.org/wiki/Paris ",
+	})
+	require.Nil(t, errors)
+	assert.Equal(t, "Paris has been the capital since 987.", question.Explanation)
+	assert.Equal(t, "https://en.wikipedia.org/wiki/Paris", question.ReferenceLink)
+
+	_, errors = getQuestionFromSubmission(QuizTypeSingleAnswer, map[string]interface{}{
+		DialogSubmissionFieldQuestion:      "Capital of France?",
+		DialogSubmissionFieldAnswer:        "Paris",
+		DialogSubmissionFieldReferenceLink: "wikipedia",
+	})
+	assert.Contains(t, errors, DialogSubmissionFieldReferenceLink)
+}
+
+func TestHandlersReportMissingQuiz(t *testing.T) {
+	p, _ := newPermissionsTestPlugin(t)
+
+	body := doRequest(p, AttachmentPath+AttachmentPathSave, "editor", attachmentRequest("missing"))
+	assert.Contains(t, body, "Error: quiz not found")
+
+	body = doRequest(p, DialogPath+DialogPathNameQuiz, "editor", dialogRequest("missing", nil))
+	assert.Contains(t, body, "Error: quiz not found")
+
+	body = doRequest(p, AttachmentPath+AttachmentPathSaveCourse, "editor", attachmentRequest("missing"))
+	assert.Contains(t, body, "Error: course not found")
+}
+
+func TestRecordCourseQuizScoreMultiSelect(t *testing.T) {
+	p, api := newPermissionsTestPlugin(t)
+	api.On("GetUser", "player").Return(&model.User{Id: "player", Username: "player"}, nil)
+
+	c, err := p.store.GetCourse(testCourseID)
+	require.NoError(t, err)
+	c.Lessons[0].Resources = append(c.Lessons[0].Resources, &Resource{Name: "Quiz", Type: string(ResourceTypeQuiz), Content: testQuizID})
+	require.NoError(t, p.store.StoreCourse(c))
+
+	g := &Game{
+		Quiz:           Quiz{ID: testQuizID, Type: QuizTypeMultiSelect, MultiSelectScoring: MultiSelectScoringPartial},
+		GM:             "player",
+		Type:           GameTypeSolo,
+		ScoringType:    ScoringTypeAll,
+		CourseID:       testCourseID,
+		CourseResource: getCourseResourceKey(0, 1),
+		NQuestions:     2,
+		RemainingQuestions: []Question{
+			{ID: "q1", Type: QuizTypeMultiSelect},
+			{ID: "q2", Type: QuizTypeMultiSelect},
+		},
+		CurrentAnswers: []string{"a", "b", "c", "d"},
+		CorrectAnswers: []int{0, 1, 2},
+	}
+
+	_, err = g.RecordSelectedAnswers("q1", "player", []int{0, 1, 2})
+	require.NoError(t, err)
+	g.RemainingQuestions = g.RemainingQuestions[1:]
+	g.AlreadyAnswered = map[string]bool{}
+	_, err = g.RecordSelectedAnswers("q2", "player", []int{0})
+	require.NoError(t, err)
+
+	require.NoError(t, p.recordCourseQuizScore(g))
+
+	cp, err := p.store.GetCourseProgress("player", testCourseID)
+	require.NoError(t, err)
+	score := cp.QuizScores[getCourseResourceKey(0, 1)]
+	assert.Equal(t, 2, score.Total)
+	assert.InDelta(t, 1+1.0/3, score.Score, 1e-9)
+	assert.True(t, score.Passed())
+
+	// Two thirds of a question out of two is not a pass, and does not replace the best
+	// score.
+	assert.False(t, CourseQuizScore{Score: 2.0 / 3, Total: 2}.Passed())
+	cp.RecordQuizScore(getCourseResourceKey(0, 1), 2.0/3, 2)
+	assert.Equal(t, score, cp.QuizScores[getCourseResourceKey(0, 1)])
+}
+
+func TestCoursePlayerNavigation(t *testing.T) {
+	p, api := newPermissionsTestPlugin(t)
+	api.On("GetDirectChannel", mock.Anything, mock.Anything).Return(&model.Channel{Id: "dm"}, nil)
+	var posts []*model.Post
+	api.On("CreatePost", mock.Anything).Return(&model.Post{Id: "post"}, nil).Run(func(args mock.Arguments) {
+		posts = append(posts, args.Get(0).(*model.Post).Clone())
+	})
+
+	c, err := p.store.GetCourse(testCourseID)
+	require.NoError(t, err)
+	c.Lessons = append(c.Lessons, &Lesson{Name: "Second"}, &Lesson{Name: "Third"})
+	require.NoError(t, p.store.StoreCourse(c))
+
+	lessonRequest := func(index int) []byte {
+		b, _ := json.Marshal(&model.PostActionIntegrationRequest{
+			Context: map[string]interface{}{
+				AttachmentContextFieldID:          testCourseID,
+				AttachmentContextFieldLessonIndex: index,
+			},
+		})
+		return b
+	}
+
+	navigation := func() map[string]interface{} {
+		require.NotEmpty(t, posts)
+		attachments := posts[len(posts)-1].Attachments()
+		out := map[string]interface{}{}
+		for _, action := range attachments[len(attachments)-1].Actions {
+			out[action.Name] = action.Integration.Context[AttachmentContextFieldLessonIndex]
+		}
+		return out
+	}
+
+	body := doRequest(p, DialogPath+DialogPathCourseStart, "editor", dialogRequest("", map[string]interface{}{DialogSubmissionFieldCourse: testCourseID}))
+	assert.Equal(t, "{}", body)
+	assert.Contains(t, posts[len(posts)-1].Message, "Welcome to the course")
+	assert.Equal(t, map[string]interface{}{"Next lesson": 1}, navigation())
+
+	body = doRequest(p, AttachmentPath+AttachmentPathCourseLesson, "editor", lessonRequest(1))
+	assert.NotContains(t, body, "Error")
+	assert.Equal(t, map[string]interface{}{"Previous lesson": 0, "Next lesson": 2}, navigation())
+
+	body = doRequest(p, AttachmentPath+AttachmentPathCourseLesson, "editor", lessonRequest(2))
+	assert.NotContains(t, body, "Error")
+	assert.Equal(t, map[string]interface{}{"Previous lesson": 1, "Finish course": 2}, navigation())
+
+	body = doRequest(p, AttachmentPath+AttachmentPathCourseLesson, "editor", lessonRequest(0))
+	assert.NotContains(t, body, "Error")
+	assert.Equal(t, map[string]interface{}{"Next lesson": 1}, navigation())
+
+	for _, index := range []int{-1, 3} {
+		sent := len(posts)
+		body = doRequest(p, AttachmentPath+AttachmentPathCourseLesson, "editor", lessonRequest(index))
+		assert.Contains(t, body, "Cannot find this lesson", index)
+		assert.Len(t, posts, sent, index)
+	}
+
+	cp, err := p.store.GetCourseProgress("editor", testCourseID)
+	require.NoError(t, err)
+	assert.Equal(t, 0, cp.CurrentLesson)
+	assert.Equal(t, map[int]bool{0: true, 1: true}, cp.CompletedLessons)
+
+	body = doRequest(p, AttachmentPath+AttachmentPathCourseLesson, "editor", lessonRequest(1))
+	assert.NotContains(t, body, "Error")
+	body = doRequest(p, DialogPath+DialogPathCourseStart, "editor", dialogRequest("", map[string]interface{}{DialogSubmissionFieldCourse: testCourseID}))
+	assert.Equal(t, "{}", body)
+	assert.Contains(t, posts[len(posts)-1].Message, "Welcome back")
+	assert.Equal(t, map[string]interface{}{"Previous lesson": 0, "Next lesson": 2}, navigation())
+
+	for _, index := range []int{-1, 1, 3} {
+		body = doRequest(p, AttachmentPath+AttachmentPathCourseFinish, "editor", lessonRequest(index))
+		assert.Contains(t, body, "Cannot find this lesson", index)
+	}
+	cp, err = p.store.GetCourseProgress("editor", testCourseID)
+	require.NoError(t, err)
+	assert.False(t, cp.CompletedLessons[2])
+
+	body = doRequest(p, AttachmentPath+AttachmentPathCourseFinish, "editor", lessonRequest(2))
+	assert.NotContains(t, body, "Error")
+	cp, err = p.store.GetCourseProgress("editor", testCourseID)
+	require.NoError(t, err)
+	assert.True(t, cp.Completed)
+}
@@ -2,8 +2,11 @@ package main
 
 import (
 	"fmt"
+	"math"
 	"sort"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/mattermost/mattermost-server/v5/model"
 )
@@ -34,6 +37,28 @@ func (p *Plugin) CreateAttachmentFromQuiz(q *Quiz) []*model.SlackAttachment {
 	attachment.Text = "Quiz: " + q.Name
 	renameAction.Name = "Rename quiz"
 
+	detailsAction := model.PostAction{
+		Type: "button",
+		Name: "Set details",
+		Integration: &model.PostActionIntegration{
+			URL: p.getAttachmentURL() + AttachmentPathQuizDetails,
+			Context: map[string]interface{}{
+				AttachmentContextFieldID: q.ID,
+			},
+		},
+	}
+	attachment.Actions = append(attachment.Actions, &detailsAction)
+
+	if q.Category != "" {
+		attachment.Text += "\nCategory: " + q.Category
+	}
+	if len(q.Tags) > 0 {
+		attachment.Text += "\nTags: " + strings.Join(q.Tags, ", ")
+	}
+	if q.Difficulty != "" {
+		attachment.Text += "\nDifficulty: " + string(q.Difficulty)
+	}
+
 	changeTypeAction := model.PostAction{
 		Type: "button",
 		Name: "Select type",
@@ -47,11 +72,14 @@ func (p *Plugin) CreateAttachmentFromQuiz(q *Quiz) []*model.SlackAttachment {
 	attachment.Actions = append(attachment.Actions, &changeTypeAction)
 
 	if q.Type == "" {
-		attachment.Text += "\nSelect the quiz type"
+		attachment.Text += "\nSelect the default question type"
 		return p.finishCreateAttachmentForQuiz(&attachment, q)
 	}
 
-	attachment.Text += "\nType: " + string(q.Type)
+	attachment.Text += "\nDefault question type: " + string(q.Type)
+	if q.MultiSelectScoring != "" {
+		attachment.Text += "\nMulti-select scoring: " + string(q.MultiSelectScoring)
+	}
 	changeTypeAction.Name = "Change type"
 
 	addQuestionAction := model.PostAction{
@@ -66,6 +94,19 @@ func (p *Plugin) CreateAttachmentFromQuiz(q *Quiz) []*model.SlackAttachment {
 	}
 	attachment.Actions = append(attachment.Actions, &addQuestionAction)
 
+	addQuestionOfTypeAction := model.PostAction{
+		Type:    "select",
+		Name:    "Add question of type",
+		Options: getQuizTypeOptions(),
+		Integration: &model.PostActionIntegration{
+			URL: p.getAttachmentURL() + AttachmentPathAddQuestion,
+			Context: map[string]interface{}{
+				AttachmentContextFieldID: q.ID,
+			},
+		},
+	}
+	attachment.Actions = append(attachment.Actions, &addQuestionOfTypeAction)
+
 	validQuestions := q.ValidQuestions()
 	allQuestions := len(q.Questions)
 
@@ -82,6 +123,18 @@ func (p *Plugin) CreateAttachmentFromQuiz(q *Quiz) []*model.SlackAttachment {
 		}
 		attachment.Actions = append(attachment.Actions, &reviewQuestionsAction)
 
+		editQuestionAction := model.PostAction{
+			Type: "button",
+			Name: "Edit question",
+			Integration: &model.PostActionIntegration{
+				URL: p.getAttachmentURL() + AttachmentPathEditQuestion,
+				Context: map[string]interface{}{
+					AttachmentContextFieldID: q.ID,
+				},
+			},
+		}
+		attachment.Actions = append(attachment.Actions, &editQuestionAction)
+
 		removeQuestionsAction := model.PostAction{
 			Type:  "button",
 			Name:  "Remove questions",
@@ -120,6 +173,45 @@ func (p *Plugin) CreateAttachmentFromQuiz(q *Quiz) []*model.SlackAttachment {
 }
 
 func (p *Plugin) finishCreateAttachmentForQuiz(attachment *model.SlackAttachment, q *Quiz) []*model.SlackAttachment {
+	attachment.Text += "\nScope: " + p.getScopeText(q.TeamID, q.ChannelIDs)
+	attachment.Text += "\nVisibility: " + p.getVisibilityText(q.Visibility, q.SharedWith, q.SharedGroups)
+
+	scopeAction := model.PostAction{
+		Type: "button",
+		Name: "Scope",
+		Integration: &model.PostActionIntegration{
+			URL: p.getAttachmentURL() + AttachmentPathQuizScope,
+			Context: map[string]interface{}{
+				AttachmentContextFieldID: q.ID,
+			},
+		},
+	}
+	attachment.Actions = append(attachment.Actions, &scopeAction)
+
+	visibilityAction := model.PostAction{
+		Type: "button",
+		Name: "Visibility",
+		Integration: &model.PostActionIntegration{
+			URL: p.getAttachmentURL() + AttachmentPathQuizVisibility,
+			Context: map[string]interface{}{
+				AttachmentContextFieldID: q.ID,
+			},
+		},
+	}
+	attachment.Actions = append(attachment.Actions, &visibilityAction)
+
+	editorsAction := model.PostAction{
+		Type: "button",
+		Name: "Editors",
+		Integration: &model.PostActionIntegration{
+			URL: p.getAttachmentURL() + AttachmentPathQuizEditors,
+			Context: map[string]interface{}{
+				AttachmentContextFieldID: q.ID,
+			},
+		},
+	}
+	attachment.Actions = append(attachment.Actions, &editorsAction)
+
 	cancelAction := model.PostAction{
 		Type:  "button",
 		Name:  "Cancel",
@@ -136,20 +228,139 @@ func (p *Plugin) finishCreateAttachmentForQuiz(attachment *model.SlackAttachment
 	return []*model.SlackAttachment{attachment}
 }
 
+func (p *Plugin) CreateQuestionAttachmentFromQuiz(q *Quiz, questionID string) []*model.SlackAttachment {
+	attachment := &model.SlackAttachment{
+		Title:   "Question edition",
+		Text:    "Quiz: " + q.Name,
+		Actions: []*model.PostAction{},
+	}
+
+	for _, question := range q.Questions {
+		if question.ID != questionID {
+			continue
+		}
+
+		attachment.Text += "\nQuestion: " + question.Question
+		attachment.Text += "\nType: " + string(question.Type)
+		attachment.Text += "\nCorrect answer: " + question.CorrectAnswer
+		if len(question.ExtraCorrectAnswers) > 0 {
+			attachment.Text += "\nOther correct answers: " + strings.Join(question.ExtraCorrectAnswers, ", ")
+		}
+		if question.Type.HasOptions() {
+			attachment.Text += "\nIncorrect answers: " + strings.Join(question.IncorrectAnswers, ", ")
+		}
+		if len(question.AlternativeAnswers) > 0 {
+			attachment.Text += "\nOther accepted answers: " + strings.Join(question.AlternativeAnswers, ", ")
+		}
+		if question.Type == QuizTypeNumeric {
+			attachment.Text += "\nTolerance: " + strconv.FormatFloat(question.Tolerance, 'f', -1, 64)
+			if question.Unit != "" {
+				attachment.Text += "\nUnit: " + question.Unit
+			}
+		}
+
+		attachment.ImageURL = p.getQuestionImageURL(q.ID, &question)
+
+		attachment.Actions = append(attachment.Actions, &model.PostAction{
+			Type: "button",
+			Name: "Edit question",
+			Integration: &model.PostActionIntegration{
+				URL: p.getAttachmentURL() + AttachmentPathUpdateQuestion,
+				Context: map[string]interface{}{
+					AttachmentContextFieldID:         q.ID,
+					AttachmentContextFieldQuestionID: question.ID,
+				},
+			},
+		})
+
+		imageAction := &model.PostAction{
+			Type: "button",
+			Name: "Set image",
+			Integration: &model.PostActionIntegration{
+				URL: p.getAttachmentURL() + AttachmentPathSetQuestionImage,
+				Context: map[string]interface{}{
+					AttachmentContextFieldID:         q.ID,
+					AttachmentContextFieldQuestionID: question.ID,
+				},
+			},
+		}
+		attachment.Actions = append(attachment.Actions, imageAction)
+
+		if question.ImageFileID == "" {
+			attachment.Text += "\n\nTo add an image, upload it to this conversation and click on Set image."
+			continue
+		}
+
+		imageAction.Name = "Replace image"
+		attachment.Actions = append(attachment.Actions, &model.PostAction{
+			Type:  "button",
+			Name:  "Remove image",
+			Style: "danger",
+			Integration: &model.PostActionIntegration{
+				URL: p.getAttachmentURL() + AttachmentPathRemoveQuestionImage,
+				Context: map[string]interface{}{
+					AttachmentContextFieldID:         q.ID,
+					AttachmentContextFieldQuestionID: question.ID,
+				},
+			},
+		})
+	}
+
+	if len(attachment.Actions) == 0 {
+		attachment.Text += "\nQuestion not found."
+	}
+
+	attachment.Actions = append(attachment.Actions, &model.PostAction{
+		Type: "button",
+		Name: "Back",
+		Integration: &model.PostActionIntegration{
+			URL: p.getAttachmentURL() + AttachmentPathQuestionBack,
+			Context: map[string]interface{}{
+				AttachmentContextFieldID: q.ID,
+			},
+		},
+	})
+
+	return []*model.SlackAttachment{attachment}
+}
+
 func (p *Plugin) GameAttachment(g *Game) []*model.SlackAttachment {
 	currentQuestion := g.RemainingQuestions[0]
 	attachment := &model.SlackAttachment{
-		Title:   "Quiz: " + g.Quiz.Name,
-		Text:    currentQuestion.Question,
-		Footer:  fmt.Sprintf("Question %d out of %d.", g.NQuestions-len(g.RemainingQuestions)+1, g.NQuestions),
-		Actions: []*model.PostAction{},
+		Title:    "Quiz: " + g.Quiz.Name,
+		Text:     currentQuestion.Question,
+		Footer:   fmt.Sprintf("Question %d out of %d.", g.NQuestions-len(g.RemainingQuestions)+1, g.NQuestions),
+		ImageURL: p.getGameQuestionImageURL(g, &currentQuestion),
+		Actions:  []*model.PostAction{},
 	}
 
 	if g.Type == GameTypeParty {
 		attachment.Text += fmt.Sprintf("\n\n%d people already answered.", len(g.AlreadyAnswered))
 	}
 
-	if g.Quiz.Type == QuizTypeMultipleChoice {
+	if g.QuestionDeadline != 0 {
+		attachment.Text += fmt.Sprintf("\n\nTime left: %d seconds.", int(g.TimeLeft().Round(time.Second).Seconds()))
+	}
+
+	if currentQuestion.Type == QuizTypeMultiSelect {
+		for i, answer := range g.CurrentAnswers {
+			attachment.Text += fmt.Sprintf("\n\nOption %d: %s", i+1, answer)
+		}
+		attachment.Text += "\n\nSelect every correct option."
+	}
+
+	if currentQuestion.Type == QuizTypeOrdering {
+		for i, item := range g.CurrentAnswers {
+			attachment.Text += fmt.Sprintf("\n\nItem %d: %s", i+1, item)
+		}
+		attachment.Text += "\n\nPut the items in order."
+	}
+
+	if currentQuestion.Type == QuizTypeNumeric && currentQuestion.Unit != "" {
+		attachment.Text += "\n\nAnswer in " + currentQuestion.Unit + "."
+	}
+
+	if currentQuestion.Type == QuizTypeMultipleChoice {
 		for i, answer := range g.CurrentAnswers {
 			attachment.Text += fmt.Sprintf("\n\nAnswer %d: %s", i+1, answer)
 			attachment.Actions = append(attachment.Actions, &model.PostAction{
@@ -158,7 +369,7 @@ func (p *Plugin) GameAttachment(g *Game) []*model.SlackAttachment {
 				Integration: &model.PostActionIntegration{
 					URL: p.getAttachmentURL() + AttachmentPathSelectAnswer,
 					Context: map[string]interface{}{
-						AttachmentContextFieldCorrect:    i == g.CorrectAnswer,
+						AttachmentContextFieldAnswer:     i,
 						AttachmentContextFieldGameID:     g.RootPostID,
 						AttachmentContextFieldQuestionID: currentQuestion.ID,
 					},
@@ -210,11 +421,12 @@ func (p *Plugin) GameAttachment(g *Game) []*model.SlackAttachment {
 func (p *Plugin) GameSolutionAttachment(g *Game) []*model.SlackAttachment {
 	currentQuestion := g.RemainingQuestions[0]
 	attachment := &model.SlackAttachment{
-		Title:  "Quiz: " + g.Quiz.Name,
-		Text:   currentQuestion.Question,
-		Footer: fmt.Sprintf("Question %d out of %d.", g.NQuestions-len(g.RemainingQuestions)+1, g.NQuestions),
+		Title:    "Quiz: " + g.Quiz.Name,
+		Text:     currentQuestion.Question,
+		Footer:   fmt.Sprintf("Question %d out of %d.", g.NQuestions-len(g.RemainingQuestions)+1, g.NQuestions),
+		ImageURL: p.getGameQuestionImageURL(g, &currentQuestion),
 	}
-	attachment.Text += fmt.Sprintf("\n\nThe correct answer was: %s", g.RemainingQuestions[0].CorrectAnswer)
+	attachment.Text += "\n\n" + getSolutionText(&currentQuestion) + getExplanationText(&currentQuestion)
 	if g.Type == GameTypeParty {
 		toAdd := "\n\nThe following users were right: "
 		firstRun := true
@@ -234,17 +446,96 @@ func (p *Plugin) GameSolutionAttachment(g *Game) []*model.SlackAttachment {
 	return []*model.SlackAttachment{attachment}
 }
 
+// getSolutionText returns the sentence revealing the correct answer of the question
+// to the players.
+func getSolutionText(q *Question) string {
+	switch {
+	case q.Type == QuizTypeOrdering:
+		return fmt.Sprintf("The correct order was: %s", strings.Join(q.CorrectOptions(), ", "))
+	case q.Type == QuizTypeNumeric:
+		return fmt.Sprintf("The correct answer was: %s", getNumericAnswerText(q))
+	case len(q.ExtraCorrectAnswers) > 0:
+		return fmt.Sprintf("The correct answers were: %s", strings.Join(q.CorrectOptions(), ", "))
+	default:
+		return fmt.Sprintf("The correct answer was: %s", q.CorrectAnswer)
+	}
+}
+
+// getExplanationText returns the explanation and the reference link of the question,
+// ready to be appended to the solution, or an empty string if it has none.
+func getExplanationText(q *Question) string {
+	out := ""
+	if q.Explanation != "" {
+		out += "\n\n" + q.Explanation
+	}
+	if q.ReferenceLink != "" {
+		out += "\n\n[Learn more](" + q.ReferenceLink + ")"
+	}
+
+	return out
+}
+
+// getCorrectAnswerText returns the correct answers of the question, for the editors
+// of the quiz.
+func getCorrectAnswerText(q *Question) string {
+	if q.Type == QuizTypeNumeric {
+		return getNumericAnswerText(q)
+	}
+
+	return strings.Join(q.CorrectOptions(), "; ")
+}
+
+// getNumericAnswerText returns the correct answer of a numeric question with its unit
+// and tolerance, like "10 m (± 0.5)".
+func getNumericAnswerText(q *Question) string {
+	out := q.CorrectAnswer
+	if q.Unit != "" {
+		out += " " + q.Unit
+	}
+	if q.Tolerance > 0 {
+		out += " (± " + strconv.FormatFloat(q.Tolerance, 'f', -1, 64) + ")"
+	}
+
+	return out
+}
+
 func (p *Plugin) GameEndAttachment(g *Game) []*model.SlackAttachment {
 	attachment := &model.SlackAttachment{
 		Title: "Quiz: " + g.Quiz.Name,
 		Text:  "The quiz has finished\n\n" + getScores(g),
+		Actions: []*model.PostAction{
+			{
+				Type: "button",
+				Name: "Export results",
+				Integration: &model.PostActionIntegration{
+					URL: p.getAttachmentURL() + AttachmentPathExportResults,
+					Context: map[string]interface{}{
+						AttachmentContextFieldGameID: g.RootPostID,
+					},
+				},
+			},
+		},
 	}
-	return []*model.SlackAttachment{attachment}
+
+	review := &model.SlackAttachment{
+		Title: "Review",
+	}
+	for i, question := range g.PassedQuestions {
+		if i > 0 {
+			review.Text += "\n\n"
+		}
+		review.Text += fmt.Sprintf("**%d. %s**\n\n%s%s", i+1, question.Question, getSolutionText(&question), getExplanationText(&question))
+	}
+	if review.Text == "" {
+		return []*model.SlackAttachment{attachment}
+	}
+
+	return []*model.SlackAttachment{attachment, review}
 }
 
 type scoreRow struct {
 	name  string
-	score int
+	score float64
 }
 
 func getScoreRows(g *Game) []scoreRow {
@@ -263,21 +554,27 @@ func getScores(g *Game) string {
 		rows := getScoreRows(g)
 		out += "Scores:"
 		for _, scoreRow := range rows {
-			out += fmt.Sprintf("\n\n@%s: %d", scoreRow.name, scoreRow.score)
+			out += fmt.Sprintf("\n\n@%s: %s", scoreRow.name, formatScore(scoreRow.score))
 		}
 		return out
 	}
 
 	out = "Your score: "
-	score := 0
+	score := 0.0
 	for _, v := range g.Score {
 		score = v
 		break
 	}
-	out += strconv.Itoa(score)
+	out += formatScore(score)
 	return out
 }
 
+// formatScore formats a score, which can have a fraction of a point from partially
+// correct answers, with at most two decimals.
+func formatScore(score float64) string {
+	return strconv.FormatFloat(math.Round(score*100)/100, 'f', -1, 64)
+}
+
 func (p *Plugin) CreateAttachmentFromCourse(c *Course) []*model.SlackAttachment {
 	attachment := model.SlackAttachment{
 		Title:   "Course creation",
@@ -462,6 +759,45 @@ func (p *Plugin) CreateLessonAttachmentFromCourse(c *Course, index int) []*model
 }
 
 func (p *Plugin) finishCreateAttachmentForCourse(attachment *model.SlackAttachment, c *Course) []*model.SlackAttachment {
+	attachment.Text += "\nScope: " + p.getScopeText(c.TeamID, c.ChannelIDs)
+	attachment.Text += "\nVisibility: " + p.getVisibilityText(c.Visibility, c.SharedWith, c.SharedGroups)
+
+	scopeAction := model.PostAction{
+		Type: "button",
+		Name: "Scope",
+		Integration: &model.PostActionIntegration{
+			URL: p.getAttachmentURL() + AttachmentPathCourseScope,
+			Context: map[string]interface{}{
+				AttachmentContextFieldID: c.ID,
+			},
+		},
+	}
+	attachment.Actions = append(attachment.Actions, &scopeAction)
+
+	visibilityAction := model.PostAction{
+		Type: "button",
+		Name: "Visibility",
+		Integration: &model.PostActionIntegration{
+			URL: p.getAttachmentURL() + AttachmentPathCourseVisibility,
+			Context: map[string]interface{}{
+				AttachmentContextFieldID: c.ID,
+			},
+		},
+	}
+	attachment.Actions = append(attachment.Actions, &visibilityAction)
+
+	editorsAction := model.PostAction{
+		Type: "button",
+		Name: "Editors",
+		Integration: &model.PostActionIntegration{
+			URL: p.getAttachmentURL() + AttachmentPathCourseEditors,
+			Context: map[string]interface{}{
+				AttachmentContextFieldID: c.ID,
+			},
+		},
+	}
+	attachment.Actions = append(attachment.Actions, &editorsAction)
+
 	cancelAction := model.PostAction{
 		Type:  "button",
 		Name:  "Cancel",
@@ -508,3 +844,102 @@ func (p *Plugin) finishCreateLessonAttachmentForCourse(attachment *model.SlackAt
 
 	return []*model.SlackAttachment{attachment}
 }
+
+func (p *Plugin) CourseLessonAttachments(c *Course, index int) []*model.SlackAttachment {
+	lesson := c.Lessons[index]
+	attachments := []*model.SlackAttachment{
+		{
+			Title: fmt.Sprintf("Lesson %d out of %d: %s", index+1, len(c.Lessons), lesson.Name),
+			Text:  lesson.Introduction,
+		},
+	}
+
+	for i, resource := range lesson.Resources {
+		attachments = append(attachments, p.resourceAttachment(c, index, i, resource))
+	}
+
+	navigation := &model.SlackAttachment{
+		Footer:  "Course: " + c.Name,
+		Actions: []*model.PostAction{},
+	}
+
+	if index > 0 {
+		navigation.Actions = append(navigation.Actions, &model.PostAction{
+			Type: "button",
+			Name: "Previous lesson",
+			Integration: &model.PostActionIntegration{
+				URL: p.getAttachmentURL() + AttachmentPathCourseLesson,
+				Context: map[string]interface{}{
+					AttachmentContextFieldID:          c.ID,
+					AttachmentContextFieldLessonIndex: index - 1,
+				},
+			},
+		})
+	}
+
+	if index < len(c.Lessons)-1 {
+		navigation.Actions = append(navigation.Actions, &model.PostAction{
+			Type:  "button",
+			Name:  "Next lesson",
+			Style: "primary",
+			Integration: &model.PostActionIntegration{
+				URL: p.getAttachmentURL() + AttachmentPathCourseLesson,
+				Context: map[string]interface{}{
+					AttachmentContextFieldID:          c.ID,
+					AttachmentContextFieldLessonIndex: index + 1,
+				},
+			},
+		})
+	} else {
+		navigation.Actions = append(navigation.Actions, &model.PostAction{
+			Type:  "button",
+			Name:  "Finish course",
+			Style: "good",
+			Integration: &model.PostActionIntegration{
+				URL: p.getAttachmentURL() + AttachmentPathCourseFinish,
+				Context: map[string]interface{}{
+					AttachmentContextFieldID:          c.ID,
+					AttachmentContextFieldLessonIndex: index,
+				},
+			},
+		})
+	}
+
+	return append(attachments, navigation)
+}
+
+func (p *Plugin) resourceAttachment(c *Course, lessonIndex, resourceIndex int, resource *Resource) *model.SlackAttachment {
+	attachment := &model.SlackAttachment{
+		Pretext: resource.Pretext,
+		Title:   resource.Name,
+	}
+
+	switch ResourceType(resource.Type) {
+	case ResourceTypeText:
+		attachment.Text = resource.Content
+	case ResourceTypeLink:
+		attachment.TitleLink = resource.Content
+		attachment.Text = resource.Content
+	case ResourceTypeVideo:
+		attachment.TitleLink = resource.Content
+		attachment.Text = "Watch the video: " + resource.Content
+	case ResourceTypeQuiz:
+		attachment.Text = "Check what you have learned with a quiz."
+		attachment.Actions = []*model.PostAction{
+			{
+				Type: "button",
+				Name: "Start quiz",
+				Integration: &model.PostActionIntegration{
+					URL: p.getAttachmentURL() + AttachmentPathCourseQuiz,
+					Context: map[string]interface{}{
+						AttachmentContextFieldID:          c.ID,
+						AttachmentContextFieldLessonIndex: lessonIndex,
+						AttachmentContextFieldResource:    resourceIndex,
+					},
+				},
+			},
+		}
+	}
+
+	return attachment
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	pluginapi "github.com/mattermost/mattermost-plugin-api"
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestPlugin() (*Plugin, *memoryAPI) {
+	api := newMemoryAPI()
+	api.On("GetConfig").Return(&model.Config{})
+
+	p := &Plugin{}
+	p.mm = pluginapi.NewClient(api)
+	p.store = NewStore(p.mm)
+	return p, api
+}
+
+func TestGameAttachmentHidesCorrectAnswer(t *testing.T) {
+	p, _ := newTestPlugin()
+
+	g := &Game{
+		Quiz:       Quiz{Name: "Capitals", Type: QuizTypeMultipleChoice},
+		RootPostID: "game",
+		Type:       GameTypeParty,
+		RemainingQuestions: []Question{{
+			ID:               "q1",
+			Type:             QuizTypeMultipleChoice,
+			Question:         "Capital of France?",
+			CorrectAnswer:    "Paris",
+			IncorrectAnswers: []string{"Lyon", "Nice", "Lille"},
+		}},
+		NQuestions: 1,
+	}
+	g.shuffleAnswers()
+
+	post := &model.Post{}
+	model.ParseSlackAttachment(post, p.GameAttachment(g))
+
+	attachments := post.Attachments()
+	require.Len(t, attachments, 1)
+
+	answerButtons := 0
+	for _, action := range attachments[0].Actions {
+		context := action.Integration.Context
+		if _, ok := context[AttachmentContextFieldAnswer]; !ok {
+			continue
+		}
+
+		answerButtons++
+		assert.ElementsMatch(t,
+			[]string{AttachmentContextFieldAnswer, AttachmentContextFieldGameID, AttachmentContextFieldQuestionID},
+			keys(context),
+		)
+		for _, v := range context {
+			_, isBool := v.(bool)
+			assert.False(t, isBool, "no boolean flag should be sent to the client")
+		}
+	}
+	assert.Equal(t, len(g.CurrentAnswers), answerButtons)
+
+	b, err := json.Marshal(post.GetProps())
+	require.NoError(t, err)
+	assert.NotContains(t, string(b), `"correct"`)
+}
+
+func TestSolutionAttachmentsShowExplanation(t *testing.T) {
+	p, _ := newTestPlugin()
+
+	question := Question{
+		ID:            "q1",
+		Type:          QuizTypeSingleAnswer,
+		Question:      "Capital of France?",
+		CorrectAnswer: "Paris",
+		Explanation:   "Paris has been the capital since 987.",
+		ReferenceLink: "https://en.wikipedia.org/wiki/Paris",
+	}
+	g := &Game{
+		Quiz:               Quiz{Name: "Capitals", Type: QuizTypeSingleAnswer},
+		RootPostID:         "game",
+		Type:               GameTypeSolo,
+		RemainingQuestions: []Question{question},
+		NQuestions:         1,
+	}
+
+	solution := p.GameSolutionAttachment(g)
+	require.Len(t, solution, 1)
+	assert.Contains(t, solution[0].Text, "The correct answer was: Paris")
+	assert.Contains(t, solution[0].Text, question.Explanation)
+	assert.Contains(t, solution[0].Text, "[Learn more]("+question.ReferenceLink+")")
+
+	g.RemainingQuestions = nil
+	g.PassedQuestions = []Question{question}
+	end := p.GameEndAttachment(g)
+	require.Len(t, end, 2)
+	assert.Contains(t, end[1].Text, "Capital of France?")
+	assert.Contains(t, end[1].Text, question.Explanation)
+}
+
+func TestGetScoresShowsPartialPoints(t *testing.T) {
+	g := &Game{
+		Type:  GameTypeParty,
+		Score: map[string]float64{"alice": 2, "bob": 4.0 / 3},
+	}
+	assert.Equal(t, "Scores:\n\n@alice: 2\n\n@bob: 1.33", getScores(g))
+
+	g = &Game{
+		Type:  GameTypeSolo,
+		Score: map[string]float64{"alice": 0.5},
+	}
+	assert.Equal(t, "Your score: 0.5", getScores(g))
+}
+
+func keys(m map[string]interface{}) []string {
+	out := []string{}
+	for k := range m {
+		out = append(out, k)
+	}
+	return out
+}
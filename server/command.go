@@ -2,6 +2,8 @@ package main
 
 import (
 	"fmt"
+	"io/ioutil"
+	"strings"
 
 	commandparser "github.com/larkox/mattermost-plugin-quiz/server/command_parser"
 	"github.com/mattermost/mattermost-server/v5/model"
@@ -56,6 +58,22 @@ func (p *Plugin) ExecuteCommand(c *plugin.Context, args *model.CommandArgs) (*mo
 		handler = p.runCreate
 	case "start":
 		handler = p.runStart
+	case "list":
+		handler = p.runList
+	case "edit":
+		handler = p.runEdit
+	case "course":
+		handler = p.runCourse
+	case "import":
+		handler = p.runImport
+	case "export":
+		handler = p.runExport
+	case "history":
+		handler = p.runHistory
+	case "stats":
+		handler = p.runStats
+	case "leaderboard":
+		handler = p.runLeaderboard
 	default:
 		p.postCommandResponse(args, getHelp())
 		return &model.CommandResponse{}, nil
@@ -117,7 +135,7 @@ func (p *Plugin) runCreateCourse(args []string, extra *model.CommandArgs) (bool,
 	post := &model.Post{
 		Message: "Creating a course",
 	}
-	c := &Course{}
+	c := &Course{CreatorID: extra.UserId, TeamID: extra.TeamId}
 	model.ParseSlackAttachment(post, p.CreateAttachmentFromCourse(c))
 
 	err := p.mm.Post.DM(p.BotUserID, extra.UserId, post)
@@ -138,47 +156,335 @@ func (p *Plugin) runCreateCourse(args []string, extra *model.CommandArgs) (bool,
 }
 
 func (p *Plugin) runCreateQuiz(args []string, extra *model.CommandArgs) (bool, *model.CommandResponse, error) {
+	q := &Quiz{CreatorID: extra.UserId, TeamID: extra.TeamId}
+	err := p.createQuizDraft(q, "Creating quiz")
+	if err != nil {
+		p.postCommandResponse(extra, "Error: "+err.Error())
+		return emptyCommandResponse()
+	}
+
+	p.postCommandResponse(extra, "The bot will contact you soon and guide you through the creation process.")
+	return emptyCommandResponse()
+}
+
+func (p *Plugin) runImport(args []string, extra *model.CommandArgs) (bool, *model.CommandResponse, error) {
+	fileID := ""
+	if len(args) > 0 {
+		fileID = args[0]
+	} else {
+		var err error
+		fileID, err = p.getLastUploadedFileID(extra.UserId, extra.ChannelId)
+		if err != nil {
+			p.postCommandResponse(extra, "Error: "+err.Error())
+			return emptyCommandResponse()
+		}
+	}
+
+	if fileID == "" {
+		p.postCommandResponse(extra, "Error: No file to import. Upload a .csv, .json or .gift file to this channel, or pass the file ID: `/quiz import fileID`.")
+		return emptyCommandResponse()
+	}
+
+	info, err := p.mm.File.GetInfo(fileID)
+	if err != nil {
+		p.postCommandResponse(extra, "Error: cannot find the file.")
+		return emptyCommandResponse()
+	}
+
+	if info.CreatorId != extra.UserId {
+		p.postCommandResponse(extra, "Error: you can only import files you uploaded.")
+		return emptyCommandResponse()
+	}
+
+	if info.Size > MaxImportFileSize {
+		p.postCommandResponse(extra, fmt.Sprintf("Error: the file is too big. The maximum size is %d KB.", MaxImportFileSize/1024))
+		return emptyCommandResponse()
+	}
+
+	reader, err := p.mm.File.Get(fileID)
+	if err != nil {
+		p.postCommandResponse(extra, "Error: "+err.Error())
+		return emptyCommandResponse()
+	}
+
+	data, err := ioutil.ReadAll(reader)
+	if err != nil {
+		p.postCommandResponse(extra, "Error: "+err.Error())
+		return emptyCommandResponse()
+	}
+
+	q, importErrors, err := ParseQuizFile(info.Name, data)
+	if err != nil {
+		p.postCommandResponse(extra, "Error: "+err.Error())
+		return emptyCommandResponse()
+	}
+
+	message := ""
+	if len(q.Questions) == 0 {
+		message = "Error: no valid questions found in the file."
+	} else {
+		q.CreatorID = extra.UserId
+		q.TeamID = extra.TeamId
+		err = p.createQuizDraft(q, "Importing quiz")
+		if err != nil {
+			p.postCommandResponse(extra, "Error: "+err.Error())
+			return emptyCommandResponse()
+		}
+
+		message = fmt.Sprintf("Imported %d questions into the quiz `%s`. The bot sent you the quiz so you can review and save it.", len(q.Questions), q.Name)
+	}
+
+	if len(importErrors) > 0 {
+		message += "\n\nThe following questions were skipped:"
+		for _, importError := range importErrors {
+			message += "\n- " + importError.String()
+		}
+	}
+
+	p.postCommandResponse(extra, message)
+	return emptyCommandResponse()
+}
+
+// getLastUploadedFileID returns the ID of the last file the user uploaded to the channel,
+// looking only at the most recent posts.
+func (p *Plugin) getLastUploadedFileID(userID, channelID string) (string, error) {
+	posts, err := p.mm.Post.GetPostsForChannel(channelID, 0, ImportPostsLookback)
+	if err != nil {
+		return "", err
+	}
+
+	for _, id := range posts.Order {
+		post := posts.Posts[id]
+		if post.UserId == userID && len(post.FileIds) > 0 {
+			return post.FileIds[0], nil
+		}
+	}
+
+	return "", nil
+}
+
+// createQuizDraft sends the quiz creation post to its creator and stores the quiz
+// using the post ID as quiz ID.
+func (p *Plugin) createQuizDraft(q *Quiz, message string) error {
 	post := &model.Post{
-		Message: "Creating quiz",
+		Message: message,
 	}
-	q := &Quiz{}
 	model.ParseSlackAttachment(post, p.CreateAttachmentFromQuiz(q))
 
-	err := p.mm.Post.DM(p.BotUserID, extra.UserId, post)
+	err := p.mm.Post.DM(p.BotUserID, q.CreatorID, post)
+	if err != nil {
+		return err
+	}
+
+	q.ID = post.Id
+	return p.storeQuizRevision(q, q.CreatorID)
+}
+
+func (p *Plugin) runExport(args []string, extra *model.CommandArgs) (bool, *model.CommandResponse, error) {
+	quizOptions := []*model.PostActionOptions{}
+	var selected *Quiz
+	name := strings.TrimSpace(strings.Join(args, " "))
+	for _, q := range p.store.GetAvailableQuizes() {
+		if !p.canEditQuiz(q, extra.UserId) {
+			continue
+		}
+
+		if name != "" && (q.ID == name || strings.EqualFold(q.Name, name)) {
+			selected = q
+			break
+		}
+		quizOptions = append(quizOptions, &model.PostActionOptions{Text: q.Name, Value: q.ID})
+	}
+
+	if name != "" {
+		if selected == nil {
+			p.postCommandResponse(extra, fmt.Sprintf("Error: There is no quiz named `%s` you can export.", name))
+			return emptyCommandResponse()
+		}
+
+		err := p.exportQuiz(selected, extra.UserId)
+		if err != nil {
+			p.postCommandResponse(extra, "Error: "+err.Error())
+			return emptyCommandResponse()
+		}
+
+		p.postCommandResponse(extra, "The bot sent you the exported quiz.")
+		return emptyCommandResponse()
+	}
+
+	if len(quizOptions) == 0 {
+		p.postCommandResponse(extra, "Error: There are no quizzes you can export.")
+		return emptyCommandResponse()
+	}
+
+	err := p.mm.Frontend.OpenInteractiveDialog(model.OpenDialogRequest{
+		TriggerId: extra.TriggerId,
+		URL:       p.getDialogURL() + DialogPathExportQuiz,
+		Dialog: model.Dialog{
+			Title:            "Export quiz",
+			IntroductionText: "Select the quiz to export. The bot will send you the quiz as JSON and CSV files.",
+			SubmitLabel:      "Export",
+			Elements: []model.DialogElement{
+				{
+					Type:        DialogTypeSelect,
+					Name:        DialogSubmissionFieldQuiz,
+					DisplayName: "Quiz",
+					Options:     quizOptions,
+				},
+			},
+		},
+	})
 	if err != nil {
 		p.postCommandResponse(extra, "Error: "+err.Error())
 		return emptyCommandResponse()
 	}
 
-	q.ID = post.Id
-	err = p.store.StoreQuiz(q)
+	return emptyCommandResponse()
+}
+
+// runHistory shows the revisions of a quiz the user can edit, so any of them can be
+// restored.
+func (p *Plugin) runHistory(args []string, extra *model.CommandArgs) (bool, *model.CommandResponse, error) {
+	name := strings.TrimSpace(strings.Join(args, " "))
+	if name == "" {
+		p.postCommandResponse(extra, "Usage: `/quiz history <quiz name or ID>`")
+		return emptyCommandResponse()
+	}
+
+	var selected *Quiz
+	for _, q := range p.store.GetAvailableQuizes() {
+		if p.canEditQuiz(q, extra.UserId) && (q.ID == name || strings.EqualFold(q.Name, name)) {
+			selected = q
+			break
+		}
+	}
+
+	if selected == nil {
+		p.postCommandResponse(extra, fmt.Sprintf("Error: There is no quiz named `%s` you can edit.", name))
+		return emptyCommandResponse()
+	}
+
+	revisions, err := p.getQuizRevisions(selected.ID)
 	if err != nil {
 		p.postCommandResponse(extra, "Error: "+err.Error())
 		return emptyCommandResponse()
 	}
 
-	p.postCommandResponse(extra, "The bot will contact you soon and guide you through the creation process.")
+	post := &model.Post{
+		UserId:    p.BotUserID,
+		ChannelId: extra.ChannelId,
+	}
+	model.ParseSlackAttachment(post, []*model.SlackAttachment{p.QuizHistoryAttachment(selected, revisions)})
+	p.mm.Post.SendEphemeralPost(extra.UserId, post)
 	return emptyCommandResponse()
 }
 
+func (p *Plugin) runStats(args []string, extra *model.CommandArgs) (bool, *model.CommandResponse, error) {
+	var user *model.User
+	var err error
+	if len(args) > 0 {
+		user, err = p.mm.User.GetByUsername(strings.TrimPrefix(args[0], "@"))
+	} else {
+		user, err = p.mm.User.Get(extra.UserId)
+	}
+	if err != nil {
+		p.postCommandResponse(extra, "Error: cannot find the user.")
+		return emptyCommandResponse()
+	}
+
+	results, err := p.store.GetUserGameResults(user.Id)
+	if err != nil {
+		p.postCommandResponse(extra, "Error: "+err.Error())
+		return emptyCommandResponse()
+	}
+
+	results = p.getVisibleGameResults(results, user.Id, extra.UserId)
+	p.postCommandResponse(extra, NewUserStats(user.Id, results).ToMarkdown(user.Username))
+	return emptyCommandResponse()
+}
+
+func (p *Plugin) runLeaderboard(args []string, extra *model.CommandArgs) (bool, *model.CommandResponse, error) {
+	pin := false
+	scope := LeaderboardScopeChannel
+	period := LeaderboardPeriodAll
+	for _, arg := range args {
+		switch arg {
+		case "pin":
+			pin = true
+		case string(LeaderboardScopeChannel), string(LeaderboardScopeTeam):
+			scope = LeaderboardScope(arg)
+		case string(LeaderboardPeriodWeek), string(LeaderboardPeriodMonth), string(LeaderboardPeriodAll):
+			period = LeaderboardPeriod(arg)
+		default:
+			p.postCommandResponse(extra, "Usage: `/quiz leaderboard [pin] [channel|team] [week|month|all]`")
+			return emptyCommandResponse()
+		}
+	}
+
+	scopeID := extra.ChannelId
+	if scope == LeaderboardScopeTeam {
+		scopeID = extra.TeamId
+	}
+
+	if pin {
+		if !p.canPinLeaderboard(extra.UserId, extra.ChannelId) {
+			p.postCommandResponse(extra, "Error: only the people that can manage this channel can pin a leaderboard.")
+			return emptyCommandResponse()
+		}
+
+		err := p.pinLeaderboard(extra.ChannelId, scope, scopeID, period)
+		if err != nil {
+			p.postCommandResponse(extra, "Error: "+err.Error())
+		}
+		return emptyCommandResponse()
+	}
+
+	l, err := p.getLeaderboard(scope, scopeID, period)
+	if err != nil {
+		p.postCommandResponse(extra, "Error: "+err.Error())
+		return emptyCommandResponse()
+	}
+
+	post := &model.Post{
+		UserId:    p.BotUserID,
+		ChannelId: extra.ChannelId,
+	}
+	model.ParseSlackAttachment(post, p.LeaderboardAttachment(l, scope, scopeID, period))
+	p.mm.Post.SendEphemeralPost(extra.UserId, post)
+	return emptyCommandResponse()
+}
+
+// runStart opens the dialog to start a game. The dialogs of the supported server
+// versions only have static selects, so the quizzes can be narrowed down with the same
+// search as `/quiz list`.
 func (p *Plugin) runStart(args []string, extra *model.CommandArgs) (bool, *model.CommandResponse, error) {
-	quizzes := p.store.GetAvailableQuizes()
-	if len(quizzes) == 0 {
+	search, err := parseQuizSearchArgs(args)
+	if err != nil {
+		p.postCommandResponse(extra, "Error: "+err.Error()+". Usage: `/quiz start [--tag tag] [words]`")
+		return emptyCommandResponse()
+	}
+
+	quizOptions, total := p.getStartQuizOptions(search, extra.UserId, extra.TeamId)
+	if total == 0 && search.IsEmpty() {
 		p.postCommandResponse(extra, "Error: No quizzes available to start. Create a new quiz first.")
 		return emptyCommandResponse()
 	}
+	if total == 0 {
+		p.postCommandResponse(extra, "Error: No quizzes match the search. Run `/quiz list` to see the available quizzes.")
+		return emptyCommandResponse()
+	}
 
-	quizOptions := []*model.PostActionOptions{}
-	for _, q := range quizzes {
-		quizOptions = append(quizOptions, &model.PostActionOptions{Text: q.Name, Value: q.ID})
+	introduction := "Select the quiz and the configuration."
+	if total > len(quizOptions) {
+		introduction += fmt.Sprintf(" Only the first %d out of %d quizzes are shown. Search for a quiz with `/quiz start [--tag tag] [words]`.", len(quizOptions), total)
 	}
 
-	err := p.mm.Frontend.OpenInteractiveDialog(model.OpenDialogRequest{
+	err = p.mm.Frontend.OpenInteractiveDialog(model.OpenDialogRequest{
 		TriggerId: extra.TriggerId,
 		URL:       p.getDialogURL() + DialogPathGameStart,
 		Dialog: model.Dialog{
 			Title:            "Start quiz",
-			IntroductionText: "Select the quiz and the configuration.",
+			IntroductionText: introduction,
 			SubmitLabel:      "Start quiz",
 			Elements: []model.DialogElement{
 				{
@@ -207,7 +513,7 @@ func (p *Plugin) runStart(args []string, extra *model.CommandArgs) (bool, *model
 					Type:        DialogTypeSelect,
 					Name:        DialogSubmissionFieldGameScoring,
 					DisplayName: "Scoring",
-					HelpText:    "All will give 1 point to all people that answer correctly. First will give 2 extra points to the one that answered first.",
+					HelpText:    "All will give 1 point to all people that answer correctly. First will give 2 extra points to the first one that answers correctly. Speed will give up to 10 points depending on how fast people answer correctly.",
 					Options: []*model.PostActionOptions{
 						{
 							Text:  "All",
@@ -217,6 +523,10 @@ func (p *Plugin) runStart(args []string, extra *model.CommandArgs) (bool, *model
 							Text:  "First",
 							Value: string(ScoringTypeFirst),
 						},
+						{
+							Text:  "Speed",
+							Value: string(ScoringTypeSpeed),
+						},
 					},
 				},
 				{
@@ -227,6 +537,14 @@ func (p *Plugin) runStart(args []string, extra *model.CommandArgs) (bool, *model
 					HelpText:    "0 will go through all the questions in the quiz. If this number is larger than the number of questions, it will stop when all questions are answered.",
 					Default:     "0",
 				},
+				{
+					Type:        DialogTypeText,
+					SubType:     DialogSubtypeNumber,
+					Name:        DialogSubmissionFieldTimeLimit,
+					DisplayName: "Time limit per question (seconds)",
+					HelpText:    "Only for party games. When the time is over, the solution is shown and the quiz moves to the next question. 0 will wait for the GM to move to the next question.",
+					Default:     "0",
+				},
 			},
 		},
 	})
@@ -238,6 +556,120 @@ func (p *Plugin) runStart(args []string, extra *model.CommandArgs) (bool, *model
 	return emptyCommandResponse()
 }
 
+func (p *Plugin) runCourse(args []string, extra *model.CommandArgs) (bool, *model.CommandResponse, error) {
+	lengthOfArgs := len(args)
+	restOfArgs := []string{}
+	var handler func([]string, *model.CommandArgs) (bool, *model.CommandResponse, error)
+	if lengthOfArgs == 0 {
+		return false, &model.CommandResponse{Text: "Specify what you want to do with courses."}, nil
+	}
+	command := args[0]
+	if lengthOfArgs > 1 {
+		restOfArgs = args[1:]
+	}
+	switch command {
+	case "start":
+		handler = p.runCourseStart
+	default:
+		return false, &model.CommandResponse{Text: "You can only start a course"}, nil
+	}
+
+	return handler(restOfArgs, extra)
+}
+
+func (p *Plugin) runCourseStart(args []string, extra *model.CommandArgs) (bool, *model.CommandResponse, error) {
+	courses := p.getAvailableCourses(extra.UserId, extra.TeamId)
+	if len(courses) == 0 {
+		p.postCommandResponse(extra, "Error: No courses available to start. Create a new course first.")
+		return emptyCommandResponse()
+	}
+
+	courseOptions := []*model.PostActionOptions{}
+	for _, c := range courses {
+		courseOptions = append(courseOptions, &model.PostActionOptions{Text: c.Name, Value: c.ID})
+	}
+
+	err := p.mm.Frontend.OpenInteractiveDialog(model.OpenDialogRequest{
+		TriggerId: extra.TriggerId,
+		URL:       p.getDialogURL() + DialogPathCourseStart,
+		Dialog: model.Dialog{
+			Title:            "Start course",
+			IntroductionText: "Select the course to take. The bot will guide you through its lessons.",
+			SubmitLabel:      "Start course",
+			Elements: []model.DialogElement{
+				{
+					Type:        DialogTypeSelect,
+					Name:        DialogSubmissionFieldCourse,
+					DisplayName: "Course",
+					Options:     courseOptions,
+				},
+			},
+		},
+	})
+	if err != nil {
+		p.postCommandResponse(extra, "Error: "+err.Error())
+		return emptyCommandResponse()
+	}
+
+	return emptyCommandResponse()
+}
+
+func (p *Plugin) runList(args []string, extra *model.CommandArgs) (bool, *model.CommandResponse, error) {
+	search, err := parseQuizSearchArgs(args)
+	if err != nil {
+		p.postCommandResponse(extra, "Error: "+err.Error()+". Usage: `/quiz list [--tag tag] [words]`")
+		return emptyCommandResponse()
+	}
+
+	quizzes := search.Filter(p.getAvailableQuizzes(extra.UserId, extra.TeamId))
+	if len(quizzes) == 0 {
+		p.postCommandResponse(extra, "No quizzes found.")
+		return emptyCommandResponse()
+	}
+
+	p.postCommandResponse(extra, getQuizListMarkdown(quizzes))
+	return emptyCommandResponse()
+}
+
+func (p *Plugin) runEdit(args []string, extra *model.CommandArgs) (bool, *model.CommandResponse, error) {
+	quizOptions := []*model.PostActionOptions{}
+	for _, q := range p.store.GetAvailableQuizes() {
+		if !p.canEditQuiz(q, extra.UserId) {
+			continue
+		}
+		quizOptions = append(quizOptions, &model.PostActionOptions{Text: q.Name, Value: q.ID})
+	}
+
+	if len(quizOptions) == 0 {
+		p.postCommandResponse(extra, "Error: There are no quizzes you can edit.")
+		return emptyCommandResponse()
+	}
+
+	err := p.mm.Frontend.OpenInteractiveDialog(model.OpenDialogRequest{
+		TriggerId: extra.TriggerId,
+		URL:       p.getDialogURL() + DialogPathEditQuiz,
+		Dialog: model.Dialog{
+			Title:            "Edit quiz",
+			IntroductionText: "Select the quiz to edit. The bot will send you a message to edit it.",
+			SubmitLabel:      "Edit",
+			Elements: []model.DialogElement{
+				{
+					Type:        DialogTypeSelect,
+					Name:        DialogSubmissionFieldQuiz,
+					DisplayName: "Quiz",
+					Options:     quizOptions,
+				},
+			},
+		},
+	})
+	if err != nil {
+		p.postCommandResponse(extra, "Error: "+err.Error())
+		return emptyCommandResponse()
+	}
+
+	return emptyCommandResponse()
+}
+
 func emptyCommandResponse() (bool, *model.CommandResponse, error) {
 	return false, &model.CommandResponse{}, nil
 }
@@ -18,6 +18,11 @@ import (
 // If you add non-reference types to your configuration struct, be sure to rewrite Clone as a deep
 // copy appropriate for your types.
 type configuration struct {
+	// TypoTolerance is the percentage of the answer length that can be mistyped in
+	// single answer quizzes.
+	TypoTolerance int
+	// NumericTolerance is the percentage numeric answers can differ from the correct one.
+	NumericTolerance int
 }
 
 // Clone shallow copies the configuration. Your implementation may require a deep copy if
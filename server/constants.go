@@ -1,5 +1,7 @@
 package main
 
+import "time"
+
 const (
 	CommandTrigger     = "quiz"
 	CommandDisplayName = "Quiz commands"
@@ -21,6 +23,8 @@ const (
 	DialogPathGameStart          = "/start"
 	DialogPathScore              = "/score"
 	DialogPathAnswer             = "/answer"
+	DialogPathSelectAnswers      = "/selectAnswers"
+	DialogPathOrderAnswers       = "/orderAnswers"
 	DialogPathNameCourse         = "/nameCourse"
 	DialogPathCourseDescription  = "/courseDescription"
 	DialogPathCourseDelete       = "/deleteCourse"
@@ -32,65 +36,140 @@ const (
 	DialogPathAddQuizResource    = "/addQuizResource"
 	DialogPathRemoveResources    = "/removeResource"
 	DialogPathLessonDelete       = "/deleteLesson"
+	DialogPathQuizEditors        = "/quizEditors"
+	DialogPathCourseEditors      = "/courseEditors"
+	DialogPathEditQuiz           = "/editQuiz"
+	DialogPathEditQuestion       = "/editQuestion"
+	DialogPathUpdateQuestion     = "/updateQuestion"
+	DialogPathCourseStart        = "/courseStart"
+	DialogPathExportQuiz         = "/exportQuiz"
+	DialogPathQuizDetails        = "/quizDetails"
+	DialogPathQuizScope          = "/quizScope"
+	DialogPathCourseScope        = "/courseScope"
+	DialogPathQuizVisibility     = "/quizVisibility"
+	DialogPathCourseVisibility   = "/courseVisibility"
+
+	AttachmentPath                    = "/attachment"
+	AttachmentPathNameQuiz            = "/name"
+	AttachmentPathChangeType          = "/type"
+	AttachmentPathDelete              = "/delete"
+	AttachmentPathAddQuestion         = "/add"
+	AttachmentPathReviewQuestions     = "/review"
+	AttachmentPathRemoveQuestion      = "/remove"
+	AttachmentPathSave                = "/save"
+	AttachmentPathSelectAnswer        = "/selectAnswer"
+	AttachmentPathAnswer              = "/answer"
+	AttachmentPathNext                = "/next"
+	AttachmentPathScore               = "/score"
+	AttachmentPathNameCourse          = "/nameCourse"
+	AttachmentPathCourseDelete        = "/deleteCourse"
+	AttachmentPathCourseDescription   = "/courseDescription"
+	AttachmentPathAddLesson           = "/addLesson"
+	AttachmentPathEditLesson          = "/editLesson"
+	AttachmentPathSaveCourse          = "/saveCourse"
+	AttachmentPathNameLesson          = "/nameLesson"
+	AttachmentPathLessonBack          = "/lessonBack"
+	AttachmentPathLessonIntroduction  = "/lessonIntroduction"
+	AttachmentPathAddResource         = "/addResource"
+	AttachmentPathAddQuizResource     = "/addQuizResource"
+	AttachmentPathRemoveResources     = "/removeResource"
+	AttachmentPathLessonDelete        = "/lessonDelete"
+	AttachmentPathQuizEditors         = "/quizEditors"
+	AttachmentPathCourseEditors       = "/courseEditors"
+	AttachmentPathEditQuestion        = "/editQuestion"
+	AttachmentPathUpdateQuestion      = "/updateQuestion"
+	AttachmentPathQuestionBack        = "/questionBack"
+	AttachmentPathSetQuestionImage    = "/setQuestionImage"
+	AttachmentPathRemoveQuestionImage = "/removeQuestionImage"
+	AttachmentPathCourseLesson        = "/courseLesson"
+	AttachmentPathCourseQuiz          = "/courseQuiz"
+	AttachmentPathCourseFinish        = "/courseFinish"
+	AttachmentPathExportResults       = "/exportResults"
+	AttachmentPathQuizDetails         = "/quizDetails"
+	AttachmentPathQuizScope           = "/quizScope"
+	AttachmentPathCourseScope         = "/courseScope"
+	AttachmentPathQuizVisibility      = "/quizVisibility"
+	AttachmentPathCourseVisibility    = "/courseVisibility"
+	AttachmentPathRestoreRevision     = "/restoreRevision"
 
-	AttachmentPath                   = "/attachment"
-	AttachmentPathNameQuiz           = "/name"
-	AttachmentPathChangeType         = "/type"
-	AttachmentPathDelete             = "/delete"
-	AttachmentPathAddQuestion        = "/add"
-	AttachmentPathReviewQuestions    = "/review"
-	AttachmentPathRemoveQuestion     = "/remove"
-	AttachmentPathSave               = "/save"
-	AttachmentPathSelectAnswer       = "/selectAnswer"
-	AttachmentPathAnswer             = "/answer"
-	AttachmentPathNext               = "/next"
-	AttachmentPathScore              = "/score"
-	AttachmentPathNameCourse         = "/nameCourse"
-	AttachmentPathCourseDelete       = "/deleteCourse"
-	AttachmentPathCourseDescription  = "/courseDescription"
-	AttachmentPathAddLesson          = "/addLesson"
-	AttachmentPathEditLesson         = "/editLesson"
-	AttachmentPathSaveCourse         = "/saveCourse"
-	AttachmentPathNameLesson         = "/nameLesson"
-	AttachmentPathLessonBack         = "/lessonBack"
-	AttachmentPathLessonIntroduction = "/lessonIntroduction"
-	AttachmentPathAddResource        = "/addResource"
-	AttachmentPathAddQuizResource    = "/addQuizResource"
-	AttachmentPathRemoveResources    = "/removeResource"
-	AttachmentPathLessonDelete       = "/lessonDelete"
+	StaticPath     = "/static"
+	ImagePath      = "/image"
+	QuizSearchPath = "/quizzes"
 
-	StaticPath = "/static"
+	ImageGameParameter = "game"
 
 	DialogTypeSelect    = "select"
 	DialogTypeBool      = "bool"
 	DialogTypeText      = "text"
+	DialogTypeTextArea  = "textarea"
 	DialogSubtypeNumber = "number"
+	DialogSubtypeURL    = "url"
 
 	AttachmentContextFieldID          = "ID"
-	AttachmentContextFieldCorrect     = "correct"
+	AttachmentContextFieldAnswer      = "answer"
 	AttachmentContextFieldGameID      = "gameID"
 	AttachmentContextFieldQuestionID  = "questionID"
 	AttachmentContextFieldLessonIndex = "index"
+	AttachmentContextFieldResource    = "resource"
+	AttachmentContextFieldSelected    = "selected_option"
 
 	DialogSubmissionFieldName              = "name"
 	DialogSubmissionFieldType              = "type"
 	DialogSubmissionFieldQuestion          = "question"
 	DialogSubmissionFieldAnswer            = "answer"
 	DialogSubmissionFieldWrongAnswer       = "wrong_"
+	DialogSubmissionFieldAlternatives      = "alternatives"
 	DialogSubmissionFieldGameQuiz          = "quiz"
 	DialogSubmissionFieldGameType          = "type"
 	DialogSubmissionFieldGameScoring       = "scoring"
 	DialogSubmissionFieldNumberOfQuestions = "nquestions"
+	DialogSubmissionFieldTimeLimit         = "timelimit"
 	DialogSubmissionFieldGameAnswer        = "game_answer"
+	DialogSubmissionFieldGameOption        = "game_option_"
+	DialogSubmissionFieldGamePosition      = "game_position_"
+	DialogSubmissionFieldTolerance         = "tolerance"
+	DialogSubmissionFieldUnit              = "unit"
+	DialogSubmissionFieldCorrectAnswers    = "correct_answers"
+	DialogSubmissionFieldExplanation       = "explanation"
+	DialogSubmissionFieldReferenceLink     = "reference_link"
+	DialogSubmissionFieldMultiSelectScore  = "multiselect_scoring"
 	DialogSubmissionFieldDescription       = "description"
 	DialogSubmissionFieldLesson            = "lesson"
 	DialogSubmissionFieldContent           = "content"
 	DialogSubmissionFieldQuiz              = "quiz"
+	DialogSubmissionFieldEditors           = "editors"
+	DialogSubmissionFieldChannels          = "channels"
+	DialogSubmissionFieldVisibility        = "visibility"
+	DialogSubmissionFieldSharedUsers       = "shared_users"
+	DialogSubmissionFieldSharedGroups      = "shared_groups"
+	DialogSubmissionFieldCourse            = "course"
+	DialogSubmissionFieldCategory          = "category"
+	DialogSubmissionFieldTags              = "tags"
+	DialogSubmissionFieldDifficulty        = "difficulty"
+
+	MinIncorrectAnswers      = 1
+	MaxIncorrectAnswers      = 7
+	MinOrderingItems         = 2
+	MaxOrderingItems         = 8
+	CourseQuizPassPercentage = 50
+	MinTimeLimit             = 5
+	MaxTimeLimit             = 3600
+	SpeedScoringMaxPoints    = 10
+	SpeedScoringWindow       = 30 * time.Second
+	MaxImportFileSize        = 1024 * 1024
+	MaxQuestionImageSize     = 5 * 1024 * 1024
+	ImportPostsLookback      = 30
+	StatsTopCount            = 3
+	LeaderboardSize          = 10
+	MaxQuizOptions           = 100
 
-	IncorrectAnswerCount = 3
-	Separator            = "-------------"
+	LeaderboardRefreshJobKey   = "leaderboardRefresh"
+	LeaderboardRefreshInterval = time.Hour
+	MigrationsMutexKey         = "migrations"
+	Separator                  = "-------------"
 
 	AchievementNameContentCreator = "Content creator"
 	AchievementNameWinner         = "Winner"
 	AchievementNameHardWorker     = "Hard worker"
+	AchievementNameCourseGraduate = "Course graduate"
 )
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+)
+
+// exportedQuiz is the format quizzes are exported to. It matches the JSON format
+// accepted by ParseQuizFile, so exported quizzes can be imported back.
+type exportedQuiz struct {
+	Name               string
+	Type               QuizType
+	MultiSelectScoring MultiSelectScoring `json:",omitempty"`
+	Category           string             `json:",omitempty"`
+	Tags               []string           `json:",omitempty"`
+	Difficulty         QuizDifficulty     `json:",omitempty"`
+	Questions          []exportedQuestion
+}
+
+type exportedQuestion struct {
+	Type                QuizType `json:",omitempty"`
+	Question            string
+	CorrectAnswer       string
+	IncorrectAnswers    []string `json:",omitempty"`
+	AlternativeAnswers  []string `json:",omitempty"`
+	ExtraCorrectAnswers []string `json:",omitempty"`
+	Tolerance           float64  `json:",omitempty"`
+	Unit                string   `json:",omitempty"`
+	Explanation         string   `json:",omitempty"`
+	ReferenceLink       string   `json:",omitempty"`
+}
+
+// ExportFile is a file generated by an export.
+type ExportFile struct {
+	Name string
+	Data []byte
+}
+
+func ExportQuizJSON(q *Quiz) ([]byte, error) {
+	out := exportedQuiz{
+		Name:               q.Name,
+		Type:               q.Type,
+		MultiSelectScoring: q.MultiSelectScoring,
+		Category:           q.Category,
+		Tags:               q.Tags,
+		Difficulty:         q.Difficulty,
+		Questions:          []exportedQuestion{},
+	}
+
+	for _, question := range q.Questions {
+		out.Questions = append(out.Questions, exportedQuestion{
+			Type:                question.Type,
+			Question:            question.Question,
+			CorrectAnswer:       question.CorrectAnswer,
+			IncorrectAnswers:    question.IncorrectAnswers,
+			AlternativeAnswers:  question.AlternativeAnswers,
+			ExtraCorrectAnswers: question.ExtraCorrectAnswers,
+			Tolerance:           question.Tolerance,
+			Unit:                question.Unit,
+			Explanation:         question.Explanation,
+			ReferenceLink:       question.ReferenceLink,
+		})
+	}
+
+	return json.MarshalIndent(out, "", "  ")
+}
+
+// ExportQuizCSV exports the quiz with one row per question. The first row is a
+// header, which is skipped when the file is imported back. The format has no room
+// for question types nor for several correct answers, so see canExportQuizCSV.
+func ExportQuizCSV(q *Quiz) ([]byte, error) {
+	incorrectColumns := 0
+	for _, question := range q.Questions {
+		if len(question.IncorrectAnswers) > incorrectColumns {
+			incorrectColumns = len(question.IncorrectAnswers)
+		}
+	}
+
+	header := []string{"question", "correct answer"}
+	for i := 0; i < incorrectColumns; i++ {
+		header = append(header, fmt.Sprintf("incorrect answer %d", i+1))
+	}
+
+	records := [][]string{header}
+	for _, question := range q.Questions {
+		record := []string{question.Question, question.CorrectAnswer}
+		record = append(record, question.IncorrectAnswers...)
+		records = append(records, record)
+	}
+
+	return writeCSV(records)
+}
+
+func ExportGameResultJSON(r *GameResult) ([]byte, error) {
+	return json.MarshalIndent(r, "", "  ")
+}
+
+// ExportGameResultCSV exports the results with one row per player and question. Questions
+// a player did not answer are included with an empty answer.
+func ExportGameResultCSV(r *GameResult) ([]byte, error) {
+	answers := map[string]map[string]GameAnswer{}
+	for _, answer := range r.Answers {
+		if answers[answer.Username] == nil {
+			answers[answer.Username] = map[string]GameAnswer{}
+		}
+		answers[answer.Username][answer.QuestionID] = answer
+	}
+
+	records := [][]string{{"player", "question number", "question", "correct answer", "answer", "correct", "answered at", "response time (seconds)", "score"}}
+	for _, player := range r.Usernames() {
+		score := formatScore(r.Score[player])
+		for i, question := range r.Questions {
+			record := []string{player, strconv.Itoa(i + 1), question.Question, strings.Join(question.CorrectOptions(), "; ")}
+
+			answer, ok := answers[player][question.ID]
+			if !ok {
+				record = append(record, "", "false", "", "", score)
+				records = append(records, record)
+				continue
+			}
+
+			record = append(record,
+				answer.Answer,
+				strconv.FormatBool(answer.Correct),
+				time.Unix(0, answer.AnsweredAt*int64(time.Millisecond)).UTC().Format(time.RFC3339),
+				strconv.FormatFloat(float64(answer.ResponseTime)/1000, 'f', 1, 64),
+				score,
+			)
+			records = append(records, record)
+		}
+	}
+
+	return writeCSV(records)
+}
+
+func writeCSV(records [][]string) ([]byte, error) {
+	b := &bytes.Buffer{}
+	w := csv.NewWriter(b)
+	err := w.WriteAll(records)
+	if err != nil {
+		return nil, err
+	}
+
+	return b.Bytes(), nil
+}
+
+func getQuizExportFiles(q *Quiz) ([]ExportFile, error) {
+	jsonData, err := ExportQuizJSON(q)
+	if err != nil {
+		return nil, err
+	}
+
+	name := getExportFileName(q.Name)
+	files := []ExportFile{{Name: name + ".json", Data: jsonData}}
+	if !canExportQuizCSV(q) {
+		return files, nil
+	}
+
+	csvData, err := ExportQuizCSV(q)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(files, ExportFile{Name: name + ".csv", Data: csvData}), nil
+}
+
+// canExportQuizCSV returns whether the quiz can be imported back from CSV, which
+// needs every question to have the quiz type, either single answer or multiple
+// choice.
+func canExportQuizCSV(q *Quiz) bool {
+	if q.Type != QuizTypeSingleAnswer && q.Type != QuizTypeMultipleChoice {
+		return false
+	}
+
+	for _, question := range q.Questions {
+		if question.Type != q.Type {
+			return false
+		}
+	}
+
+	return true
+}
+
+func getGameResultExportFiles(r *GameResult) ([]ExportFile, error) {
+	jsonData, err := ExportGameResultJSON(r)
+	if err != nil {
+		return nil, err
+	}
+
+	csvData, err := ExportGameResultCSV(r)
+	if err != nil {
+		return nil, err
+	}
+
+	name := getExportFileName(r.QuizName) + "-results-" + time.Unix(0, r.FinishedAt*int64(time.Millisecond)).UTC().Format("20060102-150405")
+	return []ExportFile{
+		{Name: name + ".json", Data: jsonData},
+		{Name: name + ".csv", Data: csvData},
+	}, nil
+}
+
+// sendExportFiles uploads the files to the DM between the bot and the user, and posts
+// them there with the given message.
+func (p *Plugin) sendExportFiles(userID, message string, files []ExportFile) error {
+	channel, err := p.mm.Channel.GetDirect(userID, p.BotUserID)
+	if err != nil {
+		return err
+	}
+
+	post := &model.Post{
+		UserId:    p.BotUserID,
+		ChannelId: channel.Id,
+		Message:   message,
+	}
+
+	for _, file := range files {
+		info, err := p.mm.File.Upload(bytes.NewReader(file.Data), file.Name, channel.Id)
+		if err != nil {
+			return err
+		}
+		post.FileIds = append(post.FileIds, info.Id)
+	}
+
+	return p.mm.Post.CreatePost(post)
+}
+
+func getExportFileName(name string) string {
+	out := []rune{}
+	for _, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+			out = append(out, r)
+		case r == ' ':
+			out = append(out, '-')
+		}
+	}
+
+	if len(out) == 0 {
+		return "quiz"
+	}
+
+	return string(out)
+}
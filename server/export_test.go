@@ -0,0 +1,81 @@
+package main
+
+import (
+	"encoding/csv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportQuizRoundTrip(t *testing.T) {
+	q := &Quiz{
+		ID:   "quizid",
+		Name: "World capitals",
+		Type: QuizTypeMultipleChoice,
+		Questions: []Question{
+			{ID: "q1", Type: QuizTypeMultipleChoice, Question: "Capital of France?", CorrectAnswer: "Paris", IncorrectAnswers: []string{"Lyon", "Nice", "Lille"}, Explanation: "The largest city of France.", ReferenceLink: "https://en.wikipedia.org/wiki/Paris"},
+			{ID: "q2", Type: QuizTypeMultipleChoice, Question: "Capital of Italy, the country?", CorrectAnswer: "Rome", IncorrectAnswers: []string{"Milan", "Naples", "Turin", "Genoa"}},
+		},
+		CreatorID: "creator",
+	}
+
+	files, err := getQuizExportFiles(q)
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+	assert.Equal(t, "World-capitals.json", files[0].Name)
+	assert.Equal(t, "World-capitals.csv", files[1].Name)
+	assert.NotContains(t, string(files[0].Data), "creator")
+
+	for _, file := range files {
+		imported, importErrors, err := ParseQuizFile(file.Name, file.Data)
+		require.NoError(t, err, file.Name)
+		assert.Empty(t, importErrors, file.Name)
+		assert.Equal(t, q.Type, imported.Type, file.Name)
+		require.Len(t, imported.Questions, len(q.Questions), file.Name)
+		for i, question := range imported.Questions {
+			assert.Equal(t, q.Questions[i].Question, question.Question)
+			assert.Equal(t, q.Questions[i].CorrectAnswer, question.CorrectAnswer)
+			assert.Equal(t, q.Questions[i].IncorrectAnswers, question.IncorrectAnswers)
+			if file.Name == "World-capitals.json" {
+				assert.Equal(t, q.Questions[i].Explanation, question.Explanation)
+				assert.Equal(t, q.Questions[i].ReferenceLink, question.ReferenceLink)
+			}
+		}
+	}
+}
+
+func TestExportGameResultCSV(t *testing.T) {
+	g := &Game{
+		Quiz:               Quiz{ID: "quizid", Name: "Capitals"},
+		RootPostID:         "game",
+		RemainingQuestions: []Question{{ID: "q1", Question: "Capital of France?", CorrectAnswer: "Paris"}, {ID: "q2", Question: "Capital of Spain?", CorrectAnswer: "Madrid"}},
+	}
+	g.startQuestionClock()
+	require.NoError(t, g.RecordAnswer("q1", "bob", "Paris", true))
+	require.NoError(t, g.RecordAnswer("q1", "alice", "Lyon", false))
+	g.PassedQuestions = append(g.PassedQuestions, g.RemainingQuestions[0])
+	g.RemainingQuestions = g.RemainingQuestions[1:]
+	g.AlreadyAnswered = nil
+	require.NoError(t, g.RecordAnswer("q2", "alice", "Madrid", true))
+	g.PassedQuestions = append(g.PassedQuestions, g.RemainingQuestions[0])
+
+	data, err := ExportGameResultCSV(NewGameResult(g))
+	require.NoError(t, err)
+
+	records, err := csv.NewReader(strings.NewReader(string(data))).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 5)
+
+	summary := [][]string{}
+	for _, record := range records[1:] {
+		summary = append(summary, []string{record[0], record[1], record[4], record[5], record[8]})
+	}
+	assert.Equal(t, [][]string{
+		{"alice", "1", "Lyon", "false", "1"},
+		{"alice", "2", "Madrid", "true", "1"},
+		{"bob", "1", "Paris", "true", "1"},
+		{"bob", "2", "", "false", "1"},
+	}, summary)
+}
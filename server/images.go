@@ -0,0 +1,242 @@
+package main
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/pkg/errors"
+)
+
+// copyQuestionImage copies an image uploaded by the user to a post of the bot in the
+// channel, and returns the ID of the copy. Keeping the image in a post of the bot lets
+// the plugin delete it when it is not needed anymore, without touching the posts of
+// the user.
+func (p *Plugin) copyQuestionImage(fileID, userID, channelID string) (string, error) {
+	info, err := p.mm.File.GetInfo(fileID)
+	if err != nil {
+		return "", err
+	}
+
+	if info.CreatorId != userID {
+		return "", errors.New("you can only use images you uploaded")
+	}
+
+	if !info.IsImage() {
+		return "", errors.Errorf("the file %s is not an image", info.Name)
+	}
+
+	if info.Size > MaxQuestionImageSize {
+		return "", errors.Errorf("the image is too big, the maximum size is %d KB", MaxQuestionImageSize/1024)
+	}
+
+	reader, err := p.mm.File.Get(fileID)
+	if err != nil {
+		return "", err
+	}
+
+	imageCopy, err := p.mm.File.Upload(reader, info.Name, channelID)
+	if err != nil {
+		return "", err
+	}
+
+	err = p.mm.Post.CreatePost(&model.Post{
+		UserId:    p.BotUserID,
+		ChannelId: channelID,
+		Message:   "Image added to the question. It will be deleted with the quiz.",
+		FileIds:   model.StringArray{imageCopy.Id},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return imageCopy.Id, nil
+}
+
+// deleteQuestionImage deletes the post of the bot holding the image, which deletes
+// the image too.
+func (p *Plugin) deleteQuestionImage(fileID string) {
+	if fileID == "" {
+		return
+	}
+
+	info, err := p.mm.File.GetInfo(fileID)
+	if err != nil || info.PostId == "" {
+		p.mm.Log.Debug("Cannot find the question image", "fileID", fileID, "err", err)
+		return
+	}
+
+	post, err := p.mm.Post.GetPost(info.PostId)
+	if err != nil || post.UserId != p.BotUserID {
+		return
+	}
+
+	err = p.mm.Post.DeletePost(post.Id)
+	if err != nil {
+		p.mm.Log.Debug("Cannot delete the question image", "fileID", fileID, "err", err)
+	}
+}
+
+// getQuizImageFileIDs returns the images of the questions of the quiz and of the
+// revisions kept for it, since any of them may be restored.
+func (p *Plugin) getQuizImageFileIDs(q *Quiz) map[string]bool {
+	fileIDs := map[string]bool{}
+	for _, id := range q.ImageFileIDs() {
+		fileIDs[id] = true
+	}
+
+	revisions, err := p.store.GetQuizRevisions(q.ID)
+	if err != nil {
+		p.mm.Log.Debug("Cannot get the quiz revisions", "id", q.ID, "err", err)
+		return fileIDs
+	}
+
+	for _, info := range revisions {
+		for _, id := range info.ImageFileIDs {
+			fileIDs[id] = true
+		}
+	}
+
+	return fileIDs
+}
+
+// storeQuizRevision stores the quiz as a new revision authored by the user, and deletes
+// the images that were only used by the old revisions dropped from its history.
+func (p *Plugin) storeQuizRevision(q *Quiz, authorID string) error {
+	unusedImages, err := p.store.StoreQuizRevision(q, authorID)
+	if err != nil {
+		return err
+	}
+
+	for _, fileID := range unusedImages {
+		p.deleteQuestionImage(fileID)
+	}
+
+	return nil
+}
+
+func (p *Plugin) deleteQuestionImages(fileIDs map[string]bool) {
+	for fileID := range fileIDs {
+		p.deleteQuestionImage(fileID)
+	}
+}
+
+func (p *Plugin) getQuestionImageURL(quizID string, question *Question) string {
+	if question.ImageFileID == "" {
+		return ""
+	}
+
+	return p.getPluginURL() + ImagePath + "/" + quizID + "/" + question.ImageFileID
+}
+
+// getGameQuestionImageURL returns the URL of the image of a question shown in the game,
+// which lets the people in the channel of the game see it.
+func (p *Plugin) getGameQuestionImageURL(g *Game, question *Question) string {
+	url := p.getQuestionImageURL(g.Quiz.ID, question)
+	if url == "" || g.RootPostID == "" {
+		return url
+	}
+
+	return url + "?" + ImageGameParameter + "=" + g.RootPostID
+}
+
+// canSeeQuizImage reports whether the user can see the image of a question of the quiz.
+// Only the people that can start the quiz can see its images.
+func (p *Plugin) canSeeQuizImage(quizID, fileID, userID string) bool {
+	q, err := p.store.GetQuiz(quizID)
+	if err != nil {
+		return false
+	}
+
+	if !p.canStartQuiz(q, userID, "", "") {
+		return false
+	}
+
+	for _, id := range q.ImageFileIDs() {
+		if id == fileID {
+			return true
+		}
+	}
+
+	// Posts showing older versions of the quiz may still use the images of its revisions.
+	return p.getQuizImageFileIDs(q)[fileID]
+}
+
+// canSeeGameImage reports whether the user can see the image of a question asked in the
+// game. The GM and the people that can read the channel of the game can see them, also
+// after the game finished.
+func (p *Plugin) canSeeGameImage(gameID, quizID, fileID, userID string) bool {
+	var gameQuizID, gm, channelID string
+	var questions []Question
+	g, err := p.store.GetGame(gameID)
+	if err != nil {
+		return false
+	}
+
+	if g != nil {
+		gameQuizID, gm, channelID = g.Quiz.ID, g.GM, g.ChannelID
+		questions = append(append(questions, g.PassedQuestions...), g.RemainingQuestions...)
+	} else {
+		result, err := p.store.GetGameResult(gameID)
+		if err != nil || result == nil {
+			return false
+		}
+
+		gameQuizID, gm, channelID = result.QuizID, result.GM, result.ChannelID
+		questions = result.Questions
+	}
+
+	if gameQuizID != quizID {
+		return false
+	}
+
+	if userID != gm && !p.mm.User.HasPermissionToChannel(userID, channelID, model.PERMISSION_READ_CHANNEL) {
+		return false
+	}
+
+	for _, question := range questions {
+		if question.ImageFileID == fileID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// serveQuestionImage serves the image of a question. Images shown in a game are served
+// to the people playing it, and the other ones to the people that can start the quiz.
+// Only images of questions are served, so the route cannot be used to read other files.
+func (p *Plugin) serveQuestionImage(w http.ResponseWriter, r *http.Request, actingUserID string) {
+	vars := mux.Vars(r)
+	allowed := false
+	if gameID := r.URL.Query().Get(ImageGameParameter); gameID != "" {
+		allowed = p.canSeeGameImage(gameID, vars["quizID"], vars["fileID"], actingUserID)
+	} else {
+		allowed = p.canSeeQuizImage(vars["quizID"], vars["fileID"], actingUserID)
+	}
+
+	if !allowed {
+		http.NotFound(w, r)
+		return
+	}
+
+	info, err := p.mm.File.GetInfo(vars["fileID"])
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	reader, err := p.mm.File.Get(info.Id)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", info.MimeType)
+	w.Header().Set("Cache-Control", "private, max-age=3600")
+	_, err = io.Copy(w, reader)
+	if err != nil {
+		p.mm.Log.Debug("Cannot serve the question image", "fileID", info.Id, "err", err)
+	}
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServeQuestionImage(t *testing.T) {
+	p, api := newPermissionsTestPlugin(t)
+	api.On("GetFileInfo", "imageid").Return(&model.FileInfo{Id: "imageid", MimeType: "image/png"}, nil)
+	api.On("GetFile", "imageid").Return([]byte("image data"), nil)
+
+	q, err := p.store.GetQuiz(testQuizID)
+	require.NoError(t, err)
+	q.Questions[0].ImageFileID = "imageid"
+	require.NoError(t, p.store.StoreQuiz(q))
+
+	get := func(path, userID string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, path, nil)
+		r.Header.Set("Mattermost-User-ID", userID)
+		p.router.ServeHTTP(w, r)
+		return w
+	}
+
+	w := get(ImagePath+"/"+testQuizID+"/imageid", "editor")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "image/png", w.Header().Get("Content-Type"))
+	assert.Equal(t, "image data", w.Body.String())
+
+	assert.Equal(t, http.StatusNotFound, get(ImagePath+"/"+testQuizID+"/imageid", "player").Code)
+	require.NoError(t, p.store.AddAvailableQuiz(q))
+	assert.Equal(t, http.StatusOK, get(ImagePath+"/"+testQuizID+"/imageid", "player").Code)
+
+	assert.Equal(t, http.StatusNotFound, get(ImagePath+"/"+testQuizID+"/otherfile", "player").Code)
+	assert.Equal(t, http.StatusNotFound, get(ImagePath+"/otherquiz/imageid", "player").Code)
+	assert.Equal(t, http.StatusUnauthorized, get(ImagePath+"/"+testQuizID+"/imageid", "").Code)
+
+	assert.Equal(t, p.getPluginURL()+ImagePath+"/"+testQuizID+"/imageid", p.getQuestionImageURL(testQuizID, &q.Questions[0]))
+	assert.Empty(t, p.getQuestionImageURL(testQuizID, &Question{}))
+}
+
+func TestServeGameQuestionImage(t *testing.T) {
+	p, api := newPermissionsTestPlugin(t)
+	api.On("GetFileInfo", "imageid").Return(&model.FileInfo{Id: "imageid", MimeType: "image/png"}, nil)
+	api.On("GetFile", "imageid").Return([]byte("image data"), nil)
+	api.On("HasPermissionToChannel", "player", "channel", model.PERMISSION_READ_CHANNEL).Return(true)
+	api.On("HasPermissionToChannel", mock.Anything, "channel", model.PERMISSION_READ_CHANNEL).Return(false)
+
+	g := &Game{
+		Quiz:               Quiz{ID: testQuizID, Name: "Quiz"},
+		GM:                 "gm",
+		RootPostID:         "game",
+		ChannelID:          "channel",
+		RemainingQuestions: []Question{{ID: "questionid", ImageFileID: "imageid"}},
+	}
+	require.NoError(t, p.store.StoreGame(g))
+
+	url := p.getGameQuestionImageURL(g, &g.RemainingQuestions[0])
+	assert.Equal(t, p.getPluginURL()+ImagePath+"/"+testQuizID+"/imageid?"+ImageGameParameter+"=game", url)
+	path := strings.TrimPrefix(url, p.getPluginURL())
+
+	get := func(path, userID string) int {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, path, nil)
+		r.Header.Set("Mattermost-User-ID", userID)
+		p.router.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, get(path, "player"))
+	assert.Equal(t, http.StatusOK, get(path, "gm"))
+	assert.Equal(t, http.StatusNotFound, get(path, "stranger"))
+	assert.Equal(t, http.StatusNotFound, get(ImagePath+"/otherquiz/imageid?"+ImageGameParameter+"=game", "player"))
+	assert.Equal(t, http.StatusNotFound, get(ImagePath+"/"+testQuizID+"/imageid?"+ImageGameParameter+"=othergame", "player"))
+
+	g.PassedQuestions = g.RemainingQuestions
+	require.NoError(t, p.store.AddGameResult(NewGameResult(g)))
+	require.NoError(t, p.store.DeleteGame(g.RootPostID))
+	assert.Equal(t, http.StatusOK, get(path, "player"))
+	assert.Equal(t, http.StatusNotFound, get(path, "stranger"))
+}
+
+func TestServeQuestionImageOfRevision(t *testing.T) {
+	p, api := newPermissionsTestPlugin(t)
+	api.On("GetFileInfo", "imageid").Return(&model.FileInfo{Id: "imageid", MimeType: "image/png"}, nil)
+	api.On("GetFile", "imageid").Return([]byte("image data"), nil)
+
+	q, err := p.store.GetQuiz(testQuizID)
+	require.NoError(t, err)
+	q.Questions[0].ImageFileID = "imageid"
+	require.NoError(t, p.storeQuizRevision(q, "editor"))
+	q.Questions[0].ImageFileID = ""
+	require.NoError(t, p.storeQuizRevision(q, "editor"))
+
+	revisions, err := p.store.GetQuizRevisions(testQuizID)
+	require.NoError(t, err)
+	require.Len(t, revisions, 3)
+	assert.Empty(t, revisions[0].ImageFileIDs)
+	assert.Equal(t, []string{"imageid"}, revisions[1].ImageFileIDs)
+	assert.Empty(t, revisions[2].ImageFileIDs)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, ImagePath+"/"+testQuizID+"/imageid", nil)
+	r.Header.Set("Mattermost-User-ID", "editor")
+	p.router.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
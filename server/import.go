@@ -0,0 +1,458 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/pkg/errors"
+)
+
+// ImportError describes a problem found on a row or question of an imported file.
+// Questions with errors are skipped, and the rest of the file is still imported.
+type ImportError struct {
+	Location string
+	Message  string
+}
+
+func (e ImportError) String() string {
+	return e.Location + ": " + e.Message
+}
+
+type importedQuestion struct {
+	location  string
+	question  Question
+	trueFalse bool
+}
+
+// ParseQuizFile parses a quiz from the contents of an uploaded file. The format is
+// chosen from the file extension:
+//
+// - .csv: one question per row, with the question, the correct answer and the
+// incorrect answers, if any, as columns. A first row starting with "question" is
+// considered a header and skipped.
+//
+// - .json: a quiz object with the same fields as Quiz, for example
+// {"Name": "Capitals", "Type": "multiple-choice", "Questions": [{"Question":
+// "Capital of France?", "CorrectAnswer": "Paris", "IncorrectAnswers": ["Lyon"]}]}.
+// Name, Type, Category, Tags and Difficulty are optional. Questions take the quiz
+// type unless they have their own Type. See Question for their other fields.
+//
+// - .gift or .txt: Moodle GIFT format. Only multiple choice, short answer and
+// true/false questions are supported. Every correct answer of a short answer
+// question after the first one is imported as an alternative answer.
+//
+// When the quiz type is not given, it is multi-select if any question has extra
+// correct answers, multiple choice if any question has incorrect answers, and single
+// answer otherwise. True/false questions are imported with the opposite value as the
+// incorrect answer in multiple choice quizzes.
+func ParseQuizFile(name string, data []byte) (*Quiz, []ImportError, error) {
+	q := &Quiz{
+		Name: strings.TrimSuffix(filepath.Base(name), filepath.Ext(name)),
+	}
+
+	var questions []importedQuestion
+	var importErrors []ImportError
+	var err error
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".csv":
+		questions, err = parseCSVQuestions(data)
+	case ".json":
+		questions, err = parseJSONQuestions(data, q)
+	case ".gift", ".txt":
+		questions, importErrors = parseGIFTQuestions(data)
+	default:
+		return nil, nil, errors.Errorf("unsupported file type %q, use a .csv, .json or .gift file", filepath.Ext(name))
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if q.Type == "" {
+		q.Type = getImportedQuizType(questions)
+	}
+
+	if !q.Type.IsValid() {
+		return nil, nil, errors.Errorf("unsupported quiz type %q", q.Type)
+	}
+
+	for _, imported := range questions {
+		question := imported.question
+		if question.Type == "" {
+			question.Type = q.Type
+		}
+
+		if question.Type == QuizTypeSingleAnswer && imported.trueFalse {
+			question.IncorrectAnswers = []string{}
+		}
+
+		err := question.Validate()
+		if err != nil {
+			importErrors = append(importErrors, ImportError{Location: imported.location, Message: err.Error()})
+			continue
+		}
+
+		question.ID = model.NewId()
+		if question.Type != QuizTypeSingleAnswer {
+			question.AlternativeAnswers = nil
+		}
+		if question.Type == QuizTypeMultiSelect && q.MultiSelectScoring == "" {
+			q.MultiSelectScoring = MultiSelectScoringAllOrNothing
+		}
+		q.Questions = append(q.Questions, question)
+	}
+
+	return q, importErrors, nil
+}
+
+// getImportedQuizType returns the quiz type described in ParseQuizFile. True/false
+// questions only count when every question is true/false, so they do not turn a
+// file of short answers into multiple choice.
+func getImportedQuizType(questions []importedQuestion) QuizType {
+	for _, imported := range questions {
+		if len(imported.question.ExtraCorrectAnswers) > 0 {
+			return QuizTypeMultiSelect
+		}
+	}
+
+	allTrueFalse := len(questions) > 0
+	for _, imported := range questions {
+		if !imported.trueFalse && len(imported.question.IncorrectAnswers) > 0 {
+			return QuizTypeMultipleChoice
+		}
+		allTrueFalse = allTrueFalse && imported.trueFalse
+	}
+
+	if allTrueFalse {
+		return QuizTypeMultipleChoice
+	}
+
+	return QuizTypeSingleAnswer
+}
+
+func newImportedQuestion(location, question, correctAnswer string, incorrectAnswers []string) importedQuestion {
+	imported := importedQuestion{
+		location: location,
+		question: Question{
+			Question:         strings.TrimSpace(question),
+			CorrectAnswer:    strings.TrimSpace(correctAnswer),
+			IncorrectAnswers: trimAnswers(incorrectAnswers),
+		},
+	}
+
+	return imported
+}
+
+func trimAnswers(answers []string) []string {
+	out := []string{}
+	for _, answer := range answers {
+		answer = strings.TrimSpace(answer)
+		if answer != "" {
+			out = append(out, answer)
+		}
+	}
+
+	return out
+}
+
+func parseCSVQuestions(data []byte) ([]importedQuestion, error) {
+	r := csv.NewReader(bytes.NewReader(data))
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+
+	out := []importedQuestion{}
+	for row := 1; ; row++ {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot read the csv file")
+		}
+
+		if row == 1 && strings.EqualFold(strings.TrimSpace(record[0]), "question") {
+			continue
+		}
+
+		for len(record) < 2 {
+			record = append(record, "")
+		}
+
+		out = append(out, newImportedQuestion(fmt.Sprintf("Row %d", row), record[0], record[1], record[2:]))
+	}
+
+	return out, nil
+}
+
+func parseJSONQuestions(data []byte, q *Quiz) ([]importedQuestion, error) {
+	imported := Quiz{}
+	err := json.Unmarshal(data, &imported)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot read the json file")
+	}
+
+	if imported.Name != "" {
+		q.Name = imported.Name
+	}
+	q.Type = imported.Type
+	q.MultiSelectScoring = imported.MultiSelectScoring
+	q.Category = strings.TrimSpace(imported.Category)
+	q.Tags = parseTags(strings.Join(imported.Tags, ","))
+	if imported.Difficulty.IsValid() {
+		q.Difficulty = imported.Difficulty
+	}
+
+	out := []importedQuestion{}
+	for i, question := range imported.Questions {
+		imported := newImportedQuestion(fmt.Sprintf("Question %d", i+1), question.Question, question.CorrectAnswer, question.IncorrectAnswers)
+		imported.question.Type = question.Type
+		imported.question.Tolerance = question.Tolerance
+		imported.question.Unit = strings.TrimSpace(question.Unit)
+		imported.question.Explanation = strings.TrimSpace(question.Explanation)
+		imported.question.ReferenceLink = strings.TrimSpace(question.ReferenceLink)
+		if alternatives := trimAnswers(question.AlternativeAnswers); len(alternatives) > 0 {
+			imported.question.AlternativeAnswers = alternatives
+		}
+		if correctAnswers := trimAnswers(question.ExtraCorrectAnswers); len(correctAnswers) > 0 {
+			imported.question.ExtraCorrectAnswers = correctAnswers
+		}
+		out = append(out, imported)
+	}
+
+	return out, nil
+}
+
+// parseGIFTQuestions parses the questions of a file in Moodle GIFT format. Questions
+// of unsupported types are reported as errors.
+func parseGIFTQuestions(data []byte) ([]importedQuestion, []ImportError) {
+	out := []importedQuestion{}
+	importErrors := []ImportError{}
+
+	lines := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	block := []string{}
+	blockStart := 0
+	flush := func() {
+		if len(block) == 0 {
+			return
+		}
+
+		location := fmt.Sprintf("Line %d", blockStart)
+		imported, err := parseGIFTQuestion(location, strings.Join(block, "\n"))
+		if err != nil {
+			importErrors = append(importErrors, ImportError{Location: location, Message: err.Error()})
+		} else {
+			out = append(out, imported)
+		}
+		block = []string{}
+	}
+
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "//") || strings.HasPrefix(trimmed, "$CATEGORY:") {
+			continue
+		}
+
+		if trimmed == "" {
+			flush()
+			continue
+		}
+
+		if len(block) == 0 {
+			blockStart = i + 1
+		}
+		block = append(block, trimmed)
+	}
+	flush()
+
+	return out, importErrors
+}
+
+func parseGIFTQuestion(location, text string) (importedQuestion, error) {
+	if strings.HasPrefix(text, "::") {
+		end := indexUnescaped(text[2:], "::")
+		if end < 0 {
+			return importedQuestion{}, errors.New("the question title is not closed")
+		}
+		text = strings.TrimSpace(text[end+4:])
+	}
+
+	for _, format := range []string{"[html]", "[moodle]", "[plain]", "[markdown]"} {
+		text = strings.TrimPrefix(text, format)
+	}
+
+	start := indexUnescaped(text, "{")
+	if start < 0 {
+		return importedQuestion{}, errors.New("the question has no answers")
+	}
+
+	end := indexUnescaped(text[start:], "}")
+	if end < 0 {
+		return importedQuestion{}, errors.New("the answers are not closed")
+	}
+	end += start
+
+	question := strings.TrimSpace(text[:start])
+	if after := strings.TrimSpace(text[end+1:]); after != "" {
+		question += " _____ " + after
+	}
+	question = unescapeGIFT(question)
+
+	answers := strings.TrimSpace(text[start+1 : end])
+	switch {
+	case answers == "":
+		return importedQuestion{}, errors.New("essay questions are not supported")
+	case strings.HasPrefix(answers, "#"):
+		return importedQuestion{}, errors.New("numerical questions are not supported")
+	case strings.Contains(answers, "->"):
+		return importedQuestion{}, errors.New("matching questions are not supported")
+	}
+
+	switch strings.ToUpper(strings.TrimSpace(splitUnescaped(answers, "#")[0])) {
+	case "T", "TRUE":
+		imported := newImportedQuestion(location, question, "True", []string{"False"})
+		imported.trueFalse = true
+		return imported, nil
+	case "F", "FALSE":
+		imported := newImportedQuestion(location, question, "False", []string{"True"})
+		imported.trueFalse = true
+		return imported, nil
+	}
+
+	parsed := []giftAnswer{}
+	multipleAnswers := true
+	positiveWeights := 0
+	for _, answer := range splitGIFTAnswers(answers) {
+		a := parseGIFTAnswer(answer)
+		parsed = append(parsed, a)
+		multipleAnswers = multipleAnswers && !a.equals
+		if a.weight > 0 {
+			positiveWeights++
+		}
+	}
+
+	// Questions with several answers with positive weights and no = are multiple
+	// answers questions, where all those answers need to be selected.
+	multipleAnswers = multipleAnswers && positiveWeights > 1
+
+	correctAnswers := []string{}
+	incorrectAnswers := []string{}
+	for _, a := range parsed {
+		isCorrect := a.equals || a.weight >= 100 || (multipleAnswers && a.weight > 0)
+		if isCorrect {
+			correctAnswers = append(correctAnswers, a.text)
+		} else {
+			incorrectAnswers = append(incorrectAnswers, a.text)
+		}
+	}
+
+	if len(correctAnswers) == 0 {
+		return newImportedQuestion(location, question, "", incorrectAnswers), nil
+	}
+
+	imported := newImportedQuestion(location, question, correctAnswers[0], incorrectAnswers)
+	switch {
+	case multipleAnswers:
+		imported.question.ExtraCorrectAnswers = trimAnswers(correctAnswers[1:])
+	case len(incorrectAnswers) == 0 && len(correctAnswers) > 1:
+		imported.question.AlternativeAnswers = trimAnswers(correctAnswers[1:])
+	}
+
+	return imported, nil
+}
+
+type giftAnswer struct {
+	text   string
+	equals bool
+	weight float64
+}
+
+// parseGIFTAnswer parses an answer starting with = or ~, with an optional %weight%
+// prefix and #feedback suffix.
+func parseGIFTAnswer(answer string) giftAnswer {
+	out := giftAnswer{equals: answer[0] == '='}
+	answer = strings.TrimSpace(splitUnescaped(answer[1:], "#")[0])
+	if strings.HasPrefix(answer, "%") {
+		weightEnd := strings.Index(answer[1:], "%")
+		if weightEnd >= 0 {
+			out.weight, _ = strconv.ParseFloat(answer[1:weightEnd+1], 64)
+			answer = strings.TrimSpace(answer[weightEnd+2:])
+		}
+	}
+	out.text = unescapeGIFT(answer)
+
+	return out
+}
+
+// splitGIFTAnswers splits the answers of a GIFT question on every unescaped = or ~,
+// keeping the marker at the start of each answer.
+func splitGIFTAnswers(text string) []string {
+	out := []string{}
+	current := ""
+	add := func() {
+		current = strings.TrimSpace(current)
+		if current != "" && (current[0] == '=' || current[0] == '~') {
+			out = append(out, current)
+		}
+		current = ""
+	}
+
+	escaped := false
+	for _, r := range text {
+		if !escaped && (r == '=' || r == '~') {
+			add()
+		}
+
+		current += string(r)
+		escaped = !escaped && r == '\\'
+	}
+	add()
+
+	return out
+}
+
+func indexUnescaped(text, sep string) int {
+	for i := 0; i < len(text); i++ {
+		if text[i] == '\\' {
+			i++
+			continue
+		}
+
+		if strings.HasPrefix(text[i:], sep) {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func splitUnescaped(text, sep string) []string {
+	out := []string{}
+	for {
+		i := indexUnescaped(text, sep)
+		if i < 0 {
+			return append(out, text)
+		}
+
+		out = append(out, text[:i])
+		text = text[i+len(sep):]
+	}
+}
+
+func unescapeGIFT(text string) string {
+	return strings.NewReplacer(
+		`\\`, `\`,
+		`\~`, `~`,
+		`\=`, `=`,
+		`\#`, `#`,
+		`\{`, `{`,
+		`\}`, `}`,
+		`\:`, `:`,
+		`\n`, "\n",
+	).Replace(text)
+}
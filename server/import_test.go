@@ -0,0 +1,224 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseQuizFile(t *testing.T) {
+	t.Run("csv", func(t *testing.T) {
+		data := "question,answer,wrong 1,wrong 2,wrong 3\n" +
+			"Capital of France?,Paris,Lyon,Nice,Lille\n" +
+			"Capital of Spain?,Madrid,Sevilla\n" +
+			"\"Capital of Italy, the country?\",Rome,Milan,Naples,Turin,\n" +
+			",Berlin,Munich,Hamburg,Cologne\n"
+
+		q, importErrors, err := ParseQuizFile("capitals.csv", []byte(data))
+		require.NoError(t, err)
+		assert.Equal(t, "capitals", q.Name)
+		assert.Equal(t, QuizTypeMultipleChoice, q.Type)
+		require.Len(t, q.Questions, 3)
+		assert.Equal(t, []string{"Sevilla"}, q.Questions[1].IncorrectAnswers)
+		assert.Equal(t, "Capital of Italy, the country?", q.Questions[2].Question)
+		assert.Equal(t, []string{"Milan", "Naples", "Turin"}, q.Questions[2].IncorrectAnswers)
+		assert.NotEmpty(t, q.Questions[0].ID)
+		assert.Equal(t, []ImportError{
+			{Location: "Row 5", Message: "the question is empty"},
+		}, importErrors)
+	})
+
+	t.Run("csv single answer", func(t *testing.T) {
+		q, importErrors, err := ParseQuizFile("capitals.CSV", []byte("Capital of France?,Paris\nCapital of Spain?,\n"))
+		require.NoError(t, err)
+		assert.Equal(t, QuizTypeSingleAnswer, q.Type)
+		assert.Len(t, q.Questions, 1)
+		assert.Equal(t, []ImportError{{Location: "Row 2", Message: "the correct answer is empty"}}, importErrors)
+	})
+
+	t.Run("json", func(t *testing.T) {
+		data := `{
+			"Name": "Capitals",
+			"Type": "single-answer",
+			"Questions": [
+				{"Question": "Capital of France?", "CorrectAnswer": "Paris"},
+				{"Question": "Capital of Spain?", "CorrectAnswer": "Madrid", "IncorrectAnswers": ["Sevilla"]}
+			]
+		}`
+
+		q, importErrors, err := ParseQuizFile("export.json", []byte(data))
+		require.NoError(t, err)
+		assert.Equal(t, "Capitals", q.Name)
+		assert.Equal(t, QuizTypeSingleAnswer, q.Type)
+		assert.Len(t, q.Questions, 1)
+		assert.Equal(t, []ImportError{{Location: "Question 2", Message: "single answer questions cannot have incorrect answers"}}, importErrors)
+	})
+
+	t.Run("json errors", func(t *testing.T) {
+		_, _, err := ParseQuizFile("export.json", []byte(`{"Questions": `))
+		assert.Error(t, err)
+
+		_, _, err = ParseQuizFile("export.json", []byte(`{"Type": "essay"}`))
+		assert.Error(t, err)
+	})
+
+	t.Run("gift", func(t *testing.T) {
+		data := "// Capitals quiz\n" +
+			"$CATEGORY: geography\n" +
+			"\n" +
+			"::Q1:: Capital of France? {=Paris ~Lyon ~Nice#Close! ~%50%Lille}\n" +
+			"\n" +
+			"What is 2\\+2\\=? {\n" +
+			"  =4\n" +
+			"  ~3\n" +
+			"  ~5\n" +
+			"  ~22\n" +
+			"}\n" +
+			"\n" +
+			"Grant is buried in Grant's tomb. {T}\n" +
+			"\n" +
+			"Write a poem. {}\n" +
+			"\n" +
+			"Pi to two decimals. {#3.14:0.01}\n"
+
+		q, importErrors, err := ParseQuizFile("capitals.gift", []byte(data))
+		require.NoError(t, err)
+		assert.Equal(t, QuizTypeMultipleChoice, q.Type)
+		require.Len(t, q.Questions, 3)
+		assert.Equal(t, "Capital of France?", q.Questions[0].Question)
+		assert.Equal(t, "Paris", q.Questions[0].CorrectAnswer)
+		assert.Equal(t, []string{"Lyon", "Nice", "Lille"}, q.Questions[0].IncorrectAnswers)
+		assert.Equal(t, "What is 2\\+2=?", q.Questions[1].Question)
+		assert.Equal(t, "4", q.Questions[1].CorrectAnswer)
+		assert.Equal(t, "True", q.Questions[2].CorrectAnswer)
+		assert.Equal(t, []string{"False"}, q.Questions[2].IncorrectAnswers)
+		assert.Equal(t, []ImportError{
+			{Location: "Line 15", Message: "essay questions are not supported"},
+			{Location: "Line 17", Message: "numerical questions are not supported"},
+		}, importErrors)
+	})
+
+	t.Run("gift short answer and missing word", func(t *testing.T) {
+		data := "Who's buried in Grant's tomb? {=Grant =Ulysses S. Grant}\n\nMoodle costs {~lots of money =nothing ~a small amount ~a fortune} to download.\n"
+
+		q, importErrors, err := ParseQuizFile("mixed.txt", []byte(data))
+		require.NoError(t, err)
+		assert.Equal(t, QuizTypeMultipleChoice, q.Type)
+		require.Len(t, q.Questions, 1)
+		assert.Equal(t, "Moodle costs _____ to download.", q.Questions[0].Question)
+		assert.Equal(t, "nothing", q.Questions[0].CorrectAnswer)
+		assert.Len(t, importErrors, 1)
+	})
+
+	t.Run("gift true/false", func(t *testing.T) {
+		q, importErrors, err := ParseQuizFile("tf.gift", []byte("The sun is a star. {T}\n\nThe moon is a planet. {FALSE}\n"))
+		require.NoError(t, err)
+		assert.Empty(t, importErrors)
+		assert.Equal(t, QuizTypeMultipleChoice, q.Type)
+		require.Len(t, q.Questions, 2)
+		assert.Equal(t, "False", q.Questions[1].CorrectAnswer)
+		assert.Equal(t, []string{"True"}, q.Questions[1].IncorrectAnswers)
+
+		q, importErrors, err = ParseQuizFile("mixed.gift", []byte("Capital of France? {=Paris}\n\nThe sun is a star. {T}\n"))
+		require.NoError(t, err)
+		assert.Empty(t, importErrors)
+		assert.Equal(t, QuizTypeSingleAnswer, q.Type)
+		require.Len(t, q.Questions, 2)
+		assert.Empty(t, q.Questions[1].IncorrectAnswers)
+	})
+
+	t.Run("too many incorrect answers", func(t *testing.T) {
+		data := "Pick one,a,b,c,d,e,f,g,h,i\nPick another,a,b,b\n"
+		q, importErrors, err := ParseQuizFile("many.csv", []byte(data))
+		require.NoError(t, err)
+		assert.Empty(t, q.Questions)
+		assert.Equal(t, []ImportError{
+			{Location: "Row 1", Message: "multiple choice questions need between 1 and 7 incorrect answers, found 8"},
+			{Location: "Row 2", Message: `the answer "b" is repeated`},
+		}, importErrors)
+	})
+
+	t.Run("multi-select", func(t *testing.T) {
+		data := "Which are primes? {~%50%2 ~%50%3 ~%-100%4 ~%-100%6}\n\nCapital of France? {=Paris ~Lyon}\n"
+		q, importErrors, err := ParseQuizFile("primes.gift", []byte(data))
+		require.NoError(t, err)
+		assert.Empty(t, importErrors)
+		assert.Equal(t, QuizTypeMultiSelect, q.Type)
+		assert.Equal(t, MultiSelectScoringAllOrNothing, q.MultiSelectScoring)
+		require.Len(t, q.Questions, 2)
+		assert.Equal(t, []string{"2", "3"}, q.Questions[0].CorrectOptions())
+		assert.Equal(t, []string{"4", "6"}, q.Questions[0].IncorrectAnswers)
+		assert.Equal(t, []string{"Paris"}, q.Questions[1].CorrectOptions())
+
+		q.MultiSelectScoring = MultiSelectScoringPartial
+		exported, err := ExportQuizJSON(q)
+		require.NoError(t, err)
+		reimported, importErrors, err := ParseQuizFile("primes.json", exported)
+		require.NoError(t, err)
+		assert.Empty(t, importErrors)
+		assert.Equal(t, QuizTypeMultiSelect, reimported.Type)
+		assert.Equal(t, MultiSelectScoringPartial, reimported.MultiSelectScoring)
+		assert.Equal(t, q.Questions[0].ExtraCorrectAnswers, reimported.Questions[0].ExtraCorrectAnswers)
+
+		files, err := getQuizExportFiles(q)
+		require.NoError(t, err)
+		require.Len(t, files, 1)
+		assert.Equal(t, "primes.json", files[0].Name)
+	})
+
+	t.Run("mixed question types", func(t *testing.T) {
+		data := `{
+			"Type": "multiple-choice",
+			"Questions": [
+				{"Question": "Capital of France?", "CorrectAnswer": "Paris", "IncorrectAnswers": ["Lyon"]},
+				{"Type": "single-answer", "Question": "Capital of Spain?", "CorrectAnswer": "Madrid", "AlternativeAnswers": ["Madrid city"]}
+			]
+		}`
+
+		q, importErrors, err := ParseQuizFile("mixed.json", []byte(data))
+		require.NoError(t, err)
+		assert.Empty(t, importErrors)
+		require.Len(t, q.Questions, 2)
+		assert.Equal(t, QuizTypeMultipleChoice, q.Questions[0].Type)
+		assert.Equal(t, QuizTypeSingleAnswer, q.Questions[1].Type)
+		assert.Equal(t, []string{"Madrid city"}, q.Questions[1].AlternativeAnswers)
+
+		files, err := getQuizExportFiles(q)
+		require.NoError(t, err)
+		require.Len(t, files, 1)
+		reimported, importErrors, err := ParseQuizFile(files[0].Name, files[0].Data)
+		require.NoError(t, err)
+		assert.Empty(t, importErrors)
+		require.Len(t, reimported.Questions, 2)
+		assert.Equal(t, QuizTypeSingleAnswer, reimported.Questions[1].Type)
+	})
+
+	t.Run("alternative answers", func(t *testing.T) {
+		data := "Who's buried in Grant's tomb? {=Grant =Ulysses S. Grant =Ulysses Grant}\n"
+		q, importErrors, err := ParseQuizFile("grant.gift", []byte(data))
+		require.NoError(t, err)
+		assert.Empty(t, importErrors)
+		require.Len(t, q.Questions, 1)
+		assert.Equal(t, "Grant", q.Questions[0].CorrectAnswer)
+		assert.Equal(t, []string{"Ulysses S. Grant", "Ulysses Grant"}, q.Questions[0].AlternativeAnswers)
+
+		data = `{"Questions": [{"Question": "Capital of the USA?", "CorrectAnswer": "Washington, D.C.", "AlternativeAnswers": [" Washington ", ""]}]}`
+		q, importErrors, err = ParseQuizFile("usa.json", []byte(data))
+		require.NoError(t, err)
+		assert.Empty(t, importErrors)
+		require.Len(t, q.Questions, 1)
+		assert.Equal(t, []string{"Washington"}, q.Questions[0].AlternativeAnswers)
+
+		exported, err := ExportQuizJSON(q)
+		require.NoError(t, err)
+		reimported, _, err := ParseQuizFile("usa.json", exported)
+		require.NoError(t, err)
+		assert.Equal(t, q.Questions[0].AlternativeAnswers, reimported.Questions[0].AlternativeAnswers)
+	})
+
+	t.Run("unsupported extension", func(t *testing.T) {
+		_, _, err := ParseQuizFile("quiz.xlsx", nil)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,287 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	pluginapi "github.com/mattermost/mattermost-plugin-api"
+	"github.com/mattermost/mattermost-plugin-api/cluster"
+	"github.com/mattermost/mattermost-server/v5/model"
+)
+
+type LeaderboardScope string
+
+const (
+	LeaderboardScopeChannel LeaderboardScope = "channel"
+	LeaderboardScopeTeam    LeaderboardScope = "team"
+)
+
+type LeaderboardPeriod string
+
+const (
+	LeaderboardPeriodWeek  LeaderboardPeriod = "week"
+	LeaderboardPeriodMonth LeaderboardPeriod = "month"
+	LeaderboardPeriodAll   LeaderboardPeriod = "all"
+)
+
+var leaderboardPeriods = []LeaderboardPeriod{LeaderboardPeriodWeek, LeaderboardPeriodMonth, LeaderboardPeriodAll}
+
+// Leaderboard holds the cumulative points and games played per user ID in a channel
+// or team during a period.
+type Leaderboard struct {
+	Scores map[string]float64
+	Played map[string]int
+}
+
+func NewLeaderboard() *Leaderboard {
+	return &Leaderboard{
+		Scores: map[string]float64{},
+		Played: map[string]int{},
+	}
+}
+
+// AddGame adds the scores of a finished game, indexed by user ID.
+func (l *Leaderboard) AddGame(scores map[string]float64) {
+	if l.Scores == nil {
+		l.Scores = map[string]float64{}
+	}
+	if l.Played == nil {
+		l.Played = map[string]int{}
+	}
+
+	for userID, score := range scores {
+		l.Scores[userID] += score
+		l.Played[userID]++
+	}
+}
+
+type leaderboardRow struct {
+	userID string
+	score  float64
+	played int
+}
+
+func (l *Leaderboard) rows() []leaderboardRow {
+	rows := []leaderboardRow{}
+	for userID, score := range l.Scores {
+		rows = append(rows, leaderboardRow{userID: userID, score: score, played: l.Played[userID]})
+	}
+
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].score != rows[j].score {
+			return rows[i].score > rows[j].score
+		}
+		if rows[i].played != rows[j].played {
+			return rows[i].played < rows[j].played
+		}
+		return rows[i].userID < rows[j].userID
+	})
+	return rows
+}
+
+// PinnedLeaderboard is a leaderboard post kept up to date by the bot.
+type PinnedLeaderboard struct {
+	PostID  string
+	Scope   LeaderboardScope
+	ScopeID string
+	Period  LeaderboardPeriod
+}
+
+// getLeaderboardBucket returns the bucket scores are added to for the period at time t.
+// Weekly buckets follow ISO weeks, and all buckets use UTC.
+func getLeaderboardBucket(period LeaderboardPeriod, t time.Time) string {
+	t = t.UTC()
+	switch period {
+	case LeaderboardPeriodWeek:
+		year, week := t.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week)
+	case LeaderboardPeriodMonth:
+		return t.Format("2006-01")
+	default:
+		return string(LeaderboardPeriodAll)
+	}
+}
+
+func getLeaderboardScores(r *GameResult) map[string]float64 {
+	scores := map[string]float64{}
+	for username, userID := range r.Players {
+		scores[userID] = r.Score[username]
+	}
+
+	return scores
+}
+
+// updateLeaderboards adds the scores of a finished party game to the leaderboards of
+// its channel and team, and refreshes the pinned leaderboards affected.
+func (p *Plugin) updateLeaderboards(r *GameResult) {
+	if r.Type != GameTypeParty {
+		return
+	}
+
+	scores := getLeaderboardScores(r)
+	finishedAt := time.Unix(0, r.FinishedAt*int64(time.Millisecond))
+	scopes := map[LeaderboardScope]string{
+		LeaderboardScopeChannel: r.ChannelID,
+		LeaderboardScopeTeam:    r.TeamID,
+	}
+
+	for scope, scopeID := range scopes {
+		if scopeID == "" {
+			continue
+		}
+
+		for _, period := range leaderboardPeriods {
+			err := p.store.AddLeaderboardScores(scope, scopeID, getLeaderboardBucket(period, finishedAt), scores)
+			if err != nil {
+				p.mm.Log.Debug("Cannot update leaderboard", "scope", scope, "scopeID", scopeID, "period", period, "err", err)
+			}
+		}
+	}
+
+	p.refreshPinnedLeaderboards(func(pinned *PinnedLeaderboard) bool {
+		return pinned.ScopeID == scopes[pinned.Scope]
+	})
+}
+
+func (p *Plugin) getLeaderboard(scope LeaderboardScope, scopeID string, period LeaderboardPeriod) (*Leaderboard, error) {
+	return p.store.GetLeaderboard(scope, scopeID, getLeaderboardBucket(period, time.Now()))
+}
+
+func (p *Plugin) LeaderboardAttachment(l *Leaderboard, scope LeaderboardScope, scopeID string, period LeaderboardPeriod) []*model.SlackAttachment {
+	attachment := &model.SlackAttachment{
+		Title: "Quiz leaderboard: " + p.getLeaderboardScopeName(scope, scopeID) + " " + getLeaderboardPeriodName(period),
+	}
+
+	rows := l.rows()
+	if len(rows) == 0 {
+		attachment.Text = "No party games have finished yet."
+	}
+
+	if len(rows) > LeaderboardSize {
+		rows = rows[:LeaderboardSize]
+	}
+
+	for i, row := range rows {
+		name := row.userID
+		user, err := p.mm.User.Get(row.userID)
+		if err == nil {
+			name = "@" + user.Username
+		}
+
+		attachment.Text += fmt.Sprintf("%d. %s: %s points in %d games\n", i+1, name, formatScore(row.score), row.played)
+	}
+
+	attachment.Footer = "Last updated " + time.Now().UTC().Format("2006-01-02 15:04 MST")
+	return []*model.SlackAttachment{attachment}
+}
+
+func (p *Plugin) getLeaderboardScopeName(scope LeaderboardScope, scopeID string) string {
+	if scope == LeaderboardScopeTeam {
+		team, err := p.mm.Team.Get(scopeID)
+		if err != nil {
+			return "this team"
+		}
+		return team.DisplayName
+	}
+
+	channel, err := p.mm.Channel.Get(scopeID)
+	if err != nil || channel.Name == "" {
+		return "this channel"
+	}
+	return "~" + channel.Name
+}
+
+func getLeaderboardPeriodName(period LeaderboardPeriod) string {
+	switch period {
+	case LeaderboardPeriodWeek:
+		return "(this week)"
+	case LeaderboardPeriodMonth:
+		return "(this month)"
+	default:
+		return "(all time)"
+	}
+}
+
+// pinLeaderboard creates a pinned leaderboard post in the channel and registers it so
+// it is refreshed when games finish and periodically.
+func (p *Plugin) pinLeaderboard(channelID string, scope LeaderboardScope, scopeID string, period LeaderboardPeriod) error {
+	l, err := p.getLeaderboard(scope, scopeID, period)
+	if err != nil {
+		return err
+	}
+
+	post := &model.Post{
+		UserId:    p.BotUserID,
+		ChannelId: channelID,
+		IsPinned:  true,
+	}
+	model.ParseSlackAttachment(post, p.LeaderboardAttachment(l, scope, scopeID, period))
+	err = p.mm.Post.CreatePost(post)
+	if err != nil {
+		return err
+	}
+
+	return p.store.AddPinnedLeaderboard(&PinnedLeaderboard{
+		PostID:  post.Id,
+		Scope:   scope,
+		ScopeID: scopeID,
+		Period:  period,
+	})
+}
+
+// refreshPinnedLeaderboards updates the pinned leaderboard posts that match the filter.
+// Leaderboards whose post was deleted are forgotten.
+func (p *Plugin) refreshPinnedLeaderboards(filter func(pinned *PinnedLeaderboard) bool) {
+	pinnedLeaderboards, err := p.store.GetPinnedLeaderboards()
+	if err != nil {
+		p.mm.Log.Debug("Cannot get pinned leaderboards", "err", err)
+		return
+	}
+
+	for _, pinned := range pinnedLeaderboards {
+		if !filter(pinned) {
+			continue
+		}
+
+		post, err := p.mm.Post.GetPost(pinned.PostID)
+		if errors.Is(err, pluginapi.ErrNotFound) || (err == nil && post.DeleteAt != 0) {
+			err = p.store.RemovePinnedLeaderboard(pinned.PostID)
+			if err != nil {
+				p.mm.Log.Debug("Cannot remove pinned leaderboard", "postID", pinned.PostID, "err", err)
+			}
+			continue
+		}
+		if err != nil {
+			p.mm.Log.Debug("Cannot get pinned leaderboard post", "postID", pinned.PostID, "err", err)
+			continue
+		}
+
+		l, err := p.getLeaderboard(pinned.Scope, pinned.ScopeID, pinned.Period)
+		if err != nil {
+			p.mm.Log.Debug("Cannot get leaderboard", "postID", pinned.PostID, "err", err)
+			continue
+		}
+
+		model.ParseSlackAttachment(post, p.LeaderboardAttachment(l, pinned.Scope, pinned.ScopeID, pinned.Period))
+		err = p.mm.Post.UpdatePost(post)
+		if err != nil {
+			p.mm.Log.Debug("Cannot update pinned leaderboard", "postID", pinned.PostID, "err", err)
+		}
+	}
+}
+
+// initializeLeaderboards schedules the periodic refresh of the pinned leaderboards, so
+// they are reset when a new week or month starts even if no game finishes.
+func (p *Plugin) initializeLeaderboards() error {
+	job, err := cluster.Schedule(p.API, LeaderboardRefreshJobKey, cluster.MakeWaitForRoundedInterval(LeaderboardRefreshInterval), func() {
+		p.refreshPinnedLeaderboards(func(*PinnedLeaderboard) bool { return true })
+	})
+	if err != nil {
+		return err
+	}
+
+	p.leaderboardJob = job
+	return nil
+}
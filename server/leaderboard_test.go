@@ -0,0 +1,112 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetLeaderboardBucket(t *testing.T) {
+	sunday := time.Date(2021, time.January, 3, 23, 0, 0, 0, time.UTC)
+	monday := sunday.Add(2 * time.Hour)
+
+	assert.Equal(t, "2020-W53", getLeaderboardBucket(LeaderboardPeriodWeek, sunday))
+	assert.Equal(t, "2021-W01", getLeaderboardBucket(LeaderboardPeriodWeek, monday))
+	assert.Equal(t, "2021-01", getLeaderboardBucket(LeaderboardPeriodMonth, sunday))
+	assert.Equal(t, "all", getLeaderboardBucket(LeaderboardPeriodAll, sunday))
+}
+
+func TestLeaderboardScores(t *testing.T) {
+	s := newTestStore()
+
+	games := []*GameResult{
+		{Players: map[string]string{"alice": "aliceid", "bob": "bobid"}, Score: map[string]float64{"alice": 3}},
+		{Players: map[string]string{"bob": "bobid", "carol": "carolid"}, Score: map[string]float64{"bob": 2, "carol": 3}},
+	}
+	for _, g := range games {
+		require.NoError(t, s.AddLeaderboardScores(LeaderboardScopeChannel, "channel", "all", getLeaderboardScores(g)))
+	}
+
+	l, err := s.GetLeaderboard(LeaderboardScopeChannel, "channel", "all")
+	require.NoError(t, err)
+	assert.Equal(t, []leaderboardRow{
+		{userID: "aliceid", score: 3, played: 1},
+		{userID: "carolid", score: 3, played: 1},
+		{userID: "bobid", score: 2, played: 2},
+	}, l.rows())
+	assert.Equal(t, map[string]int{"aliceid": 1, "bobid": 2, "carolid": 1}, l.Played)
+
+	empty, err := s.GetLeaderboard(LeaderboardScopeTeam, "channel", "all")
+	require.NoError(t, err)
+	assert.Empty(t, empty.rows())
+}
+
+func TestUpdateLeaderboards(t *testing.T) {
+	p, api := newTestPlugin()
+	api.On("GetUser", "aliceid").Return(&model.User{Id: "aliceid", Username: "alice"}, nil)
+	api.On("GetUser", "bobid").Return(&model.User{Id: "bobid", Username: "bob"}, nil)
+	api.On("GetChannel", mock.Anything).Return(&model.Channel{Id: "channel", Name: "quizzes"}, nil)
+	api.On("GetTeam", mock.Anything).Return(&model.Team{Id: "team", DisplayName: "Team"}, nil)
+	for _, postID := range []string{"channelpost", "teampost", "otherpost"} {
+		api.On("GetPost", postID).Return(&model.Post{Id: postID}, nil)
+	}
+	api.On("GetPost", "deletedpost").Return(nil, &model.AppError{StatusCode: http.StatusNotFound})
+	updated := map[string]string{}
+	api.On("UpdatePost", mock.Anything).Return(&model.Post{}, nil).Run(func(args mock.Arguments) {
+		post := args.Get(0).(*model.Post)
+		updated[post.Id] = post.Attachments()[0].Text
+	})
+
+	for _, pinned := range []*PinnedLeaderboard{
+		{PostID: "channelpost", Scope: LeaderboardScopeChannel, ScopeID: "channel", Period: LeaderboardPeriodAll},
+		{PostID: "teampost", Scope: LeaderboardScopeTeam, ScopeID: "team", Period: LeaderboardPeriodWeek},
+		{PostID: "otherpost", Scope: LeaderboardScopeChannel, ScopeID: "other", Period: LeaderboardPeriodAll},
+		{PostID: "deletedpost", Scope: LeaderboardScopeChannel, ScopeID: "channel", Period: LeaderboardPeriodMonth},
+	} {
+		require.NoError(t, p.store.AddPinnedLeaderboard(pinned))
+	}
+
+	now := time.Now()
+	result := &GameResult{
+		Type:       GameTypeParty,
+		ChannelID:  "channel",
+		TeamID:     "team",
+		Players:    map[string]string{"alice": "aliceid", "bob": "bobid"},
+		Score:      map[string]float64{"alice": 3, "bob": 1},
+		FinishedAt: model.GetMillisForTime(now),
+	}
+	p.updateLeaderboards(result)
+
+	for _, period := range leaderboardPeriods {
+		for scope, scopeID := range map[LeaderboardScope]string{LeaderboardScopeChannel: "channel", LeaderboardScopeTeam: "team"} {
+			l, err := p.store.GetLeaderboard(scope, scopeID, getLeaderboardBucket(period, now))
+			require.NoError(t, err)
+			assert.Equal(t, map[string]float64{"aliceid": 3, "bobid": 1}, l.Scores, "%s %s", scope, period)
+			assert.Equal(t, map[string]int{"aliceid": 1, "bobid": 1}, l.Played, "%s %s", scope, period)
+		}
+	}
+
+	require.Len(t, updated, 2)
+	assert.Equal(t, "1. @alice: 3 points in 1 games\n2. @bob: 1 points in 1 games\n", updated["channelpost"])
+	assert.Contains(t, updated, "teampost")
+
+	pinnedLeaderboards, err := p.store.GetPinnedLeaderboards()
+	require.NoError(t, err)
+	require.Len(t, pinnedLeaderboards, 3)
+	for _, pinned := range pinnedLeaderboards {
+		assert.NotEqual(t, "deletedpost", pinned.PostID)
+	}
+
+	updated = map[string]string{}
+	result.Type = GameTypeSolo
+	p.updateLeaderboards(result)
+	assert.Empty(t, updated)
+	l, err := p.store.GetLeaderboard(LeaderboardScopeChannel, "channel", getLeaderboardBucket(LeaderboardPeriodAll, now))
+	require.NoError(t, err)
+	assert.Equal(t, 3.0, l.Scores["aliceid"])
+}
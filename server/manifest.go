@@ -35,7 +35,22 @@ const manifestStr = `
   "settings_schema": {
     "header": "",
     "footer": "",
-    "settings": []
+    "settings": [
+      {
+        "key": "TypoTolerance",
+        "display_name": "Typo tolerance (%):",
+        "type": "number",
+        "help_text": "Percentage of the characters of a free-text answer that can be mistyped and still be accepted in single answer quizzes. Set to 0 to only ignore case, accents and punctuation.",
+        "default": 20
+      },
+      {
+        "key": "NumericTolerance",
+        "display_name": "Numeric tolerance (%):",
+        "type": "number",
+        "help_text": "Percentage a numeric answer can differ from the correct one and still be accepted in single answer quizzes. Set to 0 to require the exact value.",
+        "default": 0
+      }
+    ]
   }
 }
 `
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
+)
+
+// answerArticles are the leading words ignored when comparing free-text answers.
+var answerArticles = []string{"the", "a", "an"}
+
+// AnswerMatcher decides whether a free-text answer matches the accepted answers of
+// a question.
+type AnswerMatcher struct {
+	// TypoTolerance is the number of edits allowed, as a percentage of the length of
+	// the accepted answer.
+	TypoTolerance int
+	// NumericTolerance is the difference allowed between numeric answers, as a
+	// percentage of the accepted value.
+	NumericTolerance int
+}
+
+func (p *Plugin) getAnswerMatcher() AnswerMatcher {
+	c := p.getConfiguration()
+	return AnswerMatcher{
+		TypoTolerance:    c.TypoTolerance,
+		NumericTolerance: c.NumericTolerance,
+	}
+}
+
+// Match returns whether the answer matches the correct answer or any of the
+// alternative answers of the question. Answers to numeric questions match when they
+// are within the tolerance of the question.
+func (m AnswerMatcher) Match(q *Question, answer string) bool {
+	if q.Type == QuizTypeNumeric {
+		return matchNumericAnswer(q, answer)
+	}
+
+	for _, accepted := range q.AcceptedAnswers() {
+		if m.MatchAnswer(accepted, answer) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// MatchAnswer compares an answer with an accepted answer. Both are compared as
+// numbers when they are numeric. Otherwise they are normalized and compared allowing
+// some typos. Accepted answers with details in parentheses, like "Paris (France)",
+// also match the answer without them. The answer itself is always compared whole,
+// so a list of guesses like "Paris, Rome, Berlin" does not match "Paris".
+func (m AnswerMatcher) MatchAnswer(accepted, answer string) bool {
+	acceptedNumber, acceptedIsNumber := parseAnswerNumber(accepted)
+	answerNumber, answerIsNumber := parseAnswerNumber(answer)
+	if acceptedIsNumber && answerIsNumber {
+		return m.matchNumber(acceptedNumber, answerNumber)
+	}
+
+	normalizedAccepted := normalizeAnswer(accepted)
+	if normalizedAccepted == "" {
+		return strings.TrimSpace(accepted) == strings.TrimSpace(answer)
+	}
+
+	normalizedAnswer := normalizeAnswer(answer)
+	if normalizedAnswer == "" {
+		return false
+	}
+
+	if m.matchText(normalizedAccepted, normalizedAnswer) {
+		return true
+	}
+
+	withoutDetails := normalizeAnswer(trimAnswerDetails(accepted))
+	return withoutDetails != "" && withoutDetails != normalizedAccepted && m.matchText(withoutDetails, normalizedAnswer)
+}
+
+// trimAnswerDetails removes a trailing parenthetical, like the one in
+// "Paris (France)".
+func trimAnswerDetails(s string) string {
+	s = strings.TrimSpace(s)
+	if !strings.HasSuffix(s, ")") {
+		return s
+	}
+
+	i := strings.LastIndex(s, "(")
+	if i <= 0 {
+		return s
+	}
+
+	return strings.TrimSpace(s[:i])
+}
+
+// matchNumericAnswer compares the answer to a numeric question, which can be followed
+// by the unit of the question, like "9.8 m/s2".
+func matchNumericAnswer(q *Question, answer string) bool {
+	correct, ok := parseAnswerNumber(q.CorrectAnswer)
+	if !ok {
+		return false
+	}
+
+	answer = strings.TrimSpace(answer)
+	if q.Unit != "" && len(answer) >= len(q.Unit) && strings.EqualFold(answer[len(answer)-len(q.Unit):], q.Unit) {
+		answer = answer[:len(answer)-len(q.Unit)]
+	}
+
+	n, ok := parseAnswerNumber(answer)
+	if !ok {
+		return false
+	}
+
+	// The epsilon keeps answers right at the tolerance, like 9.7 for 9.8 ± 0.1, from
+	// failing because of rounding errors.
+	return math.Abs(correct-n) <= q.Tolerance+1e-9
+}
+
+func (m AnswerMatcher) matchNumber(accepted, answer float64) bool {
+	tolerance := math.Abs(accepted) * float64(m.NumericTolerance) / 100
+	return math.Abs(accepted-answer) <= tolerance
+}
+
+func (m AnswerMatcher) matchText(accepted, answer string) bool {
+	if accepted == answer {
+		return true
+	}
+
+	if m.TypoTolerance <= 0 {
+		return false
+	}
+
+	allowed := len([]rune(accepted)) * m.TypoTolerance / 100
+	return levenshtein(accepted, answer) <= allowed
+}
+
+// normalizeAnswer folds the case, removes accents and punctuation, collapses the
+// whitespace and drops leading articles, so "  The Eiffel-Tower! " and "eiffel tower"
+// are equal. Apostrophes and dots are removed without splitting words, so "U.S.A."
+// and "USA" are equal too.
+func normalizeAnswer(s string) string {
+	s = cases.Fold().String(norm.NFKD.String(s))
+
+	b := strings.Builder{}
+	for _, r := range s {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+		case r == '\'' || r == '’' || r == '`' || r == '.':
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			b.WriteRune(' ')
+		default:
+			b.WriteRune(r)
+		}
+	}
+
+	words := strings.Fields(b.String())
+	if len(words) > 1 {
+		for _, article := range answerArticles {
+			if words[0] == article {
+				words = words[1:]
+				break
+			}
+		}
+	}
+
+	return strings.Join(words, " ")
+}
+
+// parseAnswerNumber parses answers like "42", "-3.5", "1,000" or "3,14". A single
+// comma not followed by exactly three digits is read as a decimal separator.
+func parseAnswerNumber(s string) (float64, bool) {
+	s = strings.ReplaceAll(strings.TrimSpace(s), " ", "")
+	if s == "" {
+		return 0, false
+	}
+
+	if strings.Count(s, ",") == 1 && !strings.Contains(s, ".") && len(s)-strings.Index(s, ",") != 4 {
+		s = strings.Replace(s, ",", ".", 1)
+	}
+	s = strings.ReplaceAll(s, ",", "")
+
+	n, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsNaN(n) || math.IsInf(n, 0) {
+		return 0, false
+	}
+
+	return n, true
+}
+
+// levenshtein returns the number of single rune insertions, deletions or
+// substitutions needed to turn a into b.
+func levenshtein(a, b string) int {
+	ra := []rune(a)
+	rb := []rune(b)
+
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = minInt(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+
+	return previous[len(rb)]
+}
+
+func minInt(values ...int) int {
+	out := values[0]
+	for _, v := range values[1:] {
+		if v < out {
+			out = v
+		}
+	}
+
+	return out
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeAnswer(t *testing.T) {
+	for _, tc := range []struct {
+		in       string
+		expected string
+	}{
+		{"Paris", "paris"},
+		{"  PARIS  ", "paris"},
+		{"Paris.", "paris"},
+		{"U.S.A.", "usa"},
+		{"St. Louis", "st louis"},
+		{"¡Hola!", "hola"},
+		{"São Paulo", "sao paulo"},
+		{"Zürich", "zurich"},
+		{"Straße", "strasse"},
+		{"ＡＢＣ", "abc"},
+		{"é", "e"},
+		{"é", "e"},
+		{"The Eiffel-Tower", "eiffel tower"},
+		{"a  tale\tof\ntwo cities", "tale of two cities"},
+		{"The", "the"},
+		{"Theatre", "theatre"},
+		{"O'Neill", "oneill"},
+		{"rock ’n’ roll", "rock n roll"},
+		{"C++", "c"},
+		{"50%", "50"},
+		{"...", ""},
+		{"", ""},
+		{"東京", "東京"},
+	} {
+		assert.Equal(t, tc.expected, normalizeAnswer(tc.in), tc.in)
+	}
+}
+
+func TestParseAnswerNumber(t *testing.T) {
+	for _, tc := range []struct {
+		in       string
+		expected float64
+		ok       bool
+	}{
+		{"42", 42, true},
+		{" -3.5 ", -3.5, true},
+		{"+7", 7, true},
+		{"1,000", 1000, true},
+		{"1,000,000", 1000000, true},
+		{"1 000", 1000, true},
+		{"3,14", 3.14, true},
+		{"1,000.5", 1000.5, true},
+		{"1e3", 1000, true},
+		{"", 0, false},
+		{"forty two", 0, false},
+		{"42 km", 0, false},
+		{"NaN", 0, false},
+		{"Inf", 0, false},
+	} {
+		n, ok := parseAnswerNumber(tc.in)
+		assert.Equal(t, tc.ok, ok, tc.in)
+		assert.InDelta(t, tc.expected, n, 1e-9, tc.in)
+	}
+}
+
+func TestLevenshtein(t *testing.T) {
+	for _, tc := range []struct {
+		a, b     string
+		expected int
+	}{
+		{"", "", 0},
+		{"abc", "", 3},
+		{"", "abc", 3},
+		{"paris", "paris", 0},
+		{"paris", "pari", 1},
+		{"paris", "parris", 1},
+		{"paris", "parys", 1},
+		{"kitten", "sitting", 3},
+		{"flaw", "lawn", 2},
+		{"zurich", "zürich", 1},
+		{"東京", "京都", 2},
+	} {
+		assert.Equal(t, tc.expected, levenshtein(tc.a, tc.b), tc.a+"/"+tc.b)
+		assert.Equal(t, tc.expected, levenshtein(tc.b, tc.a), tc.b+"/"+tc.a)
+	}
+}
+
+func TestMatchAnswer(t *testing.T) {
+	defaultMatcher := AnswerMatcher{TypoTolerance: 20}
+	strictMatcher := AnswerMatcher{}
+	numericMatcher := AnswerMatcher{TypoTolerance: 20, NumericTolerance: 5}
+
+	for _, tc := range []struct {
+		name     string
+		matcher  AnswerMatcher
+		accepted string
+		answer   string
+		expected bool
+	}{
+		{"exact", defaultMatcher, "Paris", "Paris", true},
+		{"case", defaultMatcher, "Paris", "paris", true},
+		{"punctuation", defaultMatcher, "Paris", "Paris.", true},
+		{"details in the accepted answer", defaultMatcher, "Paris (France)", "Paris", true},
+		{"accepted answer with its details", defaultMatcher, "Paris (France)", "paris france", true},
+		{"details in the answer", defaultMatcher, "Paris", "Paris (France)", false},
+		{"list of guesses", defaultMatcher, "Paris", "Paris, Rome, Berlin", false},
+		{"guesses after a semicolon", defaultMatcher, "Paris", "paris; lyon", false},
+		{"guesses in parentheses", defaultMatcher, "Paris", "Paris (or maybe Lyon)", false},
+		{"only parentheses in the accepted answer", defaultMatcher, "(none)", "none", true},
+		{"accepted answers are not split", defaultMatcher, "Washington, D.C.", "Washington DC", true},
+		{"accepted answers need the details", defaultMatcher, "Washington, D.C.", "Washington", false},
+		{"accents", defaultMatcher, "Bogotá", "bogota", true},
+		{"accents in the answer", defaultMatcher, "Bogota", "Bogotá", true},
+		{"articles", defaultMatcher, "The Beatles", "beatles", true},
+		{"whitespace", defaultMatcher, "New York", "  new   york ", true},
+		{"hyphens", defaultMatcher, "Jean-Paul Sartre", "jean paul sartre", true},
+		{"one typo in a long word", defaultMatcher, "Copenhagen", "Copenhagan", true},
+		{"two typos in a long word", defaultMatcher, "Copenhagen", "Kopenhagan", true},
+		{"too many typos", defaultMatcher, "Copenhagen", "Kobenhaven", false},
+		{"no typos allowed in short words", defaultMatcher, "Rome", "Roma", false},
+		{"one typo in a five letter word", defaultMatcher, "Paris", "Parys", true},
+		{"different answer", defaultMatcher, "Paris", "London", false},
+		{"strict matcher still normalizes", strictMatcher, "Paris", "PARIS!", true},
+		{"strict matcher rejects typos", strictMatcher, "Copenhagen", "Copenhagan", false},
+		{"prefix is not enough", defaultMatcher, "Paris", "Par", false},
+		{"answer with only punctuation", defaultMatcher, "Paris", "...", false},
+		{"accepted with only punctuation", defaultMatcher, "?!", "?!", true},
+		{"accepted with only punctuation does not match others", defaultMatcher, "?!", "!?", false},
+		{"integers", defaultMatcher, "42", "42", true},
+		{"number formats", defaultMatcher, "42", "42.0", true},
+		{"thousands separator", defaultMatcher, "1000", "1,000", true},
+		{"decimal comma", defaultMatcher, "3.14", "3,14", true},
+		{"numbers do not allow typos", defaultMatcher, "1234", "1235", false},
+		{"numbers without tolerance", defaultMatcher, "100", "101", false},
+		{"numbers within tolerance", numericMatcher, "100", "104", true},
+		{"numbers at the tolerance", numericMatcher, "100", "95", true},
+		{"numbers outside tolerance", numericMatcher, "100", "106", false},
+		{"negative numbers within tolerance", numericMatcher, "-100", "-96", true},
+		{"zero needs the exact value", numericMatcher, "0", "0.1", false},
+		{"number against text", defaultMatcher, "42", "forty two", false},
+		{"text against number", defaultMatcher, "forty two", "42", false},
+		{"numbers with units are text", defaultMatcher, "42 km", "42km", true},
+		{"numbers with different units", defaultMatcher, "42 km", "42 miles", false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, tc.matcher.MatchAnswer(tc.accepted, tc.answer))
+		})
+	}
+}
+
+func TestMatchQuestion(t *testing.T) {
+	m := AnswerMatcher{TypoTolerance: 20}
+	q := &Question{
+		CorrectAnswer:      "United States of America",
+		AlternativeAnswers: []string{"USA", "US", "United States"},
+	}
+
+	assert.True(t, m.Match(q, "united states of america"))
+	assert.True(t, m.Match(q, "U.S.A."))
+	assert.True(t, m.Match(q, "us"))
+	assert.True(t, m.Match(q, "The United Sates"))
+	assert.False(t, m.Match(q, "UK"))
+	assert.False(t, m.Match(q, "America"))
+
+	assert.Equal(t, []string{"Paris"}, (&Question{CorrectAnswer: "Paris"}).AcceptedAnswers())
+}
+
+func TestMatchNumericQuestion(t *testing.T) {
+	m := AnswerMatcher{TypoTolerance: 20}
+	q := &Question{Type: QuizTypeNumeric, CorrectAnswer: "9.8", Tolerance: 0.1, Unit: "m/s2"}
+
+	assert.True(t, m.Match(q, "9.8"))
+	assert.True(t, m.Match(q, "9,75"))
+	assert.True(t, m.Match(q, "9.9 m/s2"))
+	assert.True(t, m.Match(q, "9.7M/S2"))
+	assert.False(t, m.Match(q, "10"))
+	assert.False(t, m.Match(q, "9.8 km"))
+	assert.False(t, m.Match(q, "m/s2"))
+
+	exact := &Question{Type: QuizTypeNumeric, CorrectAnswer: "1,000"}
+	assert.True(t, m.Match(exact, "1000"))
+	assert.False(t, m.Match(exact, "1001"))
+}
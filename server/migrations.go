@@ -0,0 +1,224 @@
+package main
+
+import (
+	"github.com/mattermost/mattermost-plugin-api/cluster"
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/pkg/errors"
+)
+
+// Quizzes, games and courses are stored as JSON, so changing their shape can break the
+// data already stored. The version of the shape is stored with the data, and every
+// change that needs the stored data to be upgraded adds a migration to the list below.
+// The migrations run in order when the plugin is activated, from the stored version to
+// the last one.
+
+// migration upgrades the stored data from the previous version. Each function gets an
+// entity and returns whether it changed it. Entities already in the new shape must be
+// left as they are, so a migration can run again if the plugin stops before the new
+// version is stored.
+type migration struct {
+	version     int
+	description string
+	quiz        func(p *Plugin, q *Quiz) bool
+	game        func(p *Plugin, g *Game) bool
+	course      func(p *Plugin, c *Course) bool
+}
+
+var migrations = []migration{
+	{
+		version:     1,
+		description: "store the quiz type in the questions stored before questions had their own type",
+		quiz:        func(p *Plugin, q *Quiz) bool { return q.MigrateQuestionTypes() },
+		game:        func(p *Plugin, g *Game) bool { return g.MigrateQuestionTypes() },
+	},
+	{
+		version:     2,
+		description: "record the correct options of the games started before multi-select questions",
+		game:        migrateGameCorrectAnswers,
+	},
+	{
+		version:     3,
+		description: "record the creator of the quizzes and courses created before ownership",
+		quiz:        migrateQuizCreator,
+		course:      migrateCourseCreator,
+	},
+}
+
+// errMigrationUnchanged stops the update of a game the migration did not change.
+var errMigrationUnchanged = errors.New("unchanged by the migration")
+
+// currentSchemaVersion returns the version of the shape of the data stored by this
+// version of the plugin.
+func currentSchemaVersion() int {
+	return migrations[len(migrations)-1].version
+}
+
+// migrate runs the pending migrations, holding a cluster lock so only one server of
+// the cluster migrates the data.
+func (p *Plugin) migrate() error {
+	mutex, err := cluster.NewMutex(p.API, MigrationsMutexKey)
+	if err != nil {
+		return err
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	return p.runMigrations()
+}
+
+// runMigrations upgrades the stored data from its version to the current version, one
+// migration at a time. The version is stored after each migration, so a failed
+// migration is retried from the last one that finished.
+func (p *Plugin) runMigrations() error {
+	version, err := p.store.GetSchemaVersion()
+	if err != nil {
+		return err
+	}
+
+	if version > currentSchemaVersion() {
+		p.mm.Log.Warn("The stored data is newer than this version of the plugin", "version", version, "supported", currentSchemaVersion())
+		return nil
+	}
+
+	for _, m := range migrations {
+		if m.version <= version {
+			continue
+		}
+
+		p.mm.Log.Info("Migrating the stored data", "version", m.version, "migration", m.description)
+		err = p.runMigration(m)
+		if err != nil {
+			return errors.Wrapf(err, "failed to migrate the stored data to version %d", m.version)
+		}
+
+		err = p.store.SetSchemaVersion(m.version)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (p *Plugin) runMigration(m migration) error {
+	if m.quiz != nil {
+		ids, err := p.store.ListQuizIDs()
+		if err != nil {
+			return err
+		}
+
+		for _, id := range ids {
+			q, err := p.store.GetQuiz(id)
+			if isNotFound(err) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+
+			if !m.quiz(p, q) {
+				continue
+			}
+
+			err = p.store.StoreQuiz(q)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if m.game != nil {
+		ids, err := p.store.ListGameIDs()
+		if err != nil {
+			return err
+		}
+
+		for _, id := range ids {
+			_, err = p.store.UpdateGame(id, func(g *Game) error {
+				if !m.game(p, g) {
+					return errMigrationUnchanged
+				}
+				return nil
+			})
+			if err != nil && err != errMigrationUnchanged {
+				return err
+			}
+		}
+	}
+
+	if m.course != nil {
+		ids, err := p.store.ListCourseIDs()
+		if err != nil {
+			return err
+		}
+
+		for _, id := range ids {
+			c, err := p.store.GetCourse(id)
+			if isNotFound(err) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+
+			if !m.course(p, c) {
+				continue
+			}
+
+			err = p.store.StoreCourse(c)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// migrateGameCorrectAnswers fills the correct options of the current question of games
+// started before multi-select questions, which only stored one correct option.
+func migrateGameCorrectAnswers(p *Plugin, g *Game) bool {
+	if len(g.CurrentAnswers) == 0 || len(g.CorrectAnswers) != 0 {
+		return false
+	}
+
+	g.CorrectAnswers = []int{g.CorrectAnswer}
+	return true
+}
+
+func migrateQuizCreator(p *Plugin, q *Quiz) bool {
+	if q.CreatorID != "" {
+		return false
+	}
+
+	q.CreatorID = p.getDraftRecipient(q.ID)
+	return q.CreatorID != ""
+}
+
+func migrateCourseCreator(p *Plugin, c *Course) bool {
+	if c.CreatorID != "" {
+		return false
+	}
+
+	c.CreatorID = p.getDraftRecipient(c.ID)
+	return c.CreatorID != ""
+}
+
+// getDraftRecipient returns the user the bot sent the draft post of a quiz or course to.
+// Quizzes and courses take the ID of their draft post, which the bot sends to their
+// creator.
+func (p *Plugin) getDraftRecipient(postID string) string {
+	post, err := p.mm.Post.GetPost(postID)
+	if err != nil {
+		p.mm.Log.Debug("Cannot get the draft post", "id", postID, "err", err)
+		return ""
+	}
+
+	channel, err := p.mm.Channel.Get(post.ChannelId)
+	if err != nil || channel.Type != model.CHANNEL_DIRECT {
+		return ""
+	}
+
+	return channel.GetOtherUserIdForDM(p.BotUserID)
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Fixtures of the shapes the plugin stored before the schema was versioned.
+const (
+	// Quizzes and courses had no creator, and questions took the type of the quiz.
+	fixtureBaselineQuiz = `{"ID":"basequiz","Name":"Capitals","Type":"multiple-choice","Questions":[` +
+		`{"ID":"q1","Question":"Capital of France?","CorrectAnswer":"Paris","IncorrectAnswers":["Rome","Berlin","Madrid"]}]}`
+	fixtureBaselineCourse = `{"ID":"basecourse","Name":"Geography","Description":"Learn geography","Lessons":[` +
+		`{"Name":"Europe","Introduction":"Countries of Europe","Resources":[{"Name":"Map","Type":"link","Content":"https://example.com"}]}]}`
+	// Games only recorded one correct option.
+	fixtureBaselineGame = `{"Quiz":{"ID":"basequiz","Name":"Capitals","Type":"multiple-choice"},"GM":"gm","Score":{},` +
+		`"RemainingQuestions":[{"ID":"q1","Question":"Capital of France?","CorrectAnswer":"Paris","IncorrectAnswers":["Rome","Berlin","Madrid"]}],` +
+		`"RootPostID":"basegame","CurrentPostID":"current","Type":"party","ScoringType":"all","AlreadyAnswered":{},"NQuestions":1,` +
+		`"CurrentAnswers":["Rome","Paris","Berlin","Madrid"],"CorrectAnswer":1,"RightPlayers":null}`
+	// Quizzes had a creator, and questions still took the type of the quiz.
+	fixtureOwnedQuiz = `{"ID":"ownedquiz","Name":"Rivers","Type":"single-answer","Questions":[` +
+		`{"ID":"q1","Question":"Longest river?","CorrectAnswer":"Nile","IncorrectAnswers":null}],"CreatorID":"owner","Editors":["editor"]}`
+)
+
+func newMigrationsTestPlugin() (*Plugin, *memoryAPI) {
+	p, api := newTestPlugin()
+	p.BotUserID = "bot"
+	api.kv[getQuizKey("basequiz")] = []byte(fixtureBaselineQuiz)
+	api.kv[getQuizKey("ownedquiz")] = []byte(fixtureOwnedQuiz)
+	api.kv[getCourseKey("basecourse")] = []byte(fixtureBaselineCourse)
+	api.kv[getGameKey("basegame")] = []byte(fixtureBaselineGame)
+
+	api.On("GetPost", "basequiz").Return(&model.Post{Id: "basequiz", ChannelId: "dm"}, nil)
+	api.On("GetPost", "basecourse").Return(&model.Post{Id: "basecourse", ChannelId: "dm"}, nil)
+	api.On("GetChannel", "dm").Return(&model.Channel{Id: "dm", Type: model.CHANNEL_DIRECT, Name: model.GetDMNameFromIds("bot", "author")}, nil)
+
+	return p, api
+}
+
+func TestRunMigrations(t *testing.T) {
+	p, api := newMigrationsTestPlugin()
+
+	require.NoError(t, p.runMigrations())
+
+	version, err := p.store.GetSchemaVersion()
+	require.NoError(t, err)
+	assert.Equal(t, currentSchemaVersion(), version)
+
+	var q *Quiz
+	require.NoError(t, p.mm.KV.Get(getQuizKey("basequiz"), &q))
+	assert.Equal(t, "author", q.CreatorID)
+	assert.Equal(t, QuizTypeMultipleChoice, q.Questions[0].Type)
+
+	q = nil
+	require.NoError(t, p.mm.KV.Get(getQuizKey("ownedquiz"), &q))
+	assert.Equal(t, "owner", q.CreatorID)
+	assert.Equal(t, []string{"editor"}, q.Editors)
+	assert.Equal(t, QuizTypeSingleAnswer, q.Questions[0].Type)
+
+	var c *Course
+	require.NoError(t, p.mm.KV.Get(getCourseKey("basecourse"), &c))
+	assert.Equal(t, "author", c.CreatorID)
+	assert.Equal(t, "Europe", c.Lessons[0].Name)
+
+	var g *Game
+	require.NoError(t, p.mm.KV.Get(getGameKey("basegame"), &g))
+	assert.Equal(t, QuizTypeMultipleChoice, g.RemainingQuestions[0].Type)
+	assert.Equal(t, []int{1}, g.CorrectAnswers)
+	assert.Equal(t, 1, g.CorrectAnswer)
+
+	api.AssertNumberOfCalls(t, "GetPost", 2)
+}
+
+func TestRunMigrationsIsIdempotent(t *testing.T) {
+	p, api := newMigrationsTestPlugin()
+
+	require.NoError(t, p.runMigrations())
+	migrated := map[string][]byte{}
+	for key, value := range api.kv {
+		migrated[key] = value
+	}
+
+	// Running every migration again, as if the plugin stopped before storing the
+	// version, leaves the data as it was.
+	require.NoError(t, p.store.SetSchemaVersion(0))
+	require.NoError(t, p.runMigrations())
+	delete(migrated, KVSchemaVersion)
+	for key, value := range migrated {
+		assert.Equal(t, string(value), string(api.kv[key]), key)
+	}
+
+	// The creators found the first time are kept, so the draft posts are not read again.
+	api.AssertNumberOfCalls(t, "GetPost", 2)
+}
+
+func TestRunMigrationsFromStoredVersion(t *testing.T) {
+	p, api := newMigrationsTestPlugin()
+
+	// Only the migrations after the stored version run.
+	require.NoError(t, p.store.SetSchemaVersion(2))
+	require.NoError(t, p.runMigrations())
+
+	var g *Game
+	require.NoError(t, p.mm.KV.Get(getGameKey("basegame"), &g))
+	assert.Empty(t, g.CorrectAnswers)
+	assert.Equal(t, fixtureBaselineGame, string(api.kv[getGameKey("basegame")]))
+
+	var q *Quiz
+	require.NoError(t, p.mm.KV.Get(getQuizKey("basequiz"), &q))
+	assert.Equal(t, "author", q.CreatorID)
+}
+
+func TestRunMigrationsKeepsNewerData(t *testing.T) {
+	p, api := newMigrationsTestPlugin()
+
+	require.NoError(t, p.store.SetSchemaVersion(currentSchemaVersion()+1))
+	require.NoError(t, p.runMigrations())
+
+	assert.Equal(t, fixtureBaselineQuiz, string(api.kv[getQuizKey("basequiz")]))
+}
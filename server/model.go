@@ -1,10 +1,108 @@
 package main
 
+import (
+	"fmt"
+	"math"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrQuestionPassed  = errors.New("this question has been already passed")
+	ErrAlreadyAnswered = errors.New("you already tried to answer this question")
+	ErrTimeIsUp        = errors.New("the time to answer this question is over")
+	ErrNotQuizEditor   = errors.New("you do not have permission to edit this quiz")
+	ErrNotCourseEditor = errors.New("you do not have permission to edit this course")
+)
+
 type QuizType string
 
 const (
 	QuizTypeSingleAnswer   QuizType = "single-answer"
 	QuizTypeMultipleChoice QuizType = "multiple-choice"
+	QuizTypeMultiSelect    QuizType = "multi-select"
+	QuizTypeNumeric        QuizType = "numeric"
+	QuizTypeOrdering       QuizType = "ordering"
+)
+
+// IsValid returns whether questions of the type can be created.
+func (t QuizType) IsValid() bool {
+	switch t {
+	case QuizTypeSingleAnswer, QuizTypeMultipleChoice, QuizTypeMultiSelect, QuizTypeNumeric, QuizTypeOrdering:
+		return true
+	}
+
+	return false
+}
+
+// HasOptions returns whether the questions of the type are answered by picking
+// among the correct and incorrect answers.
+func (t QuizType) HasOptions() bool {
+	return t == QuizTypeMultipleChoice || t == QuizTypeMultiSelect
+}
+
+// HasTextAnswer returns whether the questions of the type are answered by writing
+// the answer.
+func (t QuizType) HasTextAnswer() bool {
+	return t == QuizTypeSingleAnswer || t == QuizTypeNumeric
+}
+
+// QuizDifficulty is an optional hint of how hard a quiz is, to help choosing one.
+type QuizDifficulty string
+
+const (
+	QuizDifficultyEasy   QuizDifficulty = "easy"
+	QuizDifficultyMedium QuizDifficulty = "medium"
+	QuizDifficultyHard   QuizDifficulty = "hard"
+)
+
+// IsValid returns whether the difficulty is known. Quizzes without difficulty have
+// an empty one, which is also valid.
+func (d QuizDifficulty) IsValid() bool {
+	switch d {
+	case "", QuizDifficultyEasy, QuizDifficultyMedium, QuizDifficultyHard:
+		return true
+	}
+
+	return false
+}
+
+// Visibility decides who can use a saved quiz or course in its scope, besides its
+// editors.
+type Visibility string
+
+const (
+	// VisibilityPrivate quizzes can only be used by their editors.
+	VisibilityPrivate Visibility = "private"
+	// VisibilityShared quizzes can be used by the users and groups they are shared with.
+	VisibilityShared Visibility = "shared"
+	// VisibilityPublic quizzes can be used by everyone in their scope. Quizzes saved
+	// before visibilities existed have an empty visibility, which is public too.
+	VisibilityPublic Visibility = "public"
+)
+
+// IsValid returns whether the visibility is known.
+func (v Visibility) IsValid() bool {
+	switch v {
+	case "", VisibilityPrivate, VisibilityShared, VisibilityPublic:
+		return true
+	}
+
+	return false
+}
+
+// MultiSelectScoring decides how multi-select answers that are only partially right
+// are scored.
+type MultiSelectScoring string
+
+const (
+	MultiSelectScoringAllOrNothing MultiSelectScoring = "all-or-nothing"
+	MultiSelectScoringPartial      MultiSelectScoring = "partial"
 )
 
 type GameType string
@@ -19,6 +117,7 @@ type ScoringType string
 const (
 	ScoringTypeAll   ScoringType = "all"
 	ScoringTypeFirst ScoringType = "first"
+	ScoringTypeSpeed ScoringType = "speed"
 )
 
 type ResourceType string
@@ -31,23 +130,197 @@ const (
 )
 
 type Quiz struct {
-	ID        string
-	Name      string
-	Type      QuizType
-	Questions []Question
+	ID   string
+	Name string
+	// Type is the default type of new questions. Each question has its own type.
+	Type               QuizType
+	Questions          []Question
+	CreatorID          string
+	Editors            []string
+	MultiSelectScoring MultiSelectScoring `json:",omitempty"`
+	// Category, Tags and Difficulty help finding the quiz among many others.
+	Category   string         `json:",omitempty"`
+	Tags       []string       `json:",omitempty"`
+	Difficulty QuizDifficulty `json:",omitempty"`
+	// TeamID is the team whose members can use the saved quiz, and ChannelIDs restrict
+	// it to the members of some of its channels. See isInScope.
+	TeamID     string   `json:",omitempty"`
+	ChannelIDs []string `json:",omitempty"`
+	// SharedWith and SharedGroups are the IDs of the users and groups that can use
+	// the quiz when it is shared.
+	Visibility   Visibility `json:",omitempty"`
+	SharedWith   []string   `json:",omitempty"`
+	SharedGroups []string   `json:",omitempty"`
+	// Revision is the number of the last revision of the quiz. Games keep a copy of
+	// the quiz, so they keep the revision they started with.
+	Revision int `json:",omitempty"`
+}
+
+func (q *Quiz) IsEditor(userID string) bool {
+	return isEditor(userID, q.CreatorID, q.Editors)
+}
+
+// ImageFileIDs returns the images of the questions of the quiz.
+func (q *Quiz) ImageFileIDs() []string {
+	ids := []string{}
+	for _, question := range q.Questions {
+		if question.ImageFileID != "" {
+			ids = append(ids, question.ImageFileID)
+		}
+	}
+
+	return ids
 }
 
 type Question struct {
 	ID               string
+	Type             QuizType
 	Question         string
 	CorrectAnswer    string
 	IncorrectAnswers []string
+	// AlternativeAnswers are also accepted as correct in single answer questions.
+	AlternativeAnswers []string `json:",omitempty"`
+	// ExtraCorrectAnswers are the correct options besides CorrectAnswer in
+	// multi-select questions, and the items after CorrectAnswer, in order, in
+	// ordering questions.
+	ExtraCorrectAnswers []string `json:",omitempty"`
+	// Tolerance is how far answers to numeric questions can be from CorrectAnswer.
+	Tolerance float64 `json:",omitempty"`
+	// Unit is shown with numeric questions, and players can write it after the number.
+	Unit string `json:",omitempty"`
+	// ImageFileID is the ID of the file of the image shown with the question.
+	ImageFileID string `json:",omitempty"`
+	// Explanation is shown with the solution of the question.
+	Explanation string `json:",omitempty"`
+	// ReferenceLink is a link to learn more about the answer, shown with the explanation.
+	ReferenceLink string `json:",omitempty"`
+}
+
+// MigrateQuestionTypes gives the quiz type to the questions stored before questions
+// had their own type. It returns whether any question changed.
+func (q *Quiz) MigrateQuestionTypes() bool {
+	return migrateQuestionTypes(q.Questions, q.Type)
+}
+
+func migrateQuestionTypes(questions []Question, quizType QuizType) bool {
+	changed := false
+	for i := range questions {
+		if questions[i].Type == "" && quizType != "" {
+			questions[i].Type = quizType
+			changed = true
+		}
+	}
+
+	return changed
+}
+
+// CorrectOptions returns the correct answer followed by the extra correct answers.
+// For ordering questions, these are the items in the correct order.
+func (q *Question) CorrectOptions() []string {
+	return append([]string{q.CorrectAnswer}, q.ExtraCorrectAnswers...)
+}
+
+// AcceptedAnswers returns the correct answer followed by the alternative answers.
+func (q *Question) AcceptedAnswers() []string {
+	return append([]string{q.CorrectAnswer}, q.AlternativeAnswers...)
+}
+
+// Validate returns why the question cannot be asked, if it cannot. Multiple choice
+// questions need between MinIncorrectAnswers and MaxIncorrectAnswers incorrect
+// answers, all different from the correct one.
+func (q *Question) Validate() error {
+	quizType := q.Type
+	if !quizType.IsValid() {
+		return errors.Errorf("unsupported question type %q", quizType)
+	}
+
+	if q.Question == "" {
+		return errors.New("the question is empty")
+	}
+
+	if q.CorrectAnswer == "" {
+		return errors.New("the correct answer is empty")
+	}
+
+	if err := validateReferenceLink(q.ReferenceLink); err != nil {
+		return err
+	}
+
+	if quizType == QuizTypeSingleAnswer {
+		if len(q.IncorrectAnswers) > 0 {
+			return errors.New("single answer questions cannot have incorrect answers")
+		}
+		return nil
+	}
+
+	if quizType == QuizTypeNumeric {
+		if _, ok := parseAnswerNumber(q.CorrectAnswer); !ok {
+			return errors.Errorf("the correct answer %q is not a number", q.CorrectAnswer)
+		}
+		if q.Tolerance < 0 {
+			return errors.New("the tolerance cannot be negative")
+		}
+		if len(q.IncorrectAnswers) > 0 {
+			return errors.New("numeric questions cannot have incorrect answers")
+		}
+		return nil
+	}
+
+	if quizType == QuizTypeOrdering {
+		if len(q.IncorrectAnswers) > 0 {
+			return errors.New("ordering questions cannot have incorrect answers")
+		}
+		if items := len(q.CorrectOptions()); items < MinOrderingItems || items > MaxOrderingItems {
+			return errors.Errorf("ordering questions need between %d and %d items, found %d", MinOrderingItems, MaxOrderingItems, items)
+		}
+	}
+
+	if quizType == QuizTypeMultipleChoice {
+		if len(q.ExtraCorrectAnswers) > 0 {
+			return errors.New("multiple choice questions cannot have more than one correct answer")
+		}
+		if len(q.IncorrectAnswers) < MinIncorrectAnswers || len(q.IncorrectAnswers) > MaxIncorrectAnswers {
+			return errors.Errorf("multiple choice questions need between %d and %d incorrect answers, found %d", MinIncorrectAnswers, MaxIncorrectAnswers, len(q.IncorrectAnswers))
+		}
+	}
+
+	if quizType == QuizTypeMultiSelect {
+		others := len(q.ExtraCorrectAnswers) + len(q.IncorrectAnswers)
+		if others < MinIncorrectAnswers || others > MaxIncorrectAnswers {
+			return errors.Errorf("multi-select questions need between %d and %d options besides the correct answer, found %d", MinIncorrectAnswers, MaxIncorrectAnswers, others)
+		}
+	}
+
+	seen := map[string]bool{}
+	for _, answer := range append(q.CorrectOptions(), q.IncorrectAnswers...) {
+		if seen[strings.ToLower(answer)] {
+			return errors.Errorf("the answer %q is repeated", answer)
+		}
+		seen[strings.ToLower(answer)] = true
+	}
+
+	return nil
+}
+
+// validateReferenceLink checks that a reference link, if any, is an absolute http or
+// https URL, so it renders as a link in the posts.
+func validateReferenceLink(link string) error {
+	if link == "" {
+		return nil
+	}
+
+	u, err := url.Parse(link)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.Errorf("the reference link %q is not a valid http or https URL", link)
+	}
+
+	return nil
 }
 
 type Game struct {
 	Quiz               Quiz
 	GM                 string
-	Score              map[string]int
+	Score              map[string]float64
 	RemainingQuestions []Question
 	RootPostID         string
 	CurrentPostID      string
@@ -57,17 +330,372 @@ type Game struct {
 	NQuestions         int
 	CurrentAnswers     []string
 	CorrectAnswer      int
+	CorrectAnswers     []int
 	RightPlayers       []string
+	CourseID           string
+	CourseResource     string
+	ChannelID          string
+	TeamID             string
+	TimeLimit          int
+	QuestionDeadline   int64
+	QuestionStart      int64
+	AnswerTimes        map[string]int64
+	TimerJobKey        string
+	Answers            []GameAnswer
+	PassedQuestions    []Question
+	Players            map[string]string
+	StartedAt          int64
 }
 
-func (q Quiz) ValidQuestions() int {
-	if q.Type == QuizTypeSingleAnswer {
-		return len(q.Questions)
+// GameAnswer is an answer given by a player to a question of a game.
+type GameAnswer struct {
+	QuestionID   string
+	Username     string
+	Answer       string
+	Correct      bool
+	AnsweredAt   int64
+	ResponseTime int64
+}
+
+// QuizRevisionInfo describes a revision of a quiz in the history of the quiz.
+type QuizRevisionInfo struct {
+	Number    int
+	AuthorID  string
+	CreatedAt int64
+	// ImageFileIDs are the images of the questions of the revision, kept in the index so
+	// they can be checked without reading every revision.
+	ImageFileIDs []string `json:",omitempty"`
+}
+
+// QuizRevision is a copy of a quiz as it was after one of its changes.
+type QuizRevision struct {
+	QuizRevisionInfo
+	Quiz Quiz
+}
+
+// GameResult holds the results of a finished game, so they can be exported once the
+// game itself has been deleted.
+type GameResult struct {
+	ID           string
+	QuizID       string
+	QuizRevision int `json:",omitempty"`
+	QuizName     string
+	GM           string
+	Type         GameType
+	ScoringType  ScoringType
+	ChannelID    string
+	TeamID       string
+	Questions    []Question
+	Score        map[string]float64
+	Answers      []GameAnswer
+	Players      map[string]string
+	StartedAt    int64
+	FinishedAt   int64
+}
+
+func NewGameResult(g *Game) *GameResult {
+	return &GameResult{
+		ID:           g.RootPostID,
+		QuizID:       g.Quiz.ID,
+		QuizRevision: g.Quiz.Revision,
+		QuizName:     g.Quiz.Name,
+		GM:           g.GM,
+		Type:         g.Type,
+		ScoringType:  g.ScoringType,
+		ChannelID:    g.ChannelID,
+		TeamID:       g.TeamID,
+		Questions:    g.PassedQuestions,
+		Score:        g.Score,
+		Answers:      g.Answers,
+		Players:      g.Players,
+		StartedAt:    g.StartedAt,
+		FinishedAt:   model.GetMillis(),
 	}
+}
+
+// PlayerIDs returns the IDs of everyone who answered at least one question.
+func (r *GameResult) PlayerIDs() []string {
+	out := []string{}
+	for _, username := range r.Usernames() {
+		if id, ok := r.Players[username]; ok {
+			out = append(out, id)
+		}
+	}
+
+	return out
+}
+
+// Usernames returns the usernames of everyone who answered at least one question,
+// sorted alphabetically.
+func (r *GameResult) Usernames() []string {
+	seen := map[string]bool{}
+	out := []string{}
+	for _, answer := range r.Answers {
+		if !seen[answer.Username] {
+			seen[answer.Username] = true
+			out = append(out, answer.Username)
+		}
+	}
+
+	sort.Strings(out)
+	return out
+}
+
+func (g *Game) CurrentQuestion() *Question {
+	if len(g.RemainingQuestions) == 0 {
+		return nil
+	}
+
+	return &g.RemainingQuestions[0]
+}
+
+// startQuestionClock records when the current question was opened, and sets its deadline
+// if the game has a time limit.
+func (g *Game) startQuestionClock() {
+	now := time.Now()
+	g.QuestionStart = model.GetMillisForTime(now)
+	g.AnswerTimes = map[string]int64{}
+
+	if g.TimeLimit <= 0 {
+		g.QuestionDeadline = 0
+		return
+	}
+
+	g.QuestionDeadline = model.GetMillisForTime(now.Add(time.Duration(g.TimeLimit) * time.Second))
+}
 
+// TimeLeft returns how much time is left to answer the current question, or zero if
+// the game has no time limit.
+func (g *Game) TimeLeft() time.Duration {
+	if g.QuestionDeadline == 0 {
+		return 0
+	}
+
+	left := time.Until(time.Unix(0, g.QuestionDeadline*int64(time.Millisecond)))
+	if left < 0 {
+		return 0
+	}
+
+	return left
+}
+
+// AddPlayer remembers the user ID of a player, so the game history can be kept per user
+// even if the username changes.
+func (g *Game) AddPlayer(userID, username string) {
+	if g.Players == nil {
+		g.Players = map[string]string{}
+	}
+
+	g.Players[username] = userID
+}
+
+// shuffleAnswers shows the options of the current question in a random order. For
+// ordering questions, CorrectAnswers holds the positions of the items in the
+// correct order.
+func (g *Game) shuffleAnswers() {
+	question := g.RemainingQuestions[0]
+	switch {
+	case question.Type.HasOptions():
+		g.CurrentAnswers, g.CorrectAnswers = getRandomOptions(question)
+	case question.Type == QuizTypeOrdering:
+		g.CurrentAnswers, g.CorrectAnswers = getRandomOrder(question)
+	default:
+		g.CurrentAnswers = nil
+		g.CorrectAnswers = nil
+		g.CorrectAnswer = 0
+		return
+	}
+
+	g.CorrectAnswer = g.CorrectAnswers[0]
+}
+
+// MigrateQuestionTypes gives the quiz type to the questions of games started before
+// questions had their own type. It returns whether any question changed.
+func (g *Game) MigrateQuestionTypes() bool {
+	changed := g.Quiz.MigrateQuestionTypes()
+	changed = migrateQuestionTypes(g.RemainingQuestions, g.Quiz.Type) || changed
+	changed = migrateQuestionTypes(g.PassedQuestions, g.Quiz.Type) || changed
+	return changed
+}
+
+// RecordAnswer marks the user as having answered the current question and
+// updates the score depending on the game scoring type.
+func (g *Game) RecordAnswer(questionID, username, answer string, correct bool) error {
+	credit := 0.0
+	if correct {
+		credit = 1
+	}
+
+	return g.recordAnswer(questionID, username, answer, credit)
+}
+
+// RecordSelectedAnswers records the options of CurrentAnswers selected by the user
+// for the current multi-select question, and returns the credit given, from 0 to 1.
+// With partial scoring every correct option selected adds to the credit and every
+// incorrect one takes from it. Otherwise only selecting exactly the correct options
+// counts.
+func (g *Game) RecordSelectedAnswers(questionID, username string, selected []int) (float64, error) {
+	correctOptions := map[int]bool{}
+	for _, i := range g.CorrectAnswers {
+		correctOptions[i] = true
+	}
+
+	hits := 0
+	misses := 0
+	answers := []string{}
+	for _, i := range selected {
+		if i < 0 || i >= len(g.CurrentAnswers) {
+			continue
+		}
+
+		answers = append(answers, g.CurrentAnswers[i])
+		if correctOptions[i] {
+			hits++
+		} else {
+			misses++
+		}
+	}
+
+	credit := 0.0
+	switch {
+	case hits == len(correctOptions) && misses == 0:
+		credit = 1
+	case g.Quiz.MultiSelectScoring == MultiSelectScoringPartial && hits > misses:
+		credit = float64(hits-misses) / float64(len(correctOptions))
+	}
+	err := g.recordAnswer(questionID, username, strings.Join(answers, ", "), credit)
+	if err != nil {
+		return 0, err
+	}
+
+	return credit, nil
+}
+
+// RecordOrderedAnswer records the order of the items of CurrentAnswers given by the
+// user for the current ordering question, and returns whether it is the correct one.
+func (g *Game) RecordOrderedAnswer(questionID, username string, order []int) (bool, error) {
+	correct := len(order) == len(g.CorrectAnswers)
+	items := []string{}
+	for i, position := range order {
+		if position < 0 || position >= len(g.CurrentAnswers) {
+			correct = false
+			continue
+		}
+
+		items = append(items, g.CurrentAnswers[position])
+		if i >= len(g.CorrectAnswers) || g.CorrectAnswers[i] != position {
+			correct = false
+		}
+	}
+
+	err := g.RecordAnswer(questionID, username, strings.Join(items, ", "), correct)
+	if err != nil {
+		return false, err
+	}
+
+	return correct, nil
+}
+
+// recordAnswer records an answer that deserves the given credit, from 0 to 1, and adds
+// that share of the points of the question to the score of the user, so partially
+// correct answers get a fraction of a point. Only answers with full credit are
+// correct, and only those get the bonus of ScoringTypeFirst.
+func (g *Game) recordAnswer(questionID, username, answer string, credit float64) error {
+	correct := credit >= 1
+	current := g.CurrentQuestion()
+	if current == nil || current.ID != questionID {
+		return ErrQuestionPassed
+	}
+
+	// The timer job reveals the solution shortly after the deadline, and answers sent
+	// in between must not count.
+	answeredAt := model.GetMillis()
+	if g.QuestionDeadline != 0 && answeredAt > g.QuestionDeadline {
+		return ErrTimeIsUp
+	}
+
+	if g.AlreadyAnswered == nil {
+		g.AlreadyAnswered = map[string]bool{}
+	}
+
+	if g.AlreadyAnswered[username] {
+		return ErrAlreadyAnswered
+	}
+
+	g.AlreadyAnswered[username] = true
+
+	if g.AnswerTimes == nil {
+		g.AnswerTimes = map[string]int64{}
+	}
+
+	g.AnswerTimes[username] = answeredAt
+	g.Answers = append(g.Answers, GameAnswer{
+		QuestionID:   questionID,
+		Username:     username,
+		Answer:       answer,
+		Correct:      correct,
+		AnsweredAt:   answeredAt,
+		ResponseTime: answeredAt - g.QuestionStart,
+	})
+
+	if credit <= 0 {
+		return nil
+	}
+
+	// A question is worth one point, plus the bonus of the scoring type, so scores stay
+	// comparable with the number of questions of the game.
+	points := credit
+	switch g.ScoringType {
+	case ScoringTypeFirst:
+		if correct && len(g.RightPlayers) == 0 {
+			points += 2
+		}
+	case ScoringTypeSpeed:
+		points = credit * float64(g.speedPoints(answeredAt))
+	}
+
+	if g.Score == nil {
+		g.Score = map[string]float64{}
+	}
+	g.Score[username] += points
+
+	if correct {
+		g.RightPlayers = append(g.RightPlayers, username)
+	}
+	return nil
+}
+
+// speedPoints returns the points for a correct answer given at answeredAt. Answering
+// right when the question opens gives SpeedScoringMaxPoints, and the points decrease
+// linearly down to one point at the end of the time limit, or of SpeedScoringWindow if
+// the game has no time limit.
+func (g *Game) speedPoints(answeredAt int64) int {
+	window := SpeedScoringWindow
+	if g.TimeLimit > 0 {
+		window = time.Duration(g.TimeLimit) * time.Second
+	}
+
+	elapsed := time.Duration(answeredAt-g.QuestionStart) * time.Millisecond
+	if g.QuestionStart == 0 || elapsed < 0 {
+		elapsed = 0
+	}
+
+	if elapsed >= window {
+		return 1
+	}
+
+	points := int(math.Round(float64(SpeedScoringMaxPoints) * float64(window-elapsed) / float64(window)))
+	if points < 1 {
+		return 1
+	}
+
+	return points
+}
+
+func (q Quiz) ValidQuestions() int {
 	n := 0
-	for _, question := range q.Questions {
-		if len(question.IncorrectAnswers) >= IncorrectAnswerCount {
+	for i := range q.Questions {
+		if q.Questions[i].Validate() == nil {
 			n++
 		}
 	}
@@ -80,6 +708,19 @@ type Course struct {
 	Name        string
 	Description string
 	Lessons     []*Lesson
+	CreatorID   string
+	Editors     []string
+	// TeamID and ChannelIDs are the scope of the saved course, and Visibility,
+	// SharedWith and SharedGroups who can take it in that scope, like in quizzes.
+	TeamID       string     `json:",omitempty"`
+	ChannelIDs   []string   `json:",omitempty"`
+	Visibility   Visibility `json:",omitempty"`
+	SharedWith   []string   `json:",omitempty"`
+	SharedGroups []string   `json:",omitempty"`
+}
+
+func (c *Course) IsEditor(userID string) bool {
+	return isEditor(userID, c.CreatorID, c.Editors)
 }
 
 type Lesson struct {
@@ -94,3 +735,108 @@ type Resource struct {
 	Content string
 	Pretext string
 }
+
+// CourseProgress holds how far a user has gone through a course.
+type CourseProgress struct {
+	CourseID         string
+	CurrentLesson    int
+	CompletedLessons map[int]bool
+	ViewedResources  map[string]bool
+	QuizScores       map[string]CourseQuizScore
+	Completed        bool
+}
+
+// CourseQuizScore holds the best result a user got on a quiz resource of a course.
+type CourseQuizScore struct {
+	Score float64
+	Total int
+}
+
+func (s CourseQuizScore) Passed() bool {
+	return s.Total > 0 && s.Score*100 >= float64(s.Total*CourseQuizPassPercentage)
+}
+
+func NewCourseProgress(courseID string) *CourseProgress {
+	return &CourseProgress{
+		CourseID:         courseID,
+		CompletedLessons: map[int]bool{},
+		ViewedResources:  map[string]bool{},
+		QuizScores:       map[string]CourseQuizScore{},
+	}
+}
+
+// VisitLesson records that the user reached the lesson at index. Every lesson before it
+// is considered done, since lessons can only be reached in order.
+func (cp *CourseProgress) VisitLesson(c *Course, index int) {
+	cp.CurrentLesson = index
+	for i := 0; i < index; i++ {
+		cp.CompletedLessons[i] = true
+	}
+
+	for i, resource := range c.Lessons[index].Resources {
+		if resource.Type != string(ResourceTypeQuiz) {
+			cp.ViewedResources[getCourseResourceKey(index, i)] = true
+		}
+	}
+}
+
+// RecordQuizScore keeps the best score the user got on the quiz resource.
+func (cp *CourseProgress) RecordQuizScore(resourceKey string, score float64, total int) {
+	best, ok := cp.QuizScores[resourceKey]
+	if ok && best.Score*float64(total) >= score*float64(best.Total) {
+		return
+	}
+
+	cp.QuizScores[resourceKey] = CourseQuizScore{Score: score, Total: total}
+}
+
+// PendingQuizzes returns the names of the quiz resources the user has not passed yet.
+func (cp *CourseProgress) PendingQuizzes(c *Course) []string {
+	out := []string{}
+	for i, lesson := range c.Lessons {
+		for j, resource := range lesson.Resources {
+			if resource.Type != string(ResourceTypeQuiz) {
+				continue
+			}
+
+			if !cp.QuizScores[getCourseResourceKey(i, j)].Passed() {
+				out = append(out, resource.Name)
+			}
+		}
+	}
+
+	return out
+}
+
+// IsComplete reports whether every lesson is done and every quiz resource passed.
+func (cp *CourseProgress) IsComplete(c *Course) bool {
+	for i := range c.Lessons {
+		if !cp.CompletedLessons[i] {
+			return false
+		}
+	}
+
+	return len(cp.PendingQuizzes(c)) == 0
+}
+
+func getCourseResourceKey(lessonIndex, resourceIndex int) string {
+	return fmt.Sprintf("%d-%d", lessonIndex, resourceIndex)
+}
+
+func isEditor(userID, creatorID string, editors []string) bool {
+	if userID == "" {
+		return false
+	}
+
+	if userID == creatorID {
+		return true
+	}
+
+	for _, id := range editors {
+		if id == userID {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,262 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordAnswerScoring(t *testing.T) {
+	setupGame := func(scoring ScoringType) *Game {
+		g := &Game{
+			ScoringType:        scoring,
+			RemainingQuestions: []Question{{ID: "q1"}},
+		}
+		g.startQuestionClock()
+		return g
+	}
+
+	t.Run("first rewards the first correct answer", func(t *testing.T) {
+		g := setupGame(ScoringTypeFirst)
+		require.NoError(t, g.RecordAnswer("q1", "wrong", "", false))
+		require.NoError(t, g.RecordAnswer("q1", "right", "", true))
+		require.NoError(t, g.RecordAnswer("q1", "late", "", true))
+
+		assert.Equal(t, map[string]float64{"right": 3, "late": 1}, g.Score)
+		assert.Equal(t, []string{"right", "late"}, g.RightPlayers)
+		assert.Len(t, g.AnswerTimes, 3)
+	})
+
+	t.Run("speed rewards faster answers", func(t *testing.T) {
+		g := setupGame(ScoringTypeSpeed)
+		require.NoError(t, g.RecordAnswer("q1", "fast", "", true))
+
+		g.QuestionStart = model.GetMillisForTime(time.Now().Add(-SpeedScoringWindow / 2))
+		require.NoError(t, g.RecordAnswer("q1", "medium", "", true))
+
+		g.QuestionStart = model.GetMillisForTime(time.Now().Add(-2 * SpeedScoringWindow))
+		require.NoError(t, g.RecordAnswer("q1", "slow", "", true))
+		require.NoError(t, g.RecordAnswer("q1", "wrong", "", false))
+
+		assert.Equal(t, float64(SpeedScoringMaxPoints), g.Score["fast"])
+		assert.Equal(t, float64(SpeedScoringMaxPoints/2), g.Score["medium"])
+		assert.Equal(t, 1.0, g.Score["slow"])
+		assert.NotContains(t, g.Score, "wrong")
+	})
+
+	t.Run("speed uses the time limit as window", func(t *testing.T) {
+		g := setupGame(ScoringTypeSpeed)
+		g.TimeLimit = 10
+		g.QuestionStart = model.GetMillisForTime(time.Now().Add(-5 * time.Second))
+		require.NoError(t, g.RecordAnswer("q1", "player", "", true))
+
+		assert.Equal(t, float64(SpeedScoringMaxPoints/2), g.Score["player"])
+	})
+}
+
+func TestQuestionValidate(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		quizType QuizType
+		question Question
+		valid    bool
+	}{
+		{"single answer", QuizTypeSingleAnswer, Question{Question: "q", CorrectAnswer: "a"}, true},
+		{"single answer with incorrect answers", QuizTypeSingleAnswer, Question{Question: "q", CorrectAnswer: "a", IncorrectAnswers: []string{"b"}}, false},
+		{"empty question", QuizTypeSingleAnswer, Question{CorrectAnswer: "a"}, false},
+		{"empty answer", QuizTypeMultipleChoice, Question{Question: "q", IncorrectAnswers: []string{"b"}}, false},
+		{"true/false", QuizTypeMultipleChoice, Question{Question: "q", CorrectAnswer: "True", IncorrectAnswers: []string{"False"}}, true},
+		{"no incorrect answers", QuizTypeMultipleChoice, Question{Question: "q", CorrectAnswer: "a"}, false},
+		{"maximum incorrect answers", QuizTypeMultipleChoice, Question{Question: "q", CorrectAnswer: "a", IncorrectAnswers: []string{"b", "c", "d", "e", "f", "g", "h"}}, true},
+		{"too many incorrect answers", QuizTypeMultipleChoice, Question{Question: "q", CorrectAnswer: "a", IncorrectAnswers: []string{"b", "c", "d", "e", "f", "g", "h", "i"}}, false},
+		{"incorrect answer equal to the correct one", QuizTypeMultipleChoice, Question{Question: "q", CorrectAnswer: "a", IncorrectAnswers: []string{"b", "A"}}, false},
+		{"repeated incorrect answers", QuizTypeMultipleChoice, Question{Question: "q", CorrectAnswer: "a", IncorrectAnswers: []string{"b", "b"}}, false},
+		{"multiple choice with several correct answers", QuizTypeMultipleChoice, Question{Question: "q", CorrectAnswer: "a", ExtraCorrectAnswers: []string{"b"}, IncorrectAnswers: []string{"c"}}, false},
+		{"multi-select", QuizTypeMultiSelect, Question{Question: "q", CorrectAnswer: "a", ExtraCorrectAnswers: []string{"b"}, IncorrectAnswers: []string{"c"}}, true},
+		{"multi-select with one correct answer", QuizTypeMultiSelect, Question{Question: "q", CorrectAnswer: "a", IncorrectAnswers: []string{"c"}}, true},
+		{"multi-select with only correct answers", QuizTypeMultiSelect, Question{Question: "q", CorrectAnswer: "a", ExtraCorrectAnswers: []string{"b"}}, true},
+		{"multi-select with a single option", QuizTypeMultiSelect, Question{Question: "q", CorrectAnswer: "a"}, false},
+		{"multi-select with too many options", QuizTypeMultiSelect, Question{Question: "q", CorrectAnswer: "a", ExtraCorrectAnswers: []string{"b", "c", "d", "e"}, IncorrectAnswers: []string{"f", "g", "h", "i"}}, false},
+		{"multi-select with repeated answers", QuizTypeMultiSelect, Question{Question: "q", CorrectAnswer: "a", ExtraCorrectAnswers: []string{"b"}, IncorrectAnswers: []string{"B"}}, false},
+		{"numeric", QuizTypeNumeric, Question{Question: "q", CorrectAnswer: "3.14", Tolerance: 0.01, Unit: "m"}, true},
+		{"numeric with text answer", QuizTypeNumeric, Question{Question: "q", CorrectAnswer: "pi"}, false},
+		{"numeric with negative tolerance", QuizTypeNumeric, Question{Question: "q", CorrectAnswer: "3", Tolerance: -1}, false},
+		{"ordering", QuizTypeOrdering, Question{Question: "q", CorrectAnswer: "a", ExtraCorrectAnswers: []string{"b", "c"}}, true},
+		{"ordering with one item", QuizTypeOrdering, Question{Question: "q", CorrectAnswer: "a"}, false},
+		{"ordering with too many items", QuizTypeOrdering, Question{Question: "q", CorrectAnswer: "a", ExtraCorrectAnswers: []string{"b", "c", "d", "e", "f", "g", "h", "i"}}, false},
+		{"ordering with repeated items", QuizTypeOrdering, Question{Question: "q", CorrectAnswer: "a", ExtraCorrectAnswers: []string{"b", "A"}}, false},
+		{"ordering with incorrect answers", QuizTypeOrdering, Question{Question: "q", CorrectAnswer: "a", ExtraCorrectAnswers: []string{"b"}, IncorrectAnswers: []string{"c"}}, false},
+		{"with explanation and reference link", QuizTypeSingleAnswer, Question{Question: "q", CorrectAnswer: "a", Explanation: "because", ReferenceLink: "https://example.com/a"}, true},
+		{"reference link without scheme", QuizTypeSingleAnswer, Question{Question: "q", CorrectAnswer: "a", ReferenceLink: "example.com/a"}, false},
+		{"reference link with other scheme", QuizTypeNumeric, Question{Question: "q", CorrectAnswer: "3", ReferenceLink: "javascript:alert(1)"}, false},
+		{"unknown type", QuizType("essay"), Question{Question: "q", CorrectAnswer: "a"}, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.question.Type = tc.quizType
+			assert.Equal(t, tc.valid, tc.question.Validate() == nil)
+		})
+	}
+}
+
+func TestGetRandomOptions(t *testing.T) {
+	q := Question{CorrectAnswer: "a", ExtraCorrectAnswers: []string{"b", "c"}, IncorrectAnswers: []string{"d", "e"}}
+	for i := 0; i < 20; i++ {
+		options, correct := getRandomOptions(q)
+		require.Len(t, options, 5)
+		require.Len(t, correct, 3)
+		assert.IsIncreasing(t, correct)
+
+		correctOptions := []string{}
+		for _, i := range correct {
+			correctOptions = append(correctOptions, options[i])
+		}
+		assert.ElementsMatch(t, []string{"a", "b", "c"}, correctOptions)
+	}
+}
+
+func TestGetRandomOrder(t *testing.T) {
+	q := Question{Type: QuizTypeOrdering, CorrectAnswer: "a", ExtraCorrectAnswers: []string{"b", "c", "d"}}
+	for i := 0; i < 20; i++ {
+		items, order := getRandomOrder(q)
+		require.Len(t, items, 4)
+		require.Len(t, order, 4)
+
+		ordered := []string{}
+		for _, i := range order {
+			ordered = append(ordered, items[i])
+		}
+		assert.Equal(t, []string{"a", "b", "c", "d"}, ordered)
+	}
+}
+
+func TestRecordOrderedAnswer(t *testing.T) {
+	g := &Game{
+		ScoringType:        ScoringTypeAll,
+		RemainingQuestions: []Question{{ID: "q1", Type: QuizTypeOrdering, CorrectAnswer: "a", ExtraCorrectAnswers: []string{"b", "c"}}},
+	}
+	g.shuffleAnswers()
+	g.startQuestionClock()
+
+	reversed := []int{g.CorrectAnswers[2], g.CorrectAnswers[1], g.CorrectAnswers[0]}
+	for _, tc := range []struct {
+		username string
+		order    []int
+		correct  bool
+	}{
+		{"right", g.CorrectAnswers, true},
+		{"reversed", reversed, false},
+		{"incomplete", g.CorrectAnswers[:2], false},
+		{"out of range", []int{g.CorrectAnswers[0], g.CorrectAnswers[1], 7}, false},
+	} {
+		correct, err := g.RecordOrderedAnswer("q1", tc.username, tc.order)
+		require.NoError(t, err)
+		assert.Equal(t, tc.correct, correct, tc.username)
+	}
+
+	assert.Equal(t, map[string]float64{"right": 1}, g.Score)
+	assert.Equal(t, "a, b, c", g.Answers[0].Answer)
+	assert.Equal(t, "c, b, a", g.Answers[1].Answer)
+}
+
+func TestRecordSelectedAnswers(t *testing.T) {
+	setupGame := func(scoring MultiSelectScoring, scoringType ScoringType) *Game {
+		g := &Game{
+			Quiz:               Quiz{Type: QuizTypeMultiSelect, MultiSelectScoring: scoring},
+			ScoringType:        scoringType,
+			RemainingQuestions: []Question{{ID: "q1"}},
+			CurrentAnswers:     []string{"a", "b", "c", "d"},
+			CorrectAnswers:     []int{0, 2},
+		}
+		g.startQuestionClock()
+		return g
+	}
+
+	t.Run("all or nothing", func(t *testing.T) {
+		g := setupGame(MultiSelectScoringAllOrNothing, ScoringTypeAll)
+		for username, selected := range map[string][]int{
+			"exact":   {0, 2},
+			"partial": {0},
+			"extra":   {0, 1, 2},
+			"wrong":   {1, 3},
+		} {
+			credit, err := g.RecordSelectedAnswers("q1", username, selected)
+			require.NoError(t, err)
+			if username == "exact" {
+				assert.Equal(t, 1.0, credit)
+			} else {
+				assert.Equal(t, 0.0, credit, username)
+			}
+		}
+
+		assert.Equal(t, map[string]float64{"exact": 1}, g.Score)
+		assert.Equal(t, []string{"exact"}, g.RightPlayers)
+	})
+
+	t.Run("partial credit", func(t *testing.T) {
+		g := setupGame(MultiSelectScoringPartial, ScoringTypeAll)
+		for _, tc := range []struct {
+			username string
+			selected []int
+			credit   float64
+		}{
+			{"exact", []int{0, 2}, 1},
+			{"half", []int{2}, 0.5},
+			{"extra", []int{0, 1, 2}, 0.5},
+			{"even", []int{0, 1}, 0},
+			{"wrong", []int{1, 3}, 0},
+			{"out of range", []int{7}, 0},
+		} {
+			credit, err := g.RecordSelectedAnswers("q1", tc.username, tc.selected)
+			require.NoError(t, err)
+			assert.Equal(t, tc.credit, credit, tc.username)
+		}
+
+		assert.Equal(t, map[string]float64{"exact": 1, "half": 0.5, "extra": 0.5}, g.Score)
+		assert.Equal(t, []string{"exact"}, g.RightPlayers)
+		require.Len(t, g.Answers, 6)
+		assert.Equal(t, "a, b, c", g.Answers[2].Answer)
+		assert.False(t, g.Answers[1].Correct)
+	})
+
+	t.Run("partial credit with first scoring", func(t *testing.T) {
+		g := setupGame(MultiSelectScoringPartial, ScoringTypeFirst)
+		_, err := g.RecordSelectedAnswers("q1", "half", []int{0})
+		require.NoError(t, err)
+		_, err = g.RecordSelectedAnswers("q1", "exact", []int{0, 2})
+		require.NoError(t, err)
+
+		assert.Equal(t, map[string]float64{"half": 0.5, "exact": 3}, g.Score)
+	})
+
+	t.Run("partial credit with speed scoring", func(t *testing.T) {
+		g := setupGame(MultiSelectScoringPartial, ScoringTypeSpeed)
+		_, err := g.RecordSelectedAnswers("q1", "half", []int{0})
+		require.NoError(t, err)
+
+		assert.Equal(t, float64(SpeedScoringMaxPoints)/2, g.Score["half"])
+	})
+
+	t.Run("answering twice", func(t *testing.T) {
+		g := setupGame(MultiSelectScoringPartial, ScoringTypeAll)
+		_, err := g.RecordSelectedAnswers("q1", "user", []int{0})
+		require.NoError(t, err)
+		_, err = g.RecordSelectedAnswers("q1", "user", []int{0, 2})
+		assert.Equal(t, ErrAlreadyAnswered, err)
+		assert.Equal(t, 0.5, g.Score["user"])
+	})
+}
+
+func TestQuizMigrateQuestionTypes(t *testing.T) {
+	q := &Quiz{
+		Type:      QuizTypeMultipleChoice,
+		Questions: []Question{{ID: "q1"}, {ID: "q2", Type: QuizTypeSingleAnswer}},
+	}
+
+	assert.True(t, q.MigrateQuestionTypes())
+	assert.Equal(t, QuizTypeMultipleChoice, q.Questions[0].Type)
+	assert.Equal(t, QuizTypeSingleAnswer, q.Questions[1].Type)
+	assert.False(t, q.MigrateQuestionTypes())
+}
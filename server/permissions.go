@@ -0,0 +1,147 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/pkg/errors"
+)
+
+func (p *Plugin) isSystemAdmin(userID string) bool {
+	return p.mm.User.HasPermissionTo(userID, model.PERMISSION_MANAGE_SYSTEM)
+}
+
+func (p *Plugin) canEditQuiz(q *Quiz, userID string) bool {
+	return q.IsEditor(userID) || p.isSystemAdmin(userID)
+}
+
+// canManageQuizEditors reports whether the user can change the list of co-editors.
+// Co-editors can edit the quiz but not share it with other people.
+func (p *Plugin) canManageQuizEditors(q *Quiz, userID string) bool {
+	return q.CreatorID == userID || p.isSystemAdmin(userID)
+}
+
+// canStartQuiz reports whether the user can start a game with the quiz in the channel
+// of the team. Saved quizzes can be started by anyone in their scope that they are
+// visible to, and only in their channels if they are scoped to some, while drafts can
+// only be started by their editors. An empty channel skips the channel check, for uses
+// that happen outside of a channel like serving the images of the quiz.
+func (p *Plugin) canStartQuiz(q *Quiz, userID, teamID, channelID string) bool {
+	if p.canEditQuiz(q, userID) {
+		return true
+	}
+
+	if channelID != "" && !isScopedChannel(q.ChannelIDs, channelID) {
+		return false
+	}
+
+	return p.isAvailableQuiz(q.ID) && canSeeQuiz(q, p.newUserMemberships(userID), teamID)
+}
+
+func (p *Plugin) isAvailableQuiz(id string) bool {
+	available, err := p.store.IsAvailableQuiz(id)
+	if err != nil {
+		p.mm.Log.Debug("Cannot check if the quiz is available", "id", id, "err", err)
+	}
+
+	return available
+}
+
+func (p *Plugin) canEditCourse(c *Course, userID string) bool {
+	return c.IsEditor(userID) || p.isSystemAdmin(userID)
+}
+
+// canExportGameResult reports whether the user can export the results of a finished
+// game. The GM and the editors of the quiz can export them.
+func (p *Plugin) canExportGameResult(r *GameResult, userID string) bool {
+	if r.GM == userID || p.isSystemAdmin(userID) {
+		return true
+	}
+
+	q, err := p.store.GetQuiz(r.QuizID)
+	if err != nil {
+		return false
+	}
+
+	return q.IsEditor(userID)
+}
+
+// getVisibleGameResults returns the results of the games of the player that the user can
+// see. Users see all their games and admins all the games of everyone. Other users only
+// see the games played in channels they can read.
+func (p *Plugin) getVisibleGameResults(results []*GameResult, playerID, userID string) []*GameResult {
+	if playerID == userID || p.isSystemAdmin(userID) {
+		return results
+	}
+
+	canRead := map[string]bool{}
+	out := []*GameResult{}
+	for _, r := range results {
+		readable, ok := canRead[r.ChannelID]
+		if !ok {
+			readable = r.ChannelID != "" && p.mm.User.HasPermissionToChannel(userID, r.ChannelID, model.PERMISSION_READ_CHANNEL)
+			canRead[r.ChannelID] = readable
+		}
+
+		if readable {
+			out = append(out, r)
+		}
+	}
+
+	return out
+}
+
+// canPinLeaderboard reports whether the user can pin a leaderboard in the channel. Only
+// the people that can manage the channel can pin posts that the bot keeps updating.
+func (p *Plugin) canPinLeaderboard(userID, channelID string) bool {
+	return p.mm.User.HasPermissionToChannel(userID, channelID, model.PERMISSION_MANAGE_PUBLIC_CHANNEL_PROPERTIES) ||
+		p.mm.User.HasPermissionToChannel(userID, channelID, model.PERMISSION_MANAGE_PRIVATE_CHANNEL_PROPERTIES)
+}
+
+// canTakeCourse reports whether the user can follow the course in the team. Saved
+// courses can be taken by anyone in their scope that they are visible to, while drafts
+// can only be taken by their editors.
+func (p *Plugin) canTakeCourse(c *Course, userID, teamID string) bool {
+	return p.canEditCourse(c, userID) || (p.isAvailableCourse(c.ID) && canSeeCourse(c, p.newUserMemberships(userID), teamID))
+}
+
+func (p *Plugin) isAvailableCourse(id string) bool {
+	available, err := p.store.IsAvailableCourse(id)
+	if err != nil {
+		p.mm.Log.Debug("Cannot check if the course is available", "id", id, "err", err)
+	}
+
+	return available
+}
+
+func (p *Plugin) canManageCourseEditors(c *Course, userID string) bool {
+	return c.CreatorID == userID || p.isSystemAdmin(userID)
+}
+
+func (p *Plugin) getUserIDsFromUsernames(text string) ([]string, error) {
+	ids := []string{}
+	for _, username := range strings.Fields(text) {
+		username = strings.TrimPrefix(username, "@")
+		user, err := p.mm.User.GetByUsername(username)
+		if err != nil {
+			return nil, errors.Errorf("user @%s not found", username)
+		}
+		ids = append(ids, user.Id)
+	}
+
+	return ids, nil
+}
+
+func (p *Plugin) getUsernamesFromUserIDs(ids []string) string {
+	usernames := []string{}
+	for _, id := range ids {
+		user, err := p.mm.User.Get(id)
+		if err != nil {
+			p.mm.Log.Debug("Cannot get user", "id", id, "err", err)
+			continue
+		}
+		usernames = append(usernames, "@"+user.Username)
+	}
+
+	return strings.Join(usernames, " ")
+}
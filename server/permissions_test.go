@@ -0,0 +1,246 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testQuizID   = "quizid"
+	testCourseID = "courseid"
+)
+
+func newPermissionsTestPlugin(t *testing.T) (*Plugin, *memoryAPI) {
+	p, api := newTestPlugin()
+	api.On("HasPermissionTo", "admin", model.PERMISSION_MANAGE_SYSTEM).Return(true)
+	api.On("HasPermissionTo", mock.Anything, model.PERMISSION_MANAGE_SYSTEM).Return(false)
+	api.On("GetPost", mock.Anything).Return(&model.Post{}, nil)
+	api.On("UpdatePost", mock.Anything).Return(&model.Post{}, nil)
+	api.On("OpenInteractiveDialog", mock.Anything).Return(nil)
+	p.initializeAPI()
+
+	err := p.store.StoreQuiz(&Quiz{
+		ID:        testQuizID,
+		Name:      "Quiz",
+		Type:      QuizTypeMultipleChoice,
+		CreatorID: "creator",
+		Editors:   []string{"editor"},
+		Questions: []Question{{
+			ID:               "questionid",
+			Type:             QuizTypeMultipleChoice,
+			Question:         "Question",
+			CorrectAnswer:    "Answer",
+			IncorrectAnswers: []string{"a", "b", "c"},
+		}},
+	})
+	require.NoError(t, err)
+
+	err = p.store.StoreCourse(&Course{
+		ID:          testCourseID,
+		Name:        "Course",
+		Description: "Description",
+		CreatorID:   "creator",
+		Editors:     []string{"editor"},
+		Lessons: []*Lesson{{
+			Name:         "Lesson",
+			Introduction: "Introduction",
+			Resources:    []*Resource{{Name: "Resource", Type: string(ResourceTypeText), Content: "Content"}},
+		}},
+	})
+	require.NoError(t, err)
+
+	return p, api
+}
+
+func dialogRequest(state string, overrides map[string]interface{}) []byte {
+	submission := map[string]interface{}{
+		DialogSubmissionFieldName:              "New name",
+		DialogSubmissionFieldType:              string(ResourceTypeText),
+		DialogSubmissionFieldQuestion:          "New question",
+		DialogSubmissionFieldAnswer:            "New answer",
+		DialogSubmissionFieldWrongAnswer + "0": "x",
+		DialogSubmissionFieldWrongAnswer + "1": "y",
+		DialogSubmissionFieldWrongAnswer + "2": "z",
+		DialogSubmissionFieldDescription:       "New description",
+		DialogSubmissionFieldLesson:            "0",
+		DialogSubmissionFieldContent:           "New content",
+		DialogSubmissionFieldQuiz:              testQuizID,
+		DialogSubmissionFieldGameScoring:       string(ScoringTypeAll),
+		DialogSubmissionFieldNumberOfQuestions: float64(0),
+		DialogSubmissionFieldEditors:           "",
+		"questionid":                           true,
+		"0":                                    true,
+	}
+	for k, v := range overrides {
+		submission[k] = v
+	}
+
+	return []byte((&model.SubmitDialogRequest{
+		State:      state,
+		Submission: submission,
+	}).ToJson())
+}
+
+func attachmentRequest(id string) []byte {
+	b, _ := json.Marshal(&model.PostActionIntegrationRequest{
+		Context: map[string]interface{}{
+			AttachmentContextFieldID:          id,
+			AttachmentContextFieldLessonIndex: 0,
+		},
+	})
+	return b
+}
+
+func doRequest(p *Plugin, path, userID string, body []byte) string {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(body))
+	r.Header.Set("Mattermost-User-ID", userID)
+	p.router.ServeHTTP(w, r)
+	return w.Body.String()
+}
+
+func TestHandlersRejectNonEditors(t *testing.T) {
+	quizDialog := dialogRequest(testQuizID, nil)
+	gameStartDialog := dialogRequest("", map[string]interface{}{DialogSubmissionFieldGameType: string(GameTypeSolo)})
+	questionDialog := dialogRequest(getQuestionDialogState(testQuizID, "postid", "questionid", QuizTypeMultipleChoice), map[string]interface{}{DialogSubmissionFieldQuestion: "questionid"})
+	courseDialog := dialogRequest(testCourseID, nil)
+	lessonDialog := dialogRequest(getLessonDialogState(testCourseID, 0), nil)
+	quizAttachment := attachmentRequest(testQuizID)
+	courseAttachment := attachmentRequest(testCourseID)
+	questionAttachment, _ := json.Marshal(&model.PostActionIntegrationRequest{
+		Context: map[string]interface{}{
+			AttachmentContextFieldID:         testQuizID,
+			AttachmentContextFieldQuestionID: "questionid",
+		},
+	})
+
+	for _, tc := range []struct {
+		name          string
+		path          string
+		body          []byte
+		expectedError string
+	}{
+		{"dialogNameQuiz", DialogPath + DialogPathNameQuiz, quizDialog, ErrNotQuizEditor.Error()},
+		{"dialogChangeType", DialogPath + DialogPathChangeType, quizDialog, ErrNotQuizEditor.Error()},
+		{"dialogQuizDetails", DialogPath + DialogPathQuizDetails, quizDialog, ErrNotQuizEditor.Error()},
+		{"dialogQuizScope", DialogPath + DialogPathQuizScope, quizDialog, ErrNotQuizEditor.Error()},
+		{"dialogCourseScope", DialogPath + DialogPathCourseScope, courseDialog, ErrNotCourseEditor.Error()},
+		{"dialogQuizVisibility", DialogPath + DialogPathQuizVisibility, quizDialog, "only the creator"},
+		{"dialogCourseVisibility", DialogPath + DialogPathCourseVisibility, courseDialog, "only the creator"},
+		{"dialogDelete", DialogPath + DialogPathDelete, quizDialog, ErrNotQuizEditor.Error()},
+		{"dialogAddQuestion", DialogPath + DialogPathAddQuestion, quizDialog, ErrNotQuizEditor.Error()},
+		{"dialogRemoveQuestions", DialogPath + DialogPathRemoveQuestion, quizDialog, ErrNotQuizEditor.Error()},
+		{"dialogQuizEditors", DialogPath + DialogPathQuizEditors, quizDialog, "only the creator"},
+		{"dialogEditQuiz", DialogPath + DialogPathEditQuiz, quizDialog, ErrNotQuizEditor.Error()},
+		{"dialogEditQuestion", DialogPath + DialogPathEditQuestion, questionDialog, ErrNotQuizEditor.Error()},
+		{"dialogUpdateQuestion", DialogPath + DialogPathUpdateQuestion, questionDialog, ErrNotQuizEditor.Error()},
+		{"dialogExportQuiz", DialogPath + DialogPathExportQuiz, quizDialog, ErrNotQuizEditor.Error()},
+		{"dialogGameStart", DialogPath + DialogPathGameStart, gameStartDialog, "You cannot start this quiz"},
+		{"dialogNameCourse", DialogPath + DialogPathNameCourse, courseDialog, ErrNotCourseEditor.Error()},
+		{"dialogCourseDescription", DialogPath + DialogPathCourseDescription, courseDialog, ErrNotCourseEditor.Error()},
+		{"dialogCourseDelete", DialogPath + DialogPathCourseDelete, courseDialog, ErrNotCourseEditor.Error()},
+		{"dialogAddLesson", DialogPath + DialogPathAddLesson, courseDialog, ErrNotCourseEditor.Error()},
+		{"dialogEditLesson", DialogPath + DialogPathEditLesson, courseDialog, ErrNotCourseEditor.Error()},
+		{"dialogCourseEditors", DialogPath + DialogPathCourseEditors, courseDialog, "only the creator"},
+		{"dialogNameLesson", DialogPath + DialogPathNameLesson, lessonDialog, ErrNotCourseEditor.Error()},
+		{"dialogLessonIntroduction", DialogPath + DialogPathLessonIntroduction, lessonDialog, ErrNotCourseEditor.Error()},
+		{"dialogAddResource", DialogPath + DialogPathAddResource, lessonDialog, ErrNotCourseEditor.Error()},
+		{"dialogAddQuizResource", DialogPath + DialogPathAddQuizResource, lessonDialog, ErrNotCourseEditor.Error()},
+		{"dialogRemoveResources", DialogPath + DialogPathRemoveResources, lessonDialog, ErrNotCourseEditor.Error()},
+		{"dialogLessonDelete", DialogPath + DialogPathLessonDelete, lessonDialog, ErrNotCourseEditor.Error()},
+		{"attachmentNameQuiz", AttachmentPath + AttachmentPathNameQuiz, quizAttachment, ErrNotQuizEditor.Error()},
+		{"attachmentChangeType", AttachmentPath + AttachmentPathChangeType, quizAttachment, ErrNotQuizEditor.Error()},
+		{"attachmentQuizDetails", AttachmentPath + AttachmentPathQuizDetails, quizAttachment, ErrNotQuizEditor.Error()},
+		{"attachmentQuizScope", AttachmentPath + AttachmentPathQuizScope, quizAttachment, ErrNotQuizEditor.Error()},
+		{"attachmentCourseScope", AttachmentPath + AttachmentPathCourseScope, courseAttachment, ErrNotCourseEditor.Error()},
+		{"attachmentQuizVisibility", AttachmentPath + AttachmentPathQuizVisibility, quizAttachment, "only the creator"},
+		{"attachmentCourseVisibility", AttachmentPath + AttachmentPathCourseVisibility, courseAttachment, "only the creator"},
+		{"attachmentRestoreRevision", AttachmentPath + AttachmentPathRestoreRevision, quizAttachment, ErrNotQuizEditor.Error()},
+		{"attachmentDelete", AttachmentPath + AttachmentPathDelete, quizAttachment, ErrNotQuizEditor.Error()},
+		{"attachmentAddQuestion", AttachmentPath + AttachmentPathAddQuestion, quizAttachment, ErrNotQuizEditor.Error()},
+		{"attachmentReviewQuestions", AttachmentPath + AttachmentPathReviewQuestions, quizAttachment, ErrNotQuizEditor.Error()},
+		{"attachmentRemoveQuestions", AttachmentPath + AttachmentPathRemoveQuestion, quizAttachment, ErrNotQuizEditor.Error()},
+		{"attachmentSave", AttachmentPath + AttachmentPathSave, quizAttachment, ErrNotQuizEditor.Error()},
+		{"attachmentQuizEditors", AttachmentPath + AttachmentPathQuizEditors, quizAttachment, "only the creator"},
+		{"attachmentEditQuestion", AttachmentPath + AttachmentPathEditQuestion, quizAttachment, ErrNotQuizEditor.Error()},
+		{"attachmentUpdateQuestion", AttachmentPath + AttachmentPathUpdateQuestion, questionAttachment, ErrNotQuizEditor.Error()},
+		{"attachmentQuestionBack", AttachmentPath + AttachmentPathQuestionBack, quizAttachment, ErrNotQuizEditor.Error()},
+		{"attachmentSetQuestionImage", AttachmentPath + AttachmentPathSetQuestionImage, questionAttachment, ErrNotQuizEditor.Error()},
+		{"attachmentRemoveQuestionImage", AttachmentPath + AttachmentPathRemoveQuestionImage, questionAttachment, ErrNotQuizEditor.Error()},
+		{"attachmentNameCourse", AttachmentPath + AttachmentPathNameCourse, courseAttachment, ErrNotCourseEditor.Error()},
+		{"attachmentCourseDescription", AttachmentPath + AttachmentPathCourseDescription, courseAttachment, ErrNotCourseEditor.Error()},
+		{"attachmentCourseDelete", AttachmentPath + AttachmentPathCourseDelete, courseAttachment, ErrNotCourseEditor.Error()},
+		{"attachmentSaveCourse", AttachmentPath + AttachmentPathSaveCourse, courseAttachment, ErrNotCourseEditor.Error()},
+		{"attachmentAddLesson", AttachmentPath + AttachmentPathAddLesson, courseAttachment, ErrNotCourseEditor.Error()},
+		{"attachmentEditLesson", AttachmentPath + AttachmentPathEditLesson, courseAttachment, ErrNotCourseEditor.Error()},
+		{"attachmentCourseEditors", AttachmentPath + AttachmentPathCourseEditors, courseAttachment, "only the creator"},
+		{"attachmentNameLesson", AttachmentPath + AttachmentPathNameLesson, courseAttachment, ErrNotCourseEditor.Error()},
+		{"attachmentLessonBack", AttachmentPath + AttachmentPathLessonBack, courseAttachment, ErrNotCourseEditor.Error()},
+		{"attachmentLessonIntroduction", AttachmentPath + AttachmentPathLessonIntroduction, courseAttachment, ErrNotCourseEditor.Error()},
+		{"attachmentAddResource", AttachmentPath + AttachmentPathAddResource, courseAttachment, ErrNotCourseEditor.Error()},
+		{"attachmentAddQuizResource", AttachmentPath + AttachmentPathAddQuizResource, courseAttachment, ErrNotCourseEditor.Error()},
+		{"attachmentRemoveResources", AttachmentPath + AttachmentPathRemoveResources, courseAttachment, ErrNotCourseEditor.Error()},
+		{"attachmentLessonDelete", AttachmentPath + AttachmentPathLessonDelete, courseAttachment, ErrNotCourseEditor.Error()},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			p, api := newPermissionsTestPlugin(t)
+			quizBefore := api.kv[getQuizKey(testQuizID)]
+			courseBefore := api.kv[getCourseKey(testCourseID)]
+
+			body := doRequest(p, tc.path, "intruder", tc.body)
+
+			assert.Contains(t, body, tc.expectedError)
+			assert.Equal(t, quizBefore, api.kv[getQuizKey(testQuizID)])
+			assert.Equal(t, courseBefore, api.kv[getCourseKey(testCourseID)])
+			api.AssertNotCalled(t, "OpenInteractiveDialog", mock.Anything)
+			api.AssertNotCalled(t, "UpdatePost", mock.Anything)
+		})
+	}
+}
+
+func TestHandlersAllowEditors(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		userID  string
+		allowed bool
+	}{
+		{"creator", "creator", true},
+		{"co-editor", "editor", true},
+		{"system admin", "admin", true},
+		{"other user", "intruder", false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			p, _ := newPermissionsTestPlugin(t)
+
+			doRequest(p, DialogPath+DialogPathNameQuiz, tc.userID, dialogRequest(testQuizID, nil))
+			q, err := p.store.GetQuiz(testQuizID)
+			require.NoError(t, err)
+			assert.Equal(t, tc.allowed, q.Name == "New name")
+
+			doRequest(p, DialogPath+DialogPathNameCourse, tc.userID, dialogRequest(testCourseID, nil))
+			c, err := p.store.GetCourse(testCourseID)
+			require.NoError(t, err)
+			assert.Equal(t, tc.allowed, c.Name == "New name")
+		})
+	}
+}
+
+func TestIsEditor(t *testing.T) {
+	q := &Quiz{CreatorID: "creator", Editors: []string{"editor"}}
+	assert.True(t, q.IsEditor("creator"))
+	assert.True(t, q.IsEditor("editor"))
+	assert.False(t, q.IsEditor("other"))
+	assert.False(t, q.IsEditor(""))
+
+	legacy := &Quiz{}
+	assert.False(t, legacy.IsEditor(""))
+	assert.False(t, legacy.IsEditor("anyone"))
+}
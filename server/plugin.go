@@ -7,6 +7,7 @@ import (
 	"github.com/gorilla/mux"
 	"github.com/larkox/mattermost-plugin-badges/badgesmodel"
 	pluginapi "github.com/mattermost/mattermost-plugin-api"
+	"github.com/mattermost/mattermost-plugin-api/cluster"
 
 	"github.com/mattermost/mattermost-server/v5/model"
 	"github.com/mattermost/mattermost-server/v5/plugin"
@@ -29,6 +30,9 @@ type Plugin struct {
 	store     Store
 	router    *mux.Router
 	badgesMap map[string]badgesmodel.BadgeID
+	scheduler *cluster.JobOnceScheduler
+
+	leaderboardJob *cluster.Job
 }
 
 // ServeHTTP demonstrates a plugin that handles HTTP requests by greeting the world.
@@ -52,8 +56,32 @@ func (p *Plugin) OnActivate() error {
 	}
 	p.BotUserID = botID
 	p.store = NewStore(p.mm)
+
+	err = p.migrate()
+	if err != nil {
+		return errors.Wrap(err, "failed to migrate the stored data")
+	}
+
 	p.initializeAPI()
 
+	err = p.initializeTimers()
+	if err != nil {
+		return errors.Wrap(err, "failed to start the question timers")
+	}
+
+	err = p.initializeLeaderboards()
+	if err != nil {
+		return errors.Wrap(err, "failed to schedule the leaderboards refresh")
+	}
+
 	p.EnsureBadges()
 	return p.mm.SlashCommand.Register(p.getCommand())
 }
+
+func (p *Plugin) OnDeactivate() error {
+	if p.leaderboardJob != nil {
+		return p.leaderboardJob.Close()
+	}
+
+	return nil
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+)
+
+// Every change to a quiz is stored as a numbered revision, so a bad edit can be rolled
+// back. Games keep a copy of the quiz they started with, and their results record the
+// number of that revision.
+
+// diffQuizzes describes the changes from a revision of a quiz to the next one. The revision
+// before is nil for the first revision.
+func diffQuizzes(before, after *Quiz) []string {
+	if before == nil {
+		return []string{fmt.Sprintf("Created with %d questions", len(after.Questions))}
+	}
+
+	changes := []string{}
+	if before.Name != after.Name {
+		changes = append(changes, fmt.Sprintf("Renamed from `%s` to `%s`", before.Name, after.Name))
+	}
+	if before.Type != after.Type {
+		changes = append(changes, fmt.Sprintf("Changed the default type from %s to %s", getQuizTypeName(before.Type), getQuizTypeName(after.Type)))
+	}
+	if before.MultiSelectScoring != after.MultiSelectScoring {
+		changes = append(changes, "Changed the multi-select scoring to "+string(after.MultiSelectScoring))
+	}
+	if before.Category != after.Category || before.Difficulty != after.Difficulty || !equalStrings(before.Tags, after.Tags) {
+		changes = append(changes, "Changed the details")
+	}
+	if !equalStrings(before.Editors, after.Editors) {
+		changes = append(changes, "Changed the editors")
+	}
+	if before.TeamID != after.TeamID || !equalStrings(before.ChannelIDs, after.ChannelIDs) {
+		changes = append(changes, "Changed the scope")
+	}
+	if before.Visibility != after.Visibility || !equalStrings(before.SharedWith, after.SharedWith) || !equalStrings(before.SharedGroups, after.SharedGroups) {
+		changes = append(changes, "Changed the visibility")
+	}
+
+	beforeQuestions := map[string]Question{}
+	beforeOrder := []string{}
+	for _, question := range before.Questions {
+		beforeQuestions[question.ID] = question
+		beforeOrder = append(beforeOrder, question.ID)
+	}
+
+	afterQuestions := map[string]bool{}
+	afterOrder := []string{}
+	for _, question := range after.Questions {
+		afterQuestions[question.ID] = true
+		afterOrder = append(afterOrder, question.ID)
+
+		beforeQuestion, ok := beforeQuestions[question.ID]
+		if !ok {
+			changes = append(changes, fmt.Sprintf("Added question `%s`", question.Question))
+			continue
+		}
+		if !reflect.DeepEqual(normalizeQuestion(beforeQuestion), normalizeQuestion(question)) {
+			changes = append(changes, fmt.Sprintf("Changed question `%s`", question.Question))
+		}
+	}
+
+	removed := false
+	for _, question := range before.Questions {
+		if !afterQuestions[question.ID] {
+			changes = append(changes, fmt.Sprintf("Removed question `%s`", question.Question))
+			removed = true
+		}
+	}
+
+	if !removed && len(beforeOrder) == len(afterOrder) && !equalStrings(beforeOrder, afterOrder) {
+		changes = append(changes, "Reordered the questions")
+	}
+
+	if len(changes) == 0 {
+		return []string{"No changes"}
+	}
+
+	return changes
+}
+
+// equalStrings reports whether both lists have the same items in the same order. Missing
+// and empty lists are equal.
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// normalizeQuestion makes empty lists of the question missing, so questions can be
+// compared no matter how their lists were stored.
+func normalizeQuestion(question Question) Question {
+	if len(question.IncorrectAnswers) == 0 {
+		question.IncorrectAnswers = nil
+	}
+	if len(question.AlternativeAnswers) == 0 {
+		question.AlternativeAnswers = nil
+	}
+	if len(question.ExtraCorrectAnswers) == 0 {
+		question.ExtraCorrectAnswers = nil
+	}
+
+	return question
+}
+
+// restoreQuizRevision copies the content of the revision into the quiz. The editors,
+// scope and visibility are not restored, since rolling back content should not give
+// access to the quiz back to people it was taken from.
+func restoreQuizRevision(q *Quiz, revision *Quiz) {
+	q.Name = revision.Name
+	q.Type = revision.Type
+	q.MultiSelectScoring = revision.MultiSelectScoring
+	q.Category = revision.Category
+	q.Tags = revision.Tags
+	q.Difficulty = revision.Difficulty
+	q.Questions = revision.Questions
+}
+
+func getRevisionDate(createdAt int64) string {
+	if createdAt == 0 {
+		return "before the history was kept"
+	}
+
+	return time.Unix(0, createdAt*int64(time.Millisecond)).UTC().Format("2006-01-02 15:04 MST")
+}
+
+// getQuizRevisions returns the revisions kept for the quiz, oldest first, skipping the
+// ones that cannot be read.
+func (p *Plugin) getQuizRevisions(quizID string) ([]*QuizRevision, error) {
+	infos, err := p.store.GetQuizRevisions(quizID)
+	if err != nil {
+		return nil, err
+	}
+
+	revisions := []*QuizRevision{}
+	for _, info := range infos {
+		revision, err := p.store.GetQuizRevision(quizID, info.Number)
+		if err != nil {
+			p.mm.Log.Debug("Error getting quiz revision", "id", quizID, "revision", info.Number, "err", err)
+			continue
+		}
+
+		if revision == nil {
+			p.mm.Log.Debug("Quiz revision not found", "id", quizID, "revision", info.Number)
+			continue
+		}
+
+		revisions = append(revisions, revision)
+	}
+
+	return revisions, nil
+}
+
+// QuizHistoryAttachment lists the revisions of the quiz, newest first, with the changes
+// of each one, and lets the editors restore any of them.
+func (p *Plugin) QuizHistoryAttachment(q *Quiz, revisions []*QuizRevision) *model.SlackAttachment {
+	attachment := &model.SlackAttachment{
+		Title: "History of " + q.Name,
+	}
+
+	if len(revisions) == 0 {
+		attachment.Text = "This quiz has no revisions yet. Revisions are kept from the next change."
+		return attachment
+	}
+
+	text := ""
+	options := []*model.PostActionOptions{}
+	for i := len(revisions) - 1; i >= 0; i-- {
+		revision := revisions[i]
+		var previous *Quiz
+		if i > 0 {
+			previous = &revisions[i-1].Quiz
+		}
+
+		text += fmt.Sprintf("**#%d** by %s, %s", revision.Number, p.getUsernamesFromUserIDs([]string{revision.AuthorID}), getRevisionDate(revision.CreatedAt))
+		if revision.Number == q.Revision {
+			text += " (current)"
+		}
+		if i == 0 && revision.Number > 1 {
+			text += "\n- Older revisions were deleted"
+		} else {
+			for _, change := range diffQuizzes(previous, &revision.Quiz) {
+				text += "\n- " + change
+			}
+		}
+		text += "\n\n"
+
+		if revision.Number != q.Revision {
+			options = append(options, &model.PostActionOptions{
+				Text:  fmt.Sprintf("#%d, %s", revision.Number, getRevisionDate(revision.CreatedAt)),
+				Value: strconv.Itoa(revision.Number),
+			})
+		}
+	}
+	attachment.Text = text
+
+	if len(options) > 0 {
+		attachment.Actions = []*model.PostAction{{
+			Type:    "select",
+			Name:    "Restore revision",
+			Options: options,
+			Integration: &model.PostActionIntegration{
+				URL: p.getAttachmentURL() + AttachmentPathRestoreRevision,
+				Context: map[string]interface{}{
+					AttachmentContextFieldID: q.ID,
+				},
+			},
+		}}
+	}
+
+	return attachment
+}
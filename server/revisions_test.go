@@ -0,0 +1,232 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStoreQuizRevision(t *testing.T) {
+	s := newTestStore()
+
+	q := &Quiz{ID: "quiz", Name: "Quiz", CreatorID: "creator"}
+	_, err := s.StoreQuizRevision(q, "creator")
+	require.NoError(t, err)
+	assert.Equal(t, 1, q.Revision)
+
+	q.Name = "Renamed"
+	_, err = s.StoreQuizRevision(q, "editor")
+	require.NoError(t, err)
+	assert.Equal(t, 2, q.Revision)
+
+	stored, err := s.GetQuiz("quiz")
+	require.NoError(t, err)
+	assert.Equal(t, "Renamed", stored.Name)
+	assert.Equal(t, 2, stored.Revision)
+
+	revisions, err := s.GetQuizRevisions("quiz")
+	require.NoError(t, err)
+	require.Len(t, revisions, 2)
+	assert.Equal(t, "creator", revisions[0].AuthorID)
+	assert.Equal(t, "editor", revisions[1].AuthorID)
+
+	first, err := s.GetQuizRevision("quiz", 1)
+	require.NoError(t, err)
+	require.NotNil(t, first)
+	assert.Equal(t, "Quiz", first.Quiz.Name)
+	assert.Equal(t, 1, first.Quiz.Revision)
+
+	missing, err := s.GetQuizRevision("quiz", 3)
+	require.NoError(t, err)
+	assert.Nil(t, missing)
+}
+
+func TestStoreQuizRevisionRecordsQuizzesSavedBefore(t *testing.T) {
+	s := newTestStore()
+
+	require.NoError(t, s.StoreQuiz(&Quiz{ID: "quiz", Name: "Quiz", CreatorID: "creator"}))
+
+	q, err := s.GetQuiz("quiz")
+	require.NoError(t, err)
+	q.Name = "Renamed"
+	_, err = s.StoreQuizRevision(q, "editor")
+	require.NoError(t, err)
+	assert.Equal(t, 2, q.Revision)
+
+	first, err := s.GetQuizRevision("quiz", 1)
+	require.NoError(t, err)
+	require.NotNil(t, first)
+	assert.Equal(t, "Quiz", first.Quiz.Name)
+	assert.Equal(t, "creator", first.AuthorID)
+	assert.Zero(t, first.CreatedAt)
+}
+
+func TestStoreQuizRevisionDeletesOldRevisions(t *testing.T) {
+	s := newTestStore()
+
+	q := &Quiz{ID: "quiz", Name: "Quiz"}
+	for i := 0; i < MaxQuizRevisions+5; i++ {
+		unusedImages, err := s.StoreQuizRevision(q, "editor")
+		require.NoError(t, err)
+		assert.Empty(t, unusedImages)
+	}
+
+	revisions, err := s.GetQuizRevisions("quiz")
+	require.NoError(t, err)
+	require.Len(t, revisions, MaxQuizRevisions)
+	assert.Equal(t, 6, revisions[0].Number)
+	assert.Equal(t, MaxQuizRevisions+5, revisions[len(revisions)-1].Number)
+
+	deleted, err := s.GetQuizRevision("quiz", 5)
+	require.NoError(t, err)
+	assert.Nil(t, deleted)
+
+	require.NoError(t, s.DeleteQuiz("quiz"))
+	revisions, err = s.GetQuizRevisions("quiz")
+	require.NoError(t, err)
+	assert.Empty(t, revisions)
+}
+
+func TestStoreQuizRevisionDeletesUnusedImages(t *testing.T) {
+	p, api := newTestPlugin()
+	p.BotUserID = "bot"
+	for _, id := range []string{"old", "kept", "current"} {
+		api.On("GetFileInfo", id).Return(&model.FileInfo{Id: id, PostId: id + "post"}, nil)
+		api.On("GetPost", id+"post").Return(&model.Post{Id: id + "post", UserId: "bot"}, nil)
+	}
+	api.On("DeletePost", mock.Anything).Return(nil)
+
+	// The first revision is the only one with the old image, and the kept image is used
+	// by the first two revisions.
+	q := &Quiz{ID: "quiz", Name: "Quiz", Questions: []Question{{ID: "q1", ImageFileID: "old"}, {ID: "q2", ImageFileID: "kept"}}}
+	require.NoError(t, p.storeQuizRevision(q, "editor"))
+	q.Questions[0].ImageFileID = ""
+	require.NoError(t, p.storeQuizRevision(q, "editor"))
+	q.Questions[1].ImageFileID = "current"
+	for i := 2; i < MaxQuizRevisions; i++ {
+		require.NoError(t, p.storeQuizRevision(q, "editor"))
+	}
+	api.AssertNotCalled(t, "DeletePost", mock.Anything)
+
+	require.NoError(t, p.storeQuizRevision(q, "editor"))
+	api.AssertCalled(t, "DeletePost", "oldpost")
+	api.AssertNumberOfCalls(t, "DeletePost", 1)
+
+	require.NoError(t, p.storeQuizRevision(q, "editor"))
+	api.AssertCalled(t, "DeletePost", "keptpost")
+	api.AssertNumberOfCalls(t, "DeletePost", 2)
+
+	for i := 0; i < MaxQuizRevisions; i++ {
+		require.NoError(t, p.storeQuizRevision(q, "editor"))
+	}
+	api.AssertNotCalled(t, "DeletePost", "currentpost")
+}
+
+func TestDiffQuizzes(t *testing.T) {
+	old := &Quiz{
+		Name: "Quiz",
+		Type: QuizTypeMultipleChoice,
+		Tags: []string{},
+		Questions: []Question{
+			{ID: "a", Question: "First"},
+			{ID: "b", Question: "Second", IncorrectAnswers: []string{}},
+			{ID: "c", Question: "Third"},
+		},
+	}
+
+	assert.Equal(t, []string{"Created with 3 questions"}, diffQuizzes(nil, old))
+	assert.Equal(t, []string{"No changes"}, diffQuizzes(old, &Quiz{
+		Name:      "Quiz",
+		Type:      QuizTypeMultipleChoice,
+		Questions: []Question{{ID: "a", Question: "First"}, {ID: "b", Question: "Second"}, {ID: "c", Question: "Third"}},
+	}))
+
+	changed := &Quiz{
+		Name:       "Renamed",
+		Type:       QuizTypeMultipleChoice,
+		Visibility: VisibilityPrivate,
+		Questions: []Question{
+			{ID: "a", Question: "First", CorrectAnswer: "Changed"},
+			{ID: "c", Question: "Third"},
+			{ID: "d", Question: "Fourth"},
+		},
+	}
+	assert.Equal(t, []string{
+		"Renamed from `Quiz` to `Renamed`",
+		"Changed the visibility",
+		"Changed question `First`",
+		"Added question `Fourth`",
+		"Removed question `Second`",
+	}, diffQuizzes(old, changed))
+
+	reordered := &Quiz{
+		Name:      "Quiz",
+		Type:      QuizTypeMultipleChoice,
+		Questions: []Question{old.Questions[1], old.Questions[0], old.Questions[2]},
+	}
+	assert.Equal(t, []string{"Reordered the questions"}, diffQuizzes(old, reordered))
+}
+
+func TestAttachmentRestoreRevision(t *testing.T) {
+	p, api := newPermissionsTestPlugin(t)
+	api.On("GetDirectChannel", "editor", mock.Anything).Return(&model.Channel{Id: "dm"}, nil)
+	api.On("GetDirectChannel", mock.Anything, "editor").Return(&model.Channel{Id: "dm"}, nil)
+	api.On("CreatePost", mock.Anything).Return(&model.Post{Id: "post"}, nil)
+
+	q, err := p.store.GetQuiz(testQuizID)
+	require.NoError(t, err)
+	q.Editors = []string{"editor", "other"}
+	q.Questions = nil
+	require.NoError(t, p.storeQuizRevision(q, "editor"))
+	require.Equal(t, 2, q.Revision)
+
+	b, _ := json.Marshal(&model.PostActionIntegrationRequest{
+		Context: map[string]interface{}{
+			AttachmentContextFieldID:       testQuizID,
+			AttachmentContextFieldSelected: "1",
+		},
+	})
+	body := doRequest(p, AttachmentPath+AttachmentPathRestoreRevision, "editor", b)
+	assert.Contains(t, body, "Revision #1 restored as revision #3")
+
+	restored, err := p.store.GetQuiz(testQuizID)
+	require.NoError(t, err)
+	assert.Equal(t, 3, restored.Revision)
+	require.Len(t, restored.Questions, 1)
+	assert.Equal(t, "questionid", restored.Questions[0].ID)
+	assert.Equal(t, []string{"editor", "other"}, restored.Editors)
+
+	b, _ = json.Marshal(&model.PostActionIntegrationRequest{
+		Context: map[string]interface{}{
+			AttachmentContextFieldID:       testQuizID,
+			AttachmentContextFieldSelected: "3",
+		},
+	})
+	body = doRequest(p, AttachmentPath+AttachmentPathRestoreRevision, "editor", b)
+	assert.Contains(t, body, "already the current one")
+}
+
+func TestGameKeepsQuizRevision(t *testing.T) {
+	p, _ := newPermissionsTestPlugin(t)
+
+	q, err := p.store.GetQuiz(testQuizID)
+	require.NoError(t, err)
+	q.Name = "Renamed"
+	require.NoError(t, p.storeQuizRevision(q, "editor"))
+
+	g := &Game{RootPostID: "game", Quiz: *q}
+	require.NoError(t, p.store.StoreGame(g))
+
+	q.Name = "Renamed again"
+	require.NoError(t, p.storeQuizRevision(q, "editor"))
+
+	stored, err := p.store.GetGame("game")
+	require.NoError(t, err)
+	assert.Equal(t, "Renamed", stored.Quiz.Name)
+	assert.Equal(t, 2, stored.Quiz.Revision)
+	assert.Equal(t, 2, NewGameResult(stored).QuizRevision)
+}
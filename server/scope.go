@@ -0,0 +1,197 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Saved quizzes and courses are scoped to the team they were created in, and
+// optionally to some channels of that team. Quizzes and courses saved before scopes
+// existed have no team and stay available to everyone.
+
+// userMemberships remembers the team, channel and group memberships of a user while
+// checking several quizzes or courses, so each of them is only fetched once.
+type userMemberships struct {
+	p        *Plugin
+	userID   string
+	teams    map[string]bool
+	channels map[string]bool
+	groups   map[string]bool
+}
+
+func (p *Plugin) newUserMemberships(userID string) *userMemberships {
+	return &userMemberships{
+		p:        p,
+		userID:   userID,
+		teams:    map[string]bool{},
+		channels: map[string]bool{},
+	}
+}
+
+func (m *userMemberships) inTeam(teamID string) bool {
+	isMember, ok := m.teams[teamID]
+	if !ok {
+		member, err := m.p.mm.Team.GetMember(teamID, m.userID)
+		isMember = err == nil && member.DeleteAt == 0
+		m.teams[teamID] = isMember
+	}
+
+	return isMember
+}
+
+func (m *userMemberships) inChannel(channelID string) bool {
+	isMember, ok := m.channels[channelID]
+	if !ok {
+		_, err := m.p.mm.Channel.GetMember(channelID, m.userID)
+		isMember = err == nil
+		m.channels[channelID] = isMember
+	}
+
+	return isMember
+}
+
+func (m *userMemberships) inGroup(groupID string) bool {
+	if m.groups == nil {
+		m.groups = map[string]bool{}
+		groups, err := m.p.mm.Group.ListForUser(m.userID)
+		if err != nil {
+			m.p.mm.Log.Debug("Cannot get the groups of the user", "userID", m.userID, "err", err)
+		}
+
+		for _, group := range groups {
+			m.groups[group.Id] = true
+		}
+	}
+
+	return m.groups[groupID]
+}
+
+// isInScope reports whether the user can use a quiz or course with the given scope from
+// the current team. The current team is empty when the user is not in a team context,
+// like in the direct messages with the bot, and then only membership is checked.
+func (m *userMemberships) isInScope(teamID string, channelIDs []string, currentTeamID string) bool {
+	if teamID == "" {
+		return true
+	}
+
+	if currentTeamID != "" && currentTeamID != teamID {
+		return false
+	}
+
+	if !m.inTeam(teamID) {
+		return false
+	}
+
+	if len(channelIDs) == 0 {
+		return true
+	}
+
+	for _, channelID := range channelIDs {
+		if m.inChannel(channelID) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isScopedChannel reports whether the channel is one of the channels a quiz or course is
+// scoped to. Those without channels can be used in every channel of their team.
+func isScopedChannel(channelIDs []string, channelID string) bool {
+	if len(channelIDs) == 0 {
+		return true
+	}
+
+	for _, id := range channelIDs {
+		if id == channelID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// getAvailableQuizzes returns the saved quizzes in the library of the user and team.
+func (p *Plugin) getAvailableQuizzes(userID, teamID string) []*Quiz {
+	out := []*Quiz{}
+	m := p.newUserMemberships(userID)
+	for _, q := range p.store.GetAvailableQuizes() {
+		if canSeeQuiz(q, m, teamID) {
+			out = append(out, q)
+		}
+	}
+
+	return out
+}
+
+// getAvailableCourses returns the saved courses in the library of the user and team.
+func (p *Plugin) getAvailableCourses(userID, teamID string) []*Course {
+	out := []*Course{}
+	m := p.newUserMemberships(userID)
+	for _, c := range p.store.GetAvailableCourses() {
+		if canSeeCourse(c, m, teamID) {
+			out = append(out, c)
+		}
+	}
+
+	return out
+}
+
+func (p *Plugin) getChannelIDsFromNames(teamID, text string) ([]string, error) {
+	ids := []string{}
+	for _, name := range strings.Fields(strings.ReplaceAll(text, ",", " ")) {
+		name = strings.TrimPrefix(name, "~")
+		channel, err := p.mm.Channel.GetByName(teamID, name, false)
+		if err != nil {
+			return nil, errors.Errorf("channel ~%s not found in the team", name)
+		}
+		ids = append(ids, channel.Id)
+	}
+
+	return ids, nil
+}
+
+func (p *Plugin) getChannelNamesFromIDs(ids []string) string {
+	names := []string{}
+	for _, id := range ids {
+		channel, err := p.mm.Channel.Get(id)
+		if err != nil {
+			p.mm.Log.Debug("Cannot get channel", "id", id, "err", err)
+			continue
+		}
+		names = append(names, "~"+channel.Name)
+	}
+
+	return strings.Join(names, " ")
+}
+
+// getScopeText describes who can use a saved quiz or course, like "team Sales" or
+// "~onboarding in team Sales".
+func (p *Plugin) getScopeText(teamID string, channelIDs []string) string {
+	if teamID == "" {
+		return "everyone"
+	}
+
+	teamName := teamID
+	team, err := p.mm.Team.Get(teamID)
+	if err == nil {
+		teamName = team.DisplayName
+	}
+
+	if len(channelIDs) == 0 {
+		return "team " + teamName
+	}
+
+	return p.getChannelNamesFromIDs(channelIDs) + " in team " + teamName
+}
+
+// getScopeIntroduction returns the introduction of the dialogs to change the scope.
+// Quizzes and courses without team take the team the dialog is submitted from.
+func getScopeIntroduction(kind, teamID string) string {
+	if teamID == "" {
+		return "The " + kind + " will be available to the members of your current team. You can also make it available only to the members of some channels of the team."
+	}
+
+	return "The " + kind + " is available to the members of its team. You can also make it available only to the members of some channels of the team."
+}
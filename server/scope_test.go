@@ -0,0 +1,113 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newScopeTestPlugin(t *testing.T) (*Plugin, *memoryAPI) {
+	p, api := newTestPlugin()
+	api.On("GetTeamMember", "sales", "seller").Return(&model.TeamMember{TeamId: "sales", UserId: "seller"}, nil)
+	api.On("GetTeamMember", "sales", "former").Return(&model.TeamMember{TeamId: "sales", UserId: "former", DeleteAt: 1}, nil)
+	api.On("GetTeamMember", "sales", "engineer").Return(nil, &model.AppError{Message: "not found"})
+	api.On("GetChannelMember", "onboarding", "seller").Return(&model.ChannelMember{ChannelId: "onboarding", UserId: "seller"}, nil)
+	api.On("GetChannelMember", "leads", "seller").Return(nil, &model.AppError{Message: "not found"})
+
+	for _, q := range []*Quiz{
+		{ID: "global", Name: "Global"},
+		{ID: "team", Name: "Team", TeamID: "sales"},
+		{ID: "onboarding", Name: "Onboarding", TeamID: "sales", ChannelIDs: []string{"onboarding"}},
+		{ID: "leads", Name: "Leads", TeamID: "sales", ChannelIDs: []string{"leads"}},
+	} {
+		require.NoError(t, p.store.StoreQuiz(q))
+		require.NoError(t, p.store.AddAvailableQuiz(q))
+	}
+
+	return p, api
+}
+
+func TestIsInScope(t *testing.T) {
+	p, _ := newScopeTestPlugin(t)
+
+	for _, tc := range []struct {
+		name          string
+		teamID        string
+		channelIDs    []string
+		userID        string
+		currentTeamID string
+		expected      bool
+	}{
+		{"no team", "", nil, "engineer", "engineering", true},
+		{"team member", "sales", nil, "seller", "sales", true},
+		{"team member from a direct message", "sales", nil, "seller", "", true},
+		{"team member from another team", "sales", nil, "seller", "engineering", false},
+		{"not a team member", "sales", nil, "engineer", "", false},
+		{"former team member", "sales", nil, "former", "sales", false},
+		{"channel member", "sales", []string{"leads", "onboarding"}, "seller", "sales", true},
+		{"not a channel member", "sales", []string{"leads"}, "seller", "sales", false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, p.newUserMemberships(tc.userID).isInScope(tc.teamID, tc.channelIDs, tc.currentTeamID))
+		})
+	}
+}
+
+func TestGetAvailableQuizzes(t *testing.T) {
+	p, api := newScopeTestPlugin(t)
+
+	ids := func(quizzes []*Quiz) []string {
+		out := []string{}
+		for _, q := range quizzes {
+			out = append(out, q.ID)
+		}
+		return out
+	}
+
+	assert.Equal(t, []string{"global", "team", "onboarding"}, ids(p.getAvailableQuizzes("seller", "sales")))
+	api.AssertNumberOfCalls(t, "GetTeamMember", 1)
+	api.AssertNumberOfCalls(t, "GetChannelMember", 2)
+	assert.Equal(t, []string{"global"}, ids(p.getAvailableQuizzes("seller", "engineering")))
+	assert.Equal(t, []string{"global"}, ids(p.getAvailableQuizzes("engineer", "")))
+}
+
+func TestCanStartQuizInChannel(t *testing.T) {
+	p, api := newScopeTestPlugin(t)
+	api.On("HasPermissionTo", mock.Anything, model.PERMISSION_MANAGE_SYSTEM).Return(false)
+
+	for _, tc := range []struct {
+		name      string
+		quizID    string
+		channelID string
+		expected  bool
+	}{
+		{"scoped channel", "onboarding", "onboarding", true},
+		{"another channel of the team", "onboarding", "townsquare", false},
+		{"no channel check", "onboarding", "", true},
+		{"team quiz in any channel", "team", "townsquare", true},
+		{"global quiz in any channel", "global", "townsquare", true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			q, err := p.store.GetQuiz(tc.quizID)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, p.canStartQuiz(q, "seller", "sales", tc.channelID))
+		})
+	}
+}
+
+func TestCanTakeCourseInTeam(t *testing.T) {
+	p, api := newScopeTestPlugin(t)
+	api.On("HasPermissionTo", mock.Anything, model.PERMISSION_MANAGE_SYSTEM).Return(false)
+
+	c := &Course{ID: "course", Name: "Course", TeamID: "sales"}
+	require.NoError(t, p.store.StoreCourse(c))
+	require.NoError(t, p.store.AddAvailableCourse(c))
+
+	assert.True(t, p.canTakeCourse(c, "seller", "sales"))
+	assert.True(t, p.canTakeCourse(c, "seller", ""))
+	assert.False(t, p.canTakeCourse(c, "seller", "engineering"))
+	assert.False(t, p.canTakeCourse(c, "engineer", "sales"))
+}
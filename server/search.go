@@ -0,0 +1,184 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/pkg/errors"
+)
+
+// QuizSearch filters quizzes by words found in their name, category or tags, and by
+// a tag.
+type QuizSearch struct {
+	Terms string
+	Tag   string
+}
+
+// parseQuizSearchArgs reads the search of commands like `/quiz list [--tag tag] [words]`.
+func parseQuizSearchArgs(args []string) (QuizSearch, error) {
+	s := QuizSearch{}
+	terms := []string{}
+	for i := 0; i < len(args); i++ {
+		if args[i] != "--tag" {
+			terms = append(terms, args[i])
+			continue
+		}
+
+		if i+1 >= len(args) || normalizeTag(args[i+1]) == "" {
+			return s, errors.New("missing the tag after --tag")
+		}
+		s.Tag = normalizeTag(args[i+1])
+		i++
+	}
+
+	s.Terms = strings.TrimSpace(strings.Join(terms, " "))
+	return s, nil
+}
+
+// IsEmpty returns whether the search matches every quiz.
+func (s QuizSearch) IsEmpty() bool {
+	return s.Terms == "" && s.Tag == ""
+}
+
+// Matches returns whether the quiz has the tag of the search, if any, and every word
+// of the search is found in its name, category or tags, ignoring case.
+func (s QuizSearch) Matches(q *Quiz) bool {
+	if s.Tag != "" && !q.HasTag(s.Tag) {
+		return false
+	}
+
+	text := strings.ToLower(q.Name + " " + q.Category + " " + strings.Join(q.Tags, " "))
+	for _, word := range strings.Fields(strings.ToLower(s.Terms)) {
+		if !strings.Contains(text, word) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Filter returns the quizzes matching the search, sorted by name.
+func (s QuizSearch) Filter(quizzes []*Quiz) []*Quiz {
+	out := []*Quiz{}
+	for _, q := range quizzes {
+		if s.Matches(q) {
+			out = append(out, q)
+		}
+	}
+
+	sort.SliceStable(out, func(i, j int) bool { return strings.ToLower(out[i].Name) < strings.ToLower(out[j].Name) })
+	return out
+}
+
+// HasTag returns whether the quiz has the tag, ignoring case.
+func (q *Quiz) HasTag(tag string) bool {
+	tag = normalizeTag(tag)
+	for _, t := range q.Tags {
+		if t == tag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// normalizeTag lowercases the tag and removes a leading #, so "#History" and "history"
+// are the same tag.
+func normalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(tag), "#"))
+}
+
+// parseTags reads a list of tags separated by commas or spaces, without repeated tags.
+func parseTags(text string) []string {
+	tags := []string{}
+	seen := map[string]bool{}
+	for _, tag := range strings.FieldsFunc(text, func(r rune) bool { return r == ',' || r == ' ' || r == '\n' }) {
+		tag = normalizeTag(tag)
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		tags = append(tags, tag)
+	}
+
+	return tags
+}
+
+// getQuizOptionText returns the text of the quiz in selects, with its category and
+// difficulty so similar names can be told apart.
+func getQuizOptionText(q *Quiz) string {
+	details := []string{}
+	if q.Category != "" {
+		details = append(details, q.Category)
+	}
+	if q.Difficulty != "" {
+		details = append(details, string(q.Difficulty))
+	}
+	if len(details) == 0 {
+		return q.Name
+	}
+
+	return q.Name + " (" + strings.Join(details, ", ") + ")"
+}
+
+// getQuizListMarkdown returns the table of quizzes shown by `/quiz list`.
+func getQuizListMarkdown(quizzes []*Quiz) string {
+	out := "| Quiz | Category | Difficulty | Tags | Questions |\n|---|---|---|---|---|"
+	for _, q := range quizzes {
+		out += fmt.Sprintf("\n| %s | %s | %s | %s | %d |", q.Name, q.Category, q.Difficulty, strings.Join(q.Tags, ", "), len(q.Questions))
+	}
+
+	return out
+}
+
+// getStartQuizOptions returns the options of the quizzes the user can start in the team
+// that match the search, up to MaxQuizOptions, and the number of matching quizzes.
+func (p *Plugin) getStartQuizOptions(s QuizSearch, userID, teamID string) ([]*model.PostActionOptions, int) {
+	quizzes := s.Filter(p.getAvailableQuizzes(userID, teamID))
+	options := []*model.PostActionOptions{}
+	for i, q := range quizzes {
+		if i == MaxQuizOptions {
+			break
+		}
+		options = append(options, &model.PostActionOptions{Text: getQuizOptionText(q), Value: q.ID})
+	}
+
+	return options, len(quizzes)
+}
+
+// searchQuizzes is the data source of dynamic selects of quizzes. It returns the quizzes
+// that can be started in the team_id query parameter matching the term and tag ones, in the
+// {"items": [{"text": ..., "value": ...}]} format of dynamic selects. The team is
+// required, and the user must be a member of it.
+func (p *Plugin) searchQuizzes(w http.ResponseWriter, r *http.Request, actingUserID string) {
+	query := r.URL.Query()
+	teamID := query.Get("team_id")
+	if teamID == "" {
+		p.writeAPIError(w, &APIErrorResponse{Message: "team_id is required", StatusCode: http.StatusBadRequest})
+		return
+	}
+
+	if !p.newUserMemberships(actingUserID).inTeam(teamID) {
+		p.writeAPIError(w, &APIErrorResponse{Message: "you are not a member of the team", StatusCode: http.StatusForbidden})
+		return
+	}
+
+	s := QuizSearch{
+		Terms: strings.TrimSpace(query.Get("term")),
+		Tag:   normalizeTag(query.Get("tag")),
+	}
+
+	options, _ := p.getStartQuizOptions(s, actingUserID, teamID)
+	b, err := json.Marshal(map[string]interface{}{"items": options})
+	if err != nil {
+		p.writeAPIError(w, &APIErrorResponse{Message: err.Error(), StatusCode: http.StatusInternalServerError})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(b)
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseQuizSearchArgs(t *testing.T) {
+	s, err := parseQuizSearchArgs([]string{"world", "--tag", "#Geography", "capitals"})
+	require.NoError(t, err)
+	assert.Equal(t, QuizSearch{Terms: "world capitals", Tag: "geography"}, s)
+
+	s, err = parseQuizSearchArgs(nil)
+	require.NoError(t, err)
+	assert.True(t, s.IsEmpty())
+
+	_, err = parseQuizSearchArgs([]string{"--tag"})
+	assert.Error(t, err)
+}
+
+func TestQuizSearchFilter(t *testing.T) {
+	capitals := &Quiz{ID: "1", Name: "World capitals", Category: "Geography", Tags: []string{"europe", "asia"}}
+	rivers := &Quiz{ID: "2", Name: "Rivers", Category: "Geography", Tags: []string{"europe"}}
+	kings := &Quiz{ID: "3", Name: "kings of France", Category: "History", Tags: []string{"europe"}}
+	quizzes := []*Quiz{capitals, rivers, kings}
+
+	for _, tc := range []struct {
+		name     string
+		search   QuizSearch
+		expected []*Quiz
+	}{
+		{"empty search sorted by name", QuizSearch{}, []*Quiz{kings, rivers, capitals}},
+		{"by name", QuizSearch{Terms: "CAPITALS"}, []*Quiz{capitals}},
+		{"by category", QuizSearch{Terms: "geography"}, []*Quiz{rivers, capitals}},
+		{"every word must match", QuizSearch{Terms: "geography asia"}, []*Quiz{capitals}},
+		{"by tag", QuizSearch{Tag: "asia"}, []*Quiz{capitals}},
+		{"by tag and words", QuizSearch{Terms: "history", Tag: "europe"}, []*Quiz{kings}},
+		{"no match", QuizSearch{Terms: "music"}, []*Quiz{}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, tc.search.Filter(quizzes))
+		})
+	}
+}
+
+func TestParseTags(t *testing.T) {
+	assert.Equal(t, []string{"history", "europe", "middle-ages"}, parseTags(" History, #europe,,middle-ages history"))
+	assert.Empty(t, parseTags(""))
+}
+
+func TestGetStartQuizOptions(t *testing.T) {
+	p, _ := newPermissionsTestPlugin(t)
+
+	q, err := p.store.GetQuiz(testQuizID)
+	require.NoError(t, err)
+	q.Category = "Geography"
+	q.Tags = []string{"europe"}
+	q.Difficulty = QuizDifficultyEasy
+	require.NoError(t, p.store.StoreQuiz(q))
+	require.NoError(t, p.store.AddAvailableQuiz(q))
+
+	options, total := p.getStartQuizOptions(QuizSearch{Terms: "geo", Tag: "europe"}, "player", "")
+	assert.Equal(t, []*model.PostActionOptions{{Text: "Quiz (Geography, easy)", Value: testQuizID}}, options)
+	assert.Equal(t, 1, total)
+
+	options, total = p.getStartQuizOptions(QuizSearch{Tag: "asia"}, "player", "")
+	assert.Empty(t, options)
+	assert.Equal(t, 0, total)
+}
+
+func TestSearchQuizzes(t *testing.T) {
+	p, api := newPermissionsTestPlugin(t)
+	api.On("GetTeamMember", "team", "player").Return(&model.TeamMember{TeamId: "team", UserId: "player"}, nil)
+	api.On("GetTeamMember", "other", "player").Return(nil, &model.AppError{Message: "not found", StatusCode: http.StatusNotFound})
+
+	q, err := p.store.GetQuiz(testQuizID)
+	require.NoError(t, err)
+	q.TeamID = "team"
+	q.Category = "Geography"
+	q.Tags = []string{"europe"}
+	q.Difficulty = QuizDifficultyEasy
+	require.NoError(t, p.store.StoreQuiz(q))
+	require.NoError(t, p.store.AddAvailableQuiz(q))
+
+	hidden := &Quiz{ID: "hiddenquiz", Name: "Hidden geography", TeamID: "team", CreatorID: "creator", Visibility: VisibilityPrivate, Tags: []string{"europe"}}
+	require.NoError(t, p.store.StoreQuiz(hidden))
+	require.NoError(t, p.store.AddAvailableQuiz(hidden))
+
+	search := func(query string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, QuizSearchPath+"?"+query, nil)
+		r.Header.Set("Mattermost-User-ID", "player")
+		p.router.ServeHTTP(w, r)
+		return w
+	}
+
+	items := func(query string) []*model.PostActionOptions {
+		w := search(query)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		response := struct {
+			Items []*model.PostActionOptions `json:"items"`
+		}{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return response.Items
+	}
+
+	assert.Equal(t, []*model.PostActionOptions{{Text: "Quiz (Geography, easy)", Value: testQuizID}}, items("team_id=team&term=geo&tag=Europe"))
+	assert.Empty(t, items("team_id=team&tag=asia"))
+
+	assert.Equal(t, http.StatusBadRequest, search("term=geo").Code)
+	assert.Equal(t, http.StatusForbidden, search("team_id=other&term=geo").Code)
+}
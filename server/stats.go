@@ -0,0 +1,132 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// UserStats summarizes the games played by a user.
+type UserStats struct {
+	GamesPlayed       int
+	QuestionsAsked    int
+	QuestionsAnswered int
+	CorrectAnswers    int
+	BestScores        []StatsScore
+	FavouriteQuizzes  []StatsQuiz
+}
+
+type StatsScore struct {
+	QuizName   string
+	Score      float64
+	Questions  int
+	FinishedAt int64
+}
+
+type StatsQuiz struct {
+	Name  string
+	Plays int
+}
+
+// NewUserStats computes the statistics of the user from the results of the games
+// the user played.
+func NewUserStats(userID string, results []*GameResult) *UserStats {
+	stats := &UserStats{
+		BestScores:       []StatsScore{},
+		FavouriteQuizzes: []StatsQuiz{},
+	}
+
+	plays := map[string]*StatsQuiz{}
+	for _, r := range results {
+		username := ""
+		for name, id := range r.Players {
+			if id == userID {
+				username = name
+				break
+			}
+		}
+		if username == "" {
+			continue
+		}
+
+		stats.GamesPlayed++
+		stats.QuestionsAsked += len(r.Questions)
+		for _, answer := range r.Answers {
+			if answer.Username != username {
+				continue
+			}
+
+			stats.QuestionsAnswered++
+			if answer.Correct {
+				stats.CorrectAnswers++
+			}
+		}
+
+		stats.BestScores = append(stats.BestScores, StatsScore{
+			QuizName:   r.QuizName,
+			Score:      r.Score[username],
+			Questions:  len(r.Questions),
+			FinishedAt: r.FinishedAt,
+		})
+
+		if plays[r.QuizID] == nil {
+			plays[r.QuizID] = &StatsQuiz{}
+		}
+		plays[r.QuizID].Name = r.QuizName
+		plays[r.QuizID].Plays++
+	}
+
+	sort.SliceStable(stats.BestScores, func(i, j int) bool {
+		return stats.BestScores[i].Score > stats.BestScores[j].Score
+	})
+	if len(stats.BestScores) > StatsTopCount {
+		stats.BestScores = stats.BestScores[:StatsTopCount]
+	}
+
+	for _, quiz := range plays {
+		stats.FavouriteQuizzes = append(stats.FavouriteQuizzes, *quiz)
+	}
+	sort.Slice(stats.FavouriteQuizzes, func(i, j int) bool {
+		if stats.FavouriteQuizzes[i].Plays != stats.FavouriteQuizzes[j].Plays {
+			return stats.FavouriteQuizzes[i].Plays > stats.FavouriteQuizzes[j].Plays
+		}
+		return stats.FavouriteQuizzes[i].Name < stats.FavouriteQuizzes[j].Name
+	})
+	if len(stats.FavouriteQuizzes) > StatsTopCount {
+		stats.FavouriteQuizzes = stats.FavouriteQuizzes[:StatsTopCount]
+	}
+
+	return stats
+}
+
+// Accuracy returns the percentage of asked questions the user answered correctly.
+func (s *UserStats) Accuracy() int {
+	if s.QuestionsAsked == 0 {
+		return 0
+	}
+
+	return s.CorrectAnswers * 100 / s.QuestionsAsked
+}
+
+func (s *UserStats) ToMarkdown(username string) string {
+	if s.GamesPlayed == 0 {
+		return fmt.Sprintf("@%s has not played any quiz yet.", username)
+	}
+
+	out := fmt.Sprintf("#### Quiz stats for @%s\n\n", username)
+	out += fmt.Sprintf("Games played: %d\n\n", s.GamesPlayed)
+	out += fmt.Sprintf("Accuracy: %d%% (%d correct answers out of %d questions, %d answered)\n\n", s.Accuracy(), s.CorrectAnswers, s.QuestionsAsked, s.QuestionsAnswered)
+
+	out += "Best scores:"
+	for _, score := range s.BestScores {
+		date := time.Unix(0, score.FinishedAt*int64(time.Millisecond)).UTC().Format("2006-01-02")
+		out += fmt.Sprintf("\n- %s: %s points in %d questions (%s)", score.QuizName, formatScore(score.Score), score.Questions, date)
+	}
+
+	out += "\n\nFavourite quizzes:"
+	for _, quiz := range s.FavouriteQuizzes {
+		out += fmt.Sprintf("\n- %s: played %d times", quiz.Name, quiz.Plays)
+	}
+
+	return out
+}
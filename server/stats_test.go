@@ -0,0 +1,92 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserStats(t *testing.T) {
+	s := newTestStore()
+
+	questions := []Question{{ID: "q1"}, {ID: "q2"}}
+	results := []*GameResult{
+		{
+			ID: "game1", QuizID: "capitals", QuizName: "Capitals", Questions: questions,
+			Players: map[string]string{"alice": "aliceid", "bob": "bobid"},
+			Score:   map[string]float64{"alice": 2, "bob": 1},
+			Answers: []GameAnswer{
+				{QuestionID: "q1", Username: "alice", Correct: true},
+				{QuestionID: "q1", Username: "bob", Correct: true},
+				{QuestionID: "q2", Username: "alice", Correct: true},
+			},
+		},
+		{
+			ID: "game2", QuizID: "rivers", QuizName: "Rivers", Questions: questions,
+			Players: map[string]string{"alice2": "aliceid"},
+			Score:   map[string]float64{},
+			Answers: []GameAnswer{
+				{QuestionID: "q1", Username: "alice2", Correct: false},
+			},
+		},
+		{
+			ID: "game3", QuizID: "capitals", QuizName: "Capitals", Questions: questions,
+			Players: map[string]string{"alice": "aliceid"},
+			Score:   map[string]float64{"alice": 1},
+			Answers: []GameAnswer{
+				{QuestionID: "q2", Username: "alice", Correct: true},
+			},
+		},
+	}
+	for _, r := range results {
+		require.NoError(t, s.AddGameResult(r))
+	}
+
+	aliceResults, err := s.GetUserGameResults("aliceid")
+	require.NoError(t, err)
+	require.Len(t, aliceResults, 3)
+
+	stats := NewUserStats("aliceid", aliceResults)
+	assert.Equal(t, 3, stats.GamesPlayed)
+	assert.Equal(t, 6, stats.QuestionsAsked)
+	assert.Equal(t, 4, stats.QuestionsAnswered)
+	assert.Equal(t, 3, stats.CorrectAnswers)
+	assert.Equal(t, 50, stats.Accuracy())
+	assert.Equal(t, 2.0, stats.BestScores[0].Score)
+	assert.Equal(t, []StatsQuiz{{Name: "Capitals", Plays: 2}, {Name: "Rivers", Plays: 1}}, stats.FavouriteQuizzes)
+
+	bobResults, err := s.GetUserGameResults("bobid")
+	require.NoError(t, err)
+	require.Len(t, bobResults, 1)
+
+	noResults, err := s.GetUserGameResults("carolid")
+	require.NoError(t, err)
+	assert.Contains(t, NewUserStats("carolid", noResults).ToMarkdown("carol"), "has not played")
+}
+
+func TestGetVisibleGameResults(t *testing.T) {
+	p, api := newPermissionsTestPlugin(t)
+	api.On("HasPermissionToChannel", "viewer", "town-square", model.PERMISSION_READ_CHANNEL).Return(true)
+	api.On("HasPermissionToChannel", "viewer", mock.Anything, model.PERMISSION_READ_CHANNEL).Return(false)
+
+	results := []*GameResult{
+		{ID: "public", ChannelID: "town-square"},
+		{ID: "private", ChannelID: "secret"},
+		{ID: "solo", ChannelID: "dm"},
+	}
+
+	ids := func(results []*GameResult) []string {
+		out := []string{}
+		for _, r := range results {
+			out = append(out, r.ID)
+		}
+		return out
+	}
+
+	assert.Equal(t, []string{"public", "private", "solo"}, ids(p.getVisibleGameResults(results, "player", "player")))
+	assert.Equal(t, []string{"public", "private", "solo"}, ids(p.getVisibleGameResults(results, "player", "admin")))
+	assert.Equal(t, []string{"public"}, ids(p.getVisibleGameResults(results, "player", "viewer")))
+}
@@ -1,31 +1,101 @@
 package main
 
-import pluginapi "github.com/mattermost/mattermost-plugin-api"
+import (
+	"encoding/json"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+
+	pluginapi "github.com/mattermost/mattermost-plugin-api"
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/pkg/errors"
+)
 
 type Store interface {
 	StoreQuiz(q *Quiz) error
 	GetQuiz(id string) (*Quiz, error)
 	DeleteQuiz(id string) error
 
+	StoreQuizRevision(q *Quiz, authorID string) ([]string, error)
+	GetQuizRevisions(quizID string) ([]*QuizRevisionInfo, error)
+	GetQuizRevision(quizID string, number int) (*QuizRevision, error)
+
 	AddAvailableQuiz(q *Quiz) error
+	IsAvailableQuiz(id string) (bool, error)
 	GetAvailableQuizes() []*Quiz
 
 	GetGame(id string) (*Game, error)
 	StoreGame(g *Game) error
+	UpdateGame(id string, update func(g *Game) error) (*Game, error)
 	DeleteGame(id string) error
 
+	AddGameResult(r *GameResult) error
+	GetGameResult(id string) (*GameResult, error)
+	GetUserGameResults(userID string) ([]*GameResult, error)
+
+	AddLeaderboardScores(scope LeaderboardScope, scopeID, bucket string, scores map[string]float64) error
+	GetLeaderboard(scope LeaderboardScope, scopeID, bucket string) (*Leaderboard, error)
+	AddPinnedLeaderboard(pinned *PinnedLeaderboard) error
+	GetPinnedLeaderboards() ([]*PinnedLeaderboard, error)
+	RemovePinnedLeaderboard(postID string) error
+
 	StoreCourse(c *Course) error
 	GetCourse(id string) (*Course, error)
 	AddAvailableCourse(c *Course) error
+	IsAvailableCourse(id string) (bool, error)
+	GetAvailableCourses() []*Course
 	DeleteCourse(id string) error
+
+	GetCourseProgress(userID, courseID string) (*CourseProgress, error)
+	UpdateCourseProgress(userID, courseID string, update func(cp *CourseProgress) error) (*CourseProgress, error)
+
+	GetSchemaVersion() (int, error)
+	SetSchemaVersion(version int) error
+	ListQuizIDs() ([]string, error)
+	ListGameIDs() ([]string, error)
+	ListCourseIDs() ([]string, error)
+}
+
+// ErrNotFound is returned by the store when the quiz or course does not exist. Its
+// message can be shown to the users.
+type ErrNotFound struct {
+	Kind string
+	ID   string
+}
+
+func (e *ErrNotFound) Error() string {
+	return e.Kind + " not found"
+}
+
+// isNotFound reports whether the error is an ErrNotFound.
+func isNotFound(err error) bool {
+	var notFound *ErrNotFound
+	return errors.As(err, &notFound)
 }
 
 const (
-	KVQuizPrefix   = "quiz_"
-	KVQuizList     = "quizList"
-	KVGamePrefix   = "game_"
-	KVCoursePrefix = "course_"
-	KVCourseList   = "courseList"
+	KVQuizPrefix         = "quiz_"
+	KVQuizList           = "quizList"
+	KVRevisionsPrefix    = "revisions_"
+	KVRevisionPrefix     = "revision_"
+	KVGamePrefix         = "game_"
+	KVCoursePrefix       = "course_"
+	KVCourseList         = "courseList"
+	KVProgressPrefix     = "progress_"
+	KVResultPrefix       = "result_"
+	KVHistoryPrefix      = "history_"
+	KVLeaderboardPrefix  = "lb_"
+	KVPinnedLeaderboards = "pinnedLeaderboards"
+	KVSchemaVersion      = "schemaVersion"
+
+	KVAtomicRetries = 50
+
+	// MaxQuizRevisions is the number of revisions kept for each quiz. Older revisions
+	// are deleted when new ones are stored.
+	MaxQuizRevisions = 50
+
+	kvListPageSize = 1000
 )
 
 type store struct {
@@ -57,6 +127,38 @@ func (s *store) StoreGame(g *Game) error {
 	return nil
 }
 
+// UpdateGame applies update to the stored game using compare and set, so concurrent
+// updates on the same game are retried instead of overwriting each other. If update
+// returns an error, nothing is stored and the error is returned as is.
+func (s *store) UpdateGame(id string, update func(g *Game) error) (*Game, error) {
+	var g *Game
+	err := s.atomicUpdate(getGameKey(id), func(oldValue []byte) (interface{}, error) {
+		g = nil
+		if len(oldValue) == 0 {
+			return nil, errAtomicUnchanged
+		}
+
+		updated := &Game{}
+		err := json.Unmarshal(oldValue, updated)
+		if err != nil {
+			return nil, err
+		}
+
+		err = update(updated)
+		if err != nil {
+			return nil, err
+		}
+
+		g = updated
+		return g, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return g, nil
+}
+
 func (s *store) DeleteGame(id string) error {
 	err := s.mm.KV.Delete(getGameKey(id))
 	if err != nil {
@@ -66,6 +168,243 @@ func (s *store) DeleteGame(id string) error {
 	return nil
 }
 
+// AddGameResult stores the results of a finished game and adds it to the history of
+// every player.
+func (s *store) AddGameResult(r *GameResult) error {
+	_, err := s.mm.KV.Set(getResultKey(r.ID), r)
+	if err != nil {
+		return err
+	}
+
+	for _, userID := range r.PlayerIDs() {
+		err = s.appendToList(getHistoryKey(userID), r.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetUserGameResults returns the results of the games the user played, oldest first.
+func (s *store) GetUserGameResults(userID string) ([]*GameResult, error) {
+	ids := []string{}
+	err := s.mm.KV.Get(getHistoryKey(userID), &ids)
+	if err != nil {
+		return nil, err
+	}
+
+	out := []*GameResult{}
+	for _, id := range ids {
+		r, err := s.GetGameResult(id)
+		if err != nil {
+			s.mm.Log.Debug("Error getting game result", "id", id, "err", err)
+			continue
+		}
+
+		if r == nil {
+			s.mm.Log.Debug("Game result not found", "id", id)
+			continue
+		}
+
+		out = append(out, r)
+	}
+
+	return out, nil
+}
+
+// AddLeaderboardScores adds the scores of a game to a leaderboard bucket using compare
+// and set, so games finishing at the same time are all counted.
+func (s *store) AddLeaderboardScores(scope LeaderboardScope, scopeID, bucket string, scores map[string]float64) error {
+	return s.atomicUpdate(getLeaderboardKey(scope, scopeID, bucket), func(oldValue []byte) (interface{}, error) {
+		l := NewLeaderboard()
+		err := unmarshalValue(oldValue, l)
+		if err != nil {
+			return nil, err
+		}
+
+		l.AddGame(scores)
+		return l, nil
+	})
+}
+
+// GetLeaderboard returns the leaderboard bucket, or an empty leaderboard if no game
+// finished in it.
+func (s *store) GetLeaderboard(scope LeaderboardScope, scopeID, bucket string) (*Leaderboard, error) {
+	l := NewLeaderboard()
+	err := s.mm.KV.Get(getLeaderboardKey(scope, scopeID, bucket), l)
+	if err != nil {
+		return nil, err
+	}
+
+	return l, nil
+}
+
+func (s *store) AddPinnedLeaderboard(pinned *PinnedLeaderboard) error {
+	return s.updatePinnedLeaderboards(func(pinnedLeaderboards []*PinnedLeaderboard) []*PinnedLeaderboard {
+		return append(pinnedLeaderboards, pinned)
+	})
+}
+
+func (s *store) GetPinnedLeaderboards() ([]*PinnedLeaderboard, error) {
+	pinnedLeaderboards := []*PinnedLeaderboard{}
+	err := s.mm.KV.Get(KVPinnedLeaderboards, &pinnedLeaderboards)
+	if err != nil {
+		return nil, err
+	}
+
+	return pinnedLeaderboards, nil
+}
+
+func (s *store) RemovePinnedLeaderboard(postID string) error {
+	return s.updatePinnedLeaderboards(func(pinnedLeaderboards []*PinnedLeaderboard) []*PinnedLeaderboard {
+		out := []*PinnedLeaderboard{}
+		for _, pinned := range pinnedLeaderboards {
+			if pinned.PostID != postID {
+				out = append(out, pinned)
+			}
+		}
+		return out
+	})
+}
+
+func (s *store) updatePinnedLeaderboards(update func([]*PinnedLeaderboard) []*PinnedLeaderboard) error {
+	return s.atomicUpdate(KVPinnedLeaderboards, func(oldValue []byte) (interface{}, error) {
+		pinnedLeaderboards := []*PinnedLeaderboard{}
+		err := unmarshalValue(oldValue, &pinnedLeaderboards)
+		if err != nil {
+			return nil, err
+		}
+
+		return update(pinnedLeaderboards), nil
+	})
+}
+
+// errAtomicUnchanged is returned by the update function of atomicUpdate to leave the
+// stored value as it is.
+var errAtomicUnchanged = errors.New("unchanged")
+
+// atomicUpdate applies update to the value stored under key using compare and set, and
+// retries when the value changed in between, so concurrent updates are not lost. update
+// gets the stored value, empty if there is none, and returns the value to store. If it
+// returns errAtomicUnchanged nothing is stored, and other errors are returned as is.
+func (s *store) atomicUpdate(key string, update func(oldValue []byte) (interface{}, error)) error {
+	for i := 0; i < KVAtomicRetries; i++ {
+		var oldValue []byte
+		err := s.mm.KV.Get(key, &oldValue)
+		if err != nil {
+			return err
+		}
+
+		newValue, err := update(oldValue)
+		if err == errAtomicUnchanged {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		saved, err := s.mm.KV.Set(key, newValue, pluginapi.SetAtomic(oldValue))
+		if err != nil {
+			return err
+		}
+
+		if saved {
+			return nil
+		}
+
+		time.Sleep(time.Duration(rand.Intn(10)+1) * time.Millisecond)
+	}
+
+	return errors.Errorf("could not update %s after %d retries", key, KVAtomicRetries)
+}
+
+// unmarshalValue decodes a stored value into v, leaving v as it is if nothing is stored.
+func unmarshalValue(data []byte, v interface{}) error {
+	if len(data) == 0 {
+		return nil
+	}
+
+	return json.Unmarshal(data, v)
+}
+
+// appendToList adds id to the list of IDs stored under key using compare and set, so
+// concurrent appends are not lost.
+func (s *store) appendToList(key, id string) error {
+	return s.atomicUpdate(key, func(oldValue []byte) (interface{}, error) {
+		ids := []string{}
+		err := unmarshalValue(oldValue, &ids)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, existing := range ids {
+			if existing == id {
+				return nil, errAtomicUnchanged
+			}
+		}
+
+		return append(ids, id), nil
+	})
+}
+
+// isInList reports whether id is in the list of IDs stored under key.
+func (s *store) isInList(key, id string) (bool, error) {
+	ids := []string{}
+	err := s.mm.KV.Get(key, &ids)
+	if err != nil {
+		return false, err
+	}
+
+	for _, existing := range ids {
+		if existing == id {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// removeFromList removes ids from the list of IDs stored under key using compare and
+// set, so concurrent changes are not lost.
+func (s *store) removeFromList(key string, ids []string) error {
+	remove := map[string]bool{}
+	for _, id := range ids {
+		remove[id] = true
+	}
+
+	return s.atomicUpdate(key, func(oldValue []byte) (interface{}, error) {
+		list := []string{}
+		err := unmarshalValue(oldValue, &list)
+		if err != nil {
+			return nil, err
+		}
+
+		kept := []string{}
+		for _, id := range list {
+			if !remove[id] {
+				kept = append(kept, id)
+			}
+		}
+
+		if len(kept) == len(list) {
+			return nil, errAtomicUnchanged
+		}
+
+		return kept, nil
+	})
+}
+
+func (s *store) GetGameResult(id string) (*GameResult, error) {
+	var r *GameResult
+	err := s.mm.KV.Get(getResultKey(id), &r)
+	if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
 func (s *store) GetAvailableQuizes() []*Quiz {
 	out := []*Quiz{}
 
@@ -76,85 +415,211 @@ func (s *store) GetAvailableQuizes() []*Quiz {
 		return out
 	}
 
+	missing := []string{}
 	for _, id := range quizIDList {
 		q, err := s.GetQuiz(id)
-		if err != nil {
-			s.mm.Log.Debug("Error getting quiz", "id", id, "err", err)
+		if isNotFound(err) {
+			missing = append(missing, id)
 			continue
 		}
-
-		if q == nil {
-			s.mm.Log.Debug("Quiz not found", "id", id)
+		if err != nil {
+			s.mm.Log.Debug("Error getting quiz", "id", id, "err", err)
 			continue
 		}
 
 		out = append(out, q)
 	}
 
+	if len(missing) > 0 {
+		s.mm.Log.Info("Removing missing quizzes from the quiz list", "ids", missing)
+		err = s.removeFromList(KVQuizList, missing)
+		if err != nil {
+			s.mm.Log.Debug("Cannot remove missing quizzes from the quiz list", "err", err)
+		}
+	}
+
 	return out
 }
 
 func (s *store) AddAvailableQuiz(q *Quiz) error {
-	quizIDList := []string{}
-	err := s.mm.KV.Get(KVQuizList, &quizIDList)
+	return s.appendToList(KVQuizList, q.ID)
+}
+
+// IsAvailableQuiz reports whether the quiz is saved, without getting every saved quiz.
+func (s *store) IsAvailableQuiz(id string) (bool, error) {
+	return s.isInList(KVQuizList, id)
+}
+
+func (s *store) removeAvailableQuiz(id string) error {
+	return s.removeFromList(KVQuizList, []string{id})
+}
+
+func (s *store) DeleteQuiz(id string) error {
+	err := s.removeAvailableQuiz(id)
 	if err != nil {
 		return err
 	}
 
-	for _, id := range quizIDList {
-		if id == q.ID {
-			return nil
-		}
+	err = s.mm.KV.Delete(getQuizKey(id))
+	if err != nil {
+		return err
 	}
 
-	quizIDList = append(quizIDList, q.ID)
+	revisions, err := s.GetQuizRevisions(id)
+	if err != nil {
+		return err
+	}
+
+	for _, info := range revisions {
+		err = s.mm.KV.Delete(getRevisionKey(id, info.Number))
+		if err != nil {
+			return err
+		}
+	}
 
-	_, err = s.mm.KV.Set(KVQuizList, quizIDList)
+	err = s.mm.KV.Delete(getRevisionsKey(id))
 	if err != nil {
 		return err
 	}
+
 	return nil
 }
 
-func (s *store) removeAvailableQuiz(id string) error {
-	quizIDList := []string{}
-	err := s.mm.KV.Get(KVQuizList, &quizIDList)
+// StoreQuizRevision stores the quiz as a new revision authored by the user, and sets
+// the number of the revision in the quiz. Quizzes saved before revisions existed get
+// their stored version recorded first, so their changes can be rolled back too. It
+// returns the images that were only used by the old revisions deleted to make room.
+func (s *store) StoreQuizRevision(q *Quiz, authorID string) ([]string, error) {
+	revisions, err := s.GetQuizRevisions(q.ID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	changed := false
-	for i, qid := range quizIDList {
-		if qid == id {
-			quizIDList = append(quizIDList[0:i], quizIDList[i+1:]...)
-			changed = true
-			break
+	if len(revisions) == 0 {
+		var stored *Quiz
+		err = s.mm.KV.Get(getQuizKey(q.ID), &stored)
+		if err != nil {
+			return nil, err
+		}
+
+		if stored != nil {
+			_, _, err = s.addQuizRevision(stored, stored.CreatorID, 0)
+			if err != nil {
+				return nil, err
+			}
 		}
 	}
 
-	if !changed {
-		return nil
+	number, unusedImages, err := s.addQuizRevision(q, authorID, model.GetMillis())
+	if err != nil {
+		return nil, err
 	}
 
-	_, err = s.mm.KV.Set(KVQuizList, quizIDList)
+	q.Revision = number
+	err = s.StoreQuiz(q)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	return nil
+
+	return unusedImages, nil
 }
 
-func (s *store) DeleteQuiz(id string) error {
-	err := s.removeAvailableQuiz(id)
+// addQuizRevision reserves the next revision number of the quiz using compare and set,
+// deletes the revisions beyond MaxQuizRevisions and stores the copy of the quiz. It
+// returns the number of the revision and the images of the deleted revisions that no
+// kept revision uses. The new revision is kept, so the images of the quiz are never
+// returned.
+func (s *store) addQuizRevision(q *Quiz, authorID string, createdAt int64) (int, []string, error) {
+	var info *QuizRevisionInfo
+	var pruned []*QuizRevisionInfo
+	var unusedImages []string
+	err := s.atomicUpdate(getRevisionsKey(q.ID), func(oldValue []byte) (interface{}, error) {
+		revisions := []*QuizRevisionInfo{}
+		err := unmarshalValue(oldValue, &revisions)
+		if err != nil {
+			return nil, err
+		}
+
+		info = &QuizRevisionInfo{Number: 1, AuthorID: authorID, CreatedAt: createdAt, ImageFileIDs: q.ImageFileIDs()}
+		if len(revisions) > 0 {
+			info.Number = revisions[len(revisions)-1].Number + 1
+		}
+		revisions = append(revisions, info)
+
+		pruned = nil
+		unusedImages = nil
+		if len(revisions) > MaxQuizRevisions {
+			pruned = revisions[:len(revisions)-MaxQuizRevisions]
+			revisions = revisions[len(revisions)-MaxQuizRevisions:]
+			unusedImages = getUnusedImageFileIDs(pruned, revisions)
+		}
+
+		return revisions, nil
+	})
 	if err != nil {
-		return err
+		return 0, nil, err
 	}
 
-	err = s.mm.KV.Delete(getQuizKey(id))
+	for _, old := range pruned {
+		err = s.mm.KV.Delete(getRevisionKey(q.ID, old.Number))
+		if err != nil {
+			s.mm.Log.Debug("Cannot delete old quiz revision", "id", q.ID, "revision", old.Number, "err", err)
+		}
+	}
+
+	revision := &QuizRevision{QuizRevisionInfo: *info, Quiz: *q}
+	revision.Quiz.Revision = info.Number
+	_, err = s.mm.KV.Set(getRevisionKey(q.ID, info.Number), revision)
 	if err != nil {
-		return err
+		return 0, nil, err
 	}
 
-	return nil
+	return info.Number, unusedImages, nil
+}
+
+// getUnusedImageFileIDs returns the images of the pruned revisions that none of the kept
+// revisions use.
+func getUnusedImageFileIDs(pruned, kept []*QuizRevisionInfo) []string {
+	used := map[string]bool{}
+	for _, info := range kept {
+		for _, id := range info.ImageFileIDs {
+			used[id] = true
+		}
+	}
+
+	out := []string{}
+	for _, info := range pruned {
+		for _, id := range info.ImageFileIDs {
+			if !used[id] {
+				used[id] = true
+				out = append(out, id)
+			}
+		}
+	}
+
+	return out
+}
+
+// GetQuizRevisions returns the revisions kept for the quiz, oldest first.
+func (s *store) GetQuizRevisions(quizID string) ([]*QuizRevisionInfo, error) {
+	revisions := []*QuizRevisionInfo{}
+	err := s.mm.KV.Get(getRevisionsKey(quizID), &revisions)
+	if err != nil {
+		return nil, err
+	}
+
+	return revisions, nil
+}
+
+// GetQuizRevision returns the revision of the quiz, or nil if it is not kept.
+func (s *store) GetQuizRevision(quizID string, number int) (*QuizRevision, error) {
+	var revision *QuizRevision
+	err := s.mm.KV.Get(getRevisionKey(quizID, number), &revision)
+	if err != nil {
+		return nil, err
+	}
+
+	return revision, nil
 }
 
 func (s *store) StoreQuiz(q *Quiz) error {
@@ -166,13 +631,18 @@ func (s *store) StoreQuiz(q *Quiz) error {
 	return nil
 }
 
+// GetQuiz returns the quiz, or an ErrNotFound error if it does not exist.
 func (s *store) GetQuiz(id string) (*Quiz, error) {
-	q := &Quiz{}
-	err := s.mm.KV.Get(getQuizKey(id), q)
+	var q *Quiz
+	err := s.mm.KV.Get(getQuizKey(id), &q)
 	if err != nil {
 		return nil, err
 	}
 
+	if q == nil {
+		return nil, &ErrNotFound{Kind: "quiz", ID: id}
+	}
+
 	return q, nil
 }
 
@@ -185,36 +655,59 @@ func (s *store) StoreCourse(c *Course) error {
 	return nil
 }
 
+// GetCourse returns the course, or an ErrNotFound error if it does not exist.
 func (s *store) GetCourse(id string) (*Course, error) {
-	c := &Course{}
-	err := s.mm.KV.Get(getCourseKey(id), c)
+	var c *Course
+	err := s.mm.KV.Get(getCourseKey(id), &c)
 	if err != nil {
 		return nil, err
 	}
 
+	if c == nil {
+		return nil, &ErrNotFound{Kind: "course", ID: id}
+	}
+
 	return c, nil
 }
 
-func (s *store) AddAvailableCourse(c *Course) error {
+func (s *store) GetAvailableCourses() []*Course {
+	out := []*Course{}
+
 	courseIDList := []string{}
 	err := s.mm.KV.Get(KVCourseList, &courseIDList)
 	if err != nil {
-		return err
+		s.mm.Log.Debug("Cannot get course list", "error", err)
+		return out
 	}
 
+	missing := []string{}
 	for _, id := range courseIDList {
-		if id == c.ID {
-			return nil
+		c, err := s.GetCourse(id)
+		if isNotFound(err) {
+			missing = append(missing, id)
+			continue
+		}
+		if err != nil {
+			s.mm.Log.Debug("Error getting course", "id", id, "err", err)
+			continue
 		}
-	}
 
-	courseIDList = append(courseIDList, c.ID)
+		out = append(out, c)
+	}
 
-	_, err = s.mm.KV.Set(KVCourseList, courseIDList)
-	if err != nil {
-		return err
+	if len(missing) > 0 {
+		s.mm.Log.Info("Removing missing courses from the course list", "ids", missing)
+		err = s.removeFromList(KVCourseList, missing)
+		if err != nil {
+			s.mm.Log.Debug("Cannot remove missing courses from the course list", "err", err)
+		}
 	}
-	return nil
+
+	return out
+}
+
+func (s *store) AddAvailableCourse(c *Course) error {
+	return s.appendToList(KVCourseList, c.ID)
 }
 
 func (s *store) DeleteCourse(id string) error {
@@ -231,37 +724,130 @@ func (s *store) DeleteCourse(id string) error {
 	return nil
 }
 
+// IsAvailableCourse reports whether the course is saved, without getting every saved
+// course.
+func (s *store) IsAvailableCourse(id string) (bool, error) {
+	return s.isInList(KVCourseList, id)
+}
+
 func (s *store) removeAvailableCourse(id string) error {
-	courseIDList := []string{}
-	err := s.mm.KV.Get(KVCourseList, &courseIDList)
+	return s.removeFromList(KVCourseList, []string{id})
+}
+
+// GetCourseProgress returns the progress of the user on the course, or nil if the user
+// never started it.
+func (s *store) GetCourseProgress(userID, courseID string) (*CourseProgress, error) {
+	progress := map[string]*CourseProgress{}
+	err := s.mm.KV.Get(getProgressKey(userID), &progress)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	changed := false
-	for i, qid := range courseIDList {
-		if qid == id {
-			courseIDList = append(courseIDList[0:i], courseIDList[i+1:]...)
-			changed = true
-			break
+	return progress[courseID], nil
+}
+
+// UpdateCourseProgress applies update to the progress of the user on the course, creating
+// it if the user never started the course. All the progress of a user is stored under a
+// single key, and it is updated using compare and set like games are.
+func (s *store) UpdateCourseProgress(userID, courseID string, update func(cp *CourseProgress) error) (*CourseProgress, error) {
+	var cp *CourseProgress
+	err := s.atomicUpdate(getProgressKey(userID), func(oldValue []byte) (interface{}, error) {
+		progress := map[string]*CourseProgress{}
+		err := unmarshalValue(oldValue, &progress)
+		if err != nil {
+			return nil, err
+		}
+
+		cp = progress[courseID]
+		if cp == nil {
+			cp = NewCourseProgress(courseID)
+			progress[courseID] = cp
+		}
+
+		err = update(cp)
+		if err != nil {
+			return nil, err
 		}
+
+		return progress, nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	if !changed {
-		return nil
+	return cp, nil
+}
+
+// GetSchemaVersion returns the version of the shape of the stored data. Data stored
+// before versions existed is version 0.
+func (s *store) GetSchemaVersion() (int, error) {
+	version := 0
+	err := s.mm.KV.Get(KVSchemaVersion, &version)
+	if err != nil {
+		return 0, err
 	}
 
-	_, err = s.mm.KV.Set(KVCourseList, courseIDList)
+	return version, nil
+}
+
+func (s *store) SetSchemaVersion(version int) error {
+	_, err := s.mm.KV.Set(KVSchemaVersion, version)
 	if err != nil {
 		return err
 	}
+
 	return nil
 }
 
+// ListQuizIDs returns the IDs of every stored quiz, saved or not.
+func (s *store) ListQuizIDs() ([]string, error) {
+	return s.listIDs(KVQuizPrefix)
+}
+
+// ListGameIDs returns the IDs of every running game.
+func (s *store) ListGameIDs() ([]string, error) {
+	return s.listIDs(KVGamePrefix)
+}
+
+// ListCourseIDs returns the IDs of every stored course, saved or not.
+func (s *store) ListCourseIDs() ([]string, error) {
+	return s.listIDs(KVCoursePrefix)
+}
+
+// listIDs returns the IDs of the keys with the prefix, going through every key of the
+// plugin.
+func (s *store) listIDs(prefix string) ([]string, error) {
+	ids := []string{}
+	for page := 0; ; page++ {
+		keys, err := s.mm.KV.ListKeys(page, kvListPageSize)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, key := range keys {
+			if strings.HasPrefix(key, prefix) {
+				ids = append(ids, strings.TrimPrefix(key, prefix))
+			}
+		}
+
+		if len(keys) < kvListPageSize {
+			return ids, nil
+		}
+	}
+}
+
 func getQuizKey(id string) string {
 	return KVQuizPrefix + id
 }
 
+func getRevisionsKey(quizID string) string {
+	return KVRevisionsPrefix + quizID
+}
+
+func getRevisionKey(quizID string, number int) string {
+	return KVRevisionPrefix + quizID + "_" + strconv.Itoa(number)
+}
+
 func getGameKey(id string) string {
 	return KVGamePrefix + id
 }
@@ -269,3 +855,19 @@ func getGameKey(id string) string {
 func getCourseKey(id string) string {
 	return KVCoursePrefix + id
 }
+
+func getProgressKey(userID string) string {
+	return KVProgressPrefix + userID
+}
+
+func getResultKey(id string) string {
+	return KVResultPrefix + id
+}
+
+func getHistoryKey(userID string) string {
+	return KVHistoryPrefix + userID
+}
+
+func getLeaderboardKey(scope LeaderboardScope, scopeID, bucket string) string {
+	return KVLeaderboardPrefix + string(scope) + "_" + scopeID + "_" + bucket
+}
@@ -0,0 +1,304 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+
+	pluginapi "github.com/mattermost/mattermost-plugin-api"
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/mattermost/mattermost-server/v5/plugin/plugintest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryAPI is a plugin API whose KV store lives in memory and honours
+// compare and set, so the store can be exercised without a server.
+type memoryAPI struct {
+	*plugintest.API
+	lock sync.Mutex
+	kv   map[string][]byte
+}
+
+func newMemoryAPI() *memoryAPI {
+	return &memoryAPI{
+		API: &plugintest.API{},
+		kv:  map[string][]byte{},
+	}
+}
+
+func (m *memoryAPI) KVGet(key string) ([]byte, *model.AppError) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	return m.kv[key], nil
+}
+
+func (m *memoryAPI) KVSetWithOptions(key string, value []byte, options model.PluginKVSetOptions) (bool, *model.AppError) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if options.Atomic {
+		current, ok := m.kv[key]
+		if options.OldValue == nil && ok {
+			return false, nil
+		}
+		if options.OldValue != nil && !bytes.Equal(current, options.OldValue) {
+			return false, nil
+		}
+	}
+
+	if value == nil {
+		delete(m.kv, key)
+		return true, nil
+	}
+
+	m.kv[key] = value
+	return true, nil
+}
+
+func (m *memoryAPI) KVList(page, perPage int) ([]string, *model.AppError) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	keys := []string{}
+	for key := range m.kv {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	start := page * perPage
+	if start >= len(keys) {
+		return []string{}, nil
+	}
+	end := start + perPage
+	if end > len(keys) {
+		end = len(keys)
+	}
+
+	return keys[start:end], nil
+}
+
+func (m *memoryAPI) KVDeleteAll() *model.AppError {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.kv = map[string][]byte{}
+	return nil
+}
+
+func (m *memoryAPI) LogDebug(msg string, keyValuePairs ...interface{}) {}
+func (m *memoryAPI) LogInfo(msg string, keyValuePairs ...interface{})  {}
+func (m *memoryAPI) LogWarn(msg string, keyValuePairs ...interface{})  {}
+func (m *memoryAPI) LogError(msg string, keyValuePairs ...interface{}) {}
+
+func newTestStore() Store {
+	return NewStore(pluginapi.NewClient(newMemoryAPI()))
+}
+
+func TestUpdateGameConcurrentAnswers(t *testing.T) {
+	s := newTestStore()
+
+	question := Question{ID: "q1", Question: "Capital of France?", CorrectAnswer: "Paris"}
+	err := s.StoreGame(&Game{
+		RootPostID:         "game",
+		Type:               GameTypeParty,
+		ScoringType:        ScoringTypeAll,
+		Score:              map[string]float64{},
+		AlreadyAnswered:    map[string]bool{},
+		RemainingQuestions: []Question{question},
+		NQuestions:         1,
+	})
+	require.NoError(t, err)
+
+	const players = 30
+	var wg sync.WaitGroup
+	errs := make(chan error, players)
+	for i := 0; i < players; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			username := fmt.Sprintf("player%d", i)
+			_, err := s.UpdateGame("game", func(g *Game) error {
+				return g.RecordAnswer(question.ID, username, "", i%2 == 0)
+			})
+			errs <- err
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		assert.NoError(t, err)
+	}
+
+	g, err := s.GetGame("game")
+	require.NoError(t, err)
+	require.NotNil(t, g)
+
+	assert.Len(t, g.AlreadyAnswered, players)
+	assert.Len(t, g.RightPlayers, players/2)
+	assert.Len(t, g.Score, players/2)
+	for i := 0; i < players; i += 2 {
+		assert.Equal(t, 1.0, g.Score[fmt.Sprintf("player%d", i)])
+	}
+}
+
+func TestUpdateGameRejectsRepeatedAnswers(t *testing.T) {
+	s := newTestStore()
+
+	err := s.StoreGame(&Game{
+		RootPostID:         "game",
+		RemainingQuestions: []Question{{ID: "q1"}, {ID: "q2"}},
+	})
+	require.NoError(t, err)
+
+	_, err = s.UpdateGame("game", func(g *Game) error {
+		return g.RecordAnswer("q1", "player", "", true)
+	})
+	require.NoError(t, err)
+
+	_, err = s.UpdateGame("game", func(g *Game) error {
+		return g.RecordAnswer("q1", "player", "", true)
+	})
+	assert.Equal(t, ErrAlreadyAnswered, err)
+
+	_, err = s.UpdateGame("game", func(g *Game) error {
+		return g.RecordAnswer("q2", "other", "", true)
+	})
+	assert.Equal(t, ErrQuestionPassed, err)
+
+	g, err := s.GetGame("game")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]float64{"player": 1}, g.Score)
+}
+
+func TestUpdateGameNotFound(t *testing.T) {
+	s := newTestStore()
+
+	g, err := s.UpdateGame("missing", func(g *Game) error {
+		t.Fatal("update should not be called")
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Nil(t, g)
+}
+
+func TestUpdateCourseProgress(t *testing.T) {
+	s := newTestStore()
+
+	c := &Course{
+		ID: "course",
+		Lessons: []*Lesson{
+			{Resources: []*Resource{{Name: "Intro", Type: string(ResourceTypeText)}}},
+			{Resources: []*Resource{{Name: "Check", Type: string(ResourceTypeQuiz), Content: "quiz"}}},
+		},
+	}
+
+	cp, err := s.GetCourseProgress("user", c.ID)
+	require.NoError(t, err)
+	assert.Nil(t, cp)
+
+	_, err = s.UpdateCourseProgress("user", c.ID, func(cp *CourseProgress) error {
+		cp.VisitLesson(c, 1)
+		return nil
+	})
+	require.NoError(t, err)
+
+	cp, err = s.GetCourseProgress("user", c.ID)
+	require.NoError(t, err)
+	require.NotNil(t, cp)
+	assert.Equal(t, 1, cp.CurrentLesson)
+	assert.True(t, cp.CompletedLessons[0])
+	assert.False(t, cp.IsComplete(c))
+
+	cp.CompletedLessons[1] = true
+	assert.Equal(t, []string{"Check"}, cp.PendingQuizzes(c))
+
+	cp.RecordQuizScore(getCourseResourceKey(1, 0), 1, 4)
+	assert.False(t, cp.IsComplete(c))
+
+	cp.RecordQuizScore(getCourseResourceKey(1, 0), 3, 4)
+	cp.RecordQuizScore(getCourseResourceKey(1, 0), 0, 4)
+	assert.Equal(t, CourseQuizScore{Score: 3, Total: 4}, cp.QuizScores[getCourseResourceKey(1, 0)])
+	assert.True(t, cp.IsComplete(c))
+
+	other, err := s.GetCourseProgress("other", c.ID)
+	require.NoError(t, err)
+	assert.Nil(t, other)
+}
+
+func TestAddAvailableConcurrently(t *testing.T) {
+	s := newTestStore()
+
+	const n = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, 2*n)
+	for i := 0; i < n; i++ {
+		q := &Quiz{ID: fmt.Sprintf("quiz%d", i)}
+		c := &Course{ID: fmt.Sprintf("course%d", i)}
+		require.NoError(t, s.StoreQuiz(q))
+		require.NoError(t, s.StoreCourse(c))
+
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			errs <- s.AddAvailableQuiz(q)
+		}()
+		go func() {
+			defer wg.Done()
+			errs <- s.AddAvailableCourse(c)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		assert.NoError(t, err)
+	}
+
+	assert.Len(t, s.GetAvailableQuizes(), n)
+	assert.Len(t, s.GetAvailableCourses(), n)
+}
+
+func TestGetMissingQuizAndCourse(t *testing.T) {
+	s := newTestStore()
+
+	q, err := s.GetQuiz("missing")
+	assert.Nil(t, q)
+	assert.True(t, isNotFound(err))
+	assert.EqualError(t, err, "quiz not found")
+
+	c, err := s.GetCourse("missing")
+	assert.Nil(t, c)
+	assert.True(t, isNotFound(err))
+	assert.EqualError(t, err, "course not found")
+
+	assert.False(t, isNotFound(nil))
+	assert.False(t, isNotFound(errors.New("other")))
+}
+
+func TestGetAvailablePrunesMissingIDs(t *testing.T) {
+	s := newTestStore()
+
+	for _, id := range []string{"first", "second"} {
+		require.NoError(t, s.StoreQuiz(&Quiz{ID: id}))
+		require.NoError(t, s.AddAvailableQuiz(&Quiz{ID: id}))
+		require.NoError(t, s.StoreCourse(&Course{ID: id}))
+		require.NoError(t, s.AddAvailableCourse(&Course{ID: id}))
+	}
+	require.NoError(t, s.AddAvailableQuiz(&Quiz{ID: "dangling"}))
+	require.NoError(t, s.AddAvailableCourse(&Course{ID: "dangling"}))
+
+	assert.Len(t, s.GetAvailableQuizes(), 2)
+	assert.Len(t, s.GetAvailableCourses(), 2)
+
+	var quizIDs, courseIDs []string
+	require.NoError(t, s.(*store).mm.KV.Get(KVQuizList, &quizIDs))
+	require.NoError(t, s.(*store).mm.KV.Get(KVCourseList, &courseIDs))
+	assert.Equal(t, []string{"first", "second"}, quizIDs)
+	assert.Equal(t, []string{"first", "second"}, courseIDs)
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-api/cluster"
+	"github.com/mattermost/mattermost-server/v5/model"
+)
+
+// TimerUpdateInterval is how often the countdown of a timed question is refreshed.
+const TimerUpdateInterval = 10 * time.Second
+
+// initializeTimers starts the scheduler used by timed questions. Jobs are persisted in
+// the KV store, so timers scheduled before a restart are resumed here.
+func (p *Plugin) initializeTimers() error {
+	p.scheduler = cluster.GetJobOnceScheduler(p.API)
+
+	err := p.scheduler.SetCallback(p.runQuestionTimer)
+	if err != nil {
+		return err
+	}
+
+	return p.scheduler.Start()
+}
+
+// scheduleQuestionTimer schedules the next run of the timer of the game current question,
+// either to refresh the countdown or to reveal the solution when the time is over. Only
+// the last scheduled job of a game is honored, so jobs left behind by questions that were
+// already passed do nothing.
+func (p *Plugin) scheduleQuestionTimer(gameID string) error {
+	if p.scheduler == nil {
+		return nil
+	}
+
+	runAt := time.Now().Add(TimerUpdateInterval)
+	key := ""
+	g, err := p.store.UpdateGame(gameID, func(g *Game) error {
+		key = ""
+		if g.QuestionDeadline == 0 {
+			return nil
+		}
+
+		deadline := time.Unix(0, g.QuestionDeadline*int64(time.Millisecond))
+		if deadline.Before(runAt) {
+			runAt = deadline
+		}
+
+		key = getTimerJobKey(g.RootPostID, runAt)
+		g.TimerJobKey = key
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if g == nil || key == "" {
+		return nil
+	}
+
+	_, err = p.scheduler.ScheduleOnce(key, runAt)
+	return err
+}
+
+func (p *Plugin) runQuestionTimer(key string) {
+	gameID := getGameIDFromTimerJobKey(key)
+	g, err := p.store.GetGame(gameID)
+	if err != nil {
+		p.mm.Log.Debug("Cannot get game for timer", "key", key, "err", err)
+		return
+	}
+
+	if g == nil || g.TimerJobKey != key {
+		return
+	}
+
+	currentQuestion := g.CurrentQuestion()
+	if currentQuestion == nil {
+		return
+	}
+
+	if g.TimeLeft() <= 0 {
+		err = p.handleNextQuestion(gameID, currentQuestion.ID, g.ChannelID, g.GM)
+		if err != nil && err != ErrQuestionPassed {
+			p.mm.Log.Debug("Cannot move to the next question", "gameID", gameID, "err", err)
+		}
+		return
+	}
+
+	// The GM may have moved to the next question since the game was read, and the
+	// countdown must not replace the solution or the next question.
+	latest, err := p.store.GetGame(gameID)
+	if err != nil || latest == nil || latest.TimerJobKey != key || latest.CurrentPostID != g.CurrentPostID {
+		return
+	}
+
+	if current := latest.CurrentQuestion(); current == nil || current.ID != currentQuestion.ID {
+		return
+	}
+
+	err = p.updateGamePost(latest)
+	if err != nil {
+		p.mm.Log.Debug("Cannot update the countdown", "gameID", gameID, "err", err)
+	}
+
+	err = p.scheduleQuestionTimer(gameID)
+	if err != nil {
+		p.mm.Log.Debug("Cannot schedule the question timer", "gameID", gameID, "err", err)
+	}
+}
+
+func (p *Plugin) updateGamePost(g *Game) error {
+	post, err := p.mm.Post.GetPost(g.CurrentPostID)
+	if err != nil {
+		return err
+	}
+
+	model.ParseSlackAttachment(post, p.GameAttachment(g))
+	return p.mm.Post.UpdatePost(post)
+}
+
+// getTimerJobKey builds a job key unique for each run. Keys must fit in the KV key
+// length once the scheduler adds its own prefixes.
+func getTimerJobKey(gameID string, runAt time.Time) string {
+	return gameID + "_" + strconv.FormatInt(runAt.UnixNano(), 36)
+}
+
+func getGameIDFromTimerJobKey(key string) string {
+	i := strings.LastIndex(key, "_")
+	if i < 0 {
+		return key
+	}
+
+	return key[:i]
+}
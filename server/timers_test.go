@@ -0,0 +1,81 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimerJobKey(t *testing.T) {
+	gameID := model.NewId()
+	key := getTimerJobKey(gameID, time.Now().Add(time.Hour))
+
+	assert.Equal(t, gameID, getGameIDFromTimerJobKey(key))
+	assert.LessOrEqual(t, len("mutex_"+key), model.KEY_VALUE_KEY_MAX_RUNES)
+}
+
+func TestGameTimeLeft(t *testing.T) {
+	g := &Game{}
+	g.startQuestionClock()
+	assert.Zero(t, g.QuestionDeadline)
+	assert.Zero(t, g.TimeLeft())
+
+	g.TimeLimit = 30
+	g.startQuestionClock()
+	assert.InDelta(t, 30*time.Second, g.TimeLeft(), float64(time.Second))
+
+	g.QuestionDeadline = time.Now().Add(-time.Minute).UnixNano() / int64(time.Millisecond)
+	assert.Zero(t, g.TimeLeft())
+}
+
+func TestRunQuestionTimer(t *testing.T) {
+	p, api := newPermissionsTestPlugin(t)
+	api.On("CreatePost", mock.Anything).Return(&model.Post{Id: "next"}, nil)
+
+	key := getTimerJobKey("game", time.Now())
+	g := &Game{
+		Quiz:       Quiz{ID: testQuizID, Name: "Quiz"},
+		GM:         "gm",
+		RootPostID: "game",
+		ChannelID:  "channel",
+		Type:       GameTypeParty,
+		NQuestions: 2,
+		TimeLimit:  30,
+		RemainingQuestions: []Question{
+			{ID: "q1", Type: QuizTypeSingleAnswer, Question: "First", CorrectAnswer: "a"},
+			{ID: "q2", Type: QuizTypeSingleAnswer, Question: "Second", CorrectAnswer: "b"},
+		},
+		CurrentPostID:    "current",
+		QuestionDeadline: model.GetMillis() - 1000,
+		TimerJobKey:      key,
+	}
+	require.NoError(t, p.store.StoreGame(g))
+
+	_, err := p.store.UpdateGame("game", func(g *Game) error {
+		return g.RecordAnswer("q1", "late", "a", true)
+	})
+	assert.Equal(t, ErrTimeIsUp, err)
+
+	p.runQuestionTimer(getTimerJobKey("game", time.Now().Add(time.Second)))
+	api.AssertNotCalled(t, "UpdatePost", mock.Anything)
+	api.AssertNotCalled(t, "CreatePost", mock.Anything)
+
+	p.runQuestionTimer(key)
+	g, err = p.store.GetGame("game")
+	require.NoError(t, err)
+	require.Len(t, g.PassedQuestions, 1)
+	assert.Equal(t, "q2", g.CurrentQuestion().ID)
+	assert.Equal(t, "next", g.CurrentPostID)
+	assert.Empty(t, g.Score)
+	api.AssertNumberOfCalls(t, "CreatePost", 1)
+
+	p.runQuestionTimer(key)
+	g, err = p.store.GetGame("game")
+	require.NoError(t, err)
+	assert.Len(t, g.PassedQuestions, 1)
+	api.AssertNumberOfCalls(t, "CreatePost", 1)
+}
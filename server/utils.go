@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"math/rand"
+	"sort"
 	"time"
 )
 
@@ -12,20 +13,31 @@ func dumpObject(v interface{}) {
 	fmt.Println(string(b))
 }
 
-func getRandomAnswers(q Question) ([]string, int) {
-	out := make([]string, IncorrectAnswerCount, IncorrectAnswerCount+1)
-	copy(out, q.IncorrectAnswers)
-	out = append(out, q.CorrectAnswer)
-	answer := IncorrectAnswerCount
+// getRandomOptions shuffles the correct and incorrect answers of the question, and
+// returns them with the sorted positions of the correct ones.
+func getRandomOptions(q Question) ([]string, []int) {
+	out, correct := shuffleOptions(append(q.CorrectOptions(), q.IncorrectAnswers...), len(q.CorrectOptions()))
+	sort.Ints(correct)
+	return out, correct
+}
+
+// getRandomOrder shuffles the items of an ordering question, and returns them with
+// the positions of the items in the correct order.
+func getRandomOrder(q Question) ([]string, []int) {
+	items := q.CorrectOptions()
+	return shuffleOptions(items, len(items))
+}
 
+// shuffleOptions returns the options in a random order, and the new positions of the
+// first n options.
+func shuffleOptions(options []string, n int) ([]string, []int) {
 	rand.Seed(time.Now().UnixNano())
-	rand.Shuffle(len(out), func(i, j int) {
-		out[i], out[j] = out[j], out[i]
-		if answer == i {
-			answer = j
-		} else if answer == j {
-			answer = i
-		}
-	})
-	return out, answer
+	positions := rand.Perm(len(options))
+
+	out := make([]string, len(options))
+	for i, option := range options {
+		out[positions[i]] = option
+	}
+
+	return out, append([]int{}, positions[:n]...)
 }
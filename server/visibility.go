@@ -0,0 +1,163 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/pkg/errors"
+)
+
+// isVisibleTo reports whether a user that is not an editor can use a saved quiz or
+// course with the given visibility.
+func (m *userMemberships) isVisibleTo(v Visibility, sharedWith, sharedGroups []string) bool {
+	switch v {
+	case VisibilityPrivate:
+		return false
+	case VisibilityShared:
+		for _, id := range sharedWith {
+			if id == m.userID {
+				return true
+			}
+		}
+
+		for _, id := range sharedGroups {
+			if m.inGroup(id) {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	return true
+}
+
+// canSeeQuiz reports whether the saved quiz is in the library of the user in the team.
+func canSeeQuiz(q *Quiz, m *userMemberships, teamID string) bool {
+	if !m.isInScope(q.TeamID, q.ChannelIDs, teamID) {
+		return false
+	}
+
+	return q.IsEditor(m.userID) || m.isVisibleTo(q.Visibility, q.SharedWith, q.SharedGroups)
+}
+
+// canSeeCourse reports whether the saved course is in the library of the user in the
+// team.
+func canSeeCourse(c *Course, m *userMemberships, teamID string) bool {
+	if !m.isInScope(c.TeamID, c.ChannelIDs, teamID) {
+		return false
+	}
+
+	return c.IsEditor(m.userID) || m.isVisibleTo(c.Visibility, c.SharedWith, c.SharedGroups)
+}
+
+func (p *Plugin) getGroupIDsFromNames(text string) ([]string, error) {
+	ids := []string{}
+	for _, name := range strings.Fields(strings.ReplaceAll(text, ",", " ")) {
+		name = strings.TrimPrefix(name, "@")
+		group, err := p.mm.Group.GetByName(name)
+		if err != nil {
+			return nil, errors.Errorf("group @%s not found", name)
+		}
+		ids = append(ids, group.Id)
+	}
+
+	return ids, nil
+}
+
+func (p *Plugin) getGroupNamesFromIDs(ids []string) string {
+	names := []string{}
+	for _, id := range ids {
+		group, err := p.mm.Group.Get(id)
+		if err != nil || group.Name == nil {
+			p.mm.Log.Debug("Cannot get group", "id", id, "err", err)
+			continue
+		}
+		names = append(names, "@"+*group.Name)
+	}
+
+	return strings.Join(names, " ")
+}
+
+// getVisibilityText describes the visibility of a saved quiz or course.
+func (p *Plugin) getVisibilityText(v Visibility, sharedWith, sharedGroups []string) string {
+	switch v {
+	case VisibilityPrivate:
+		return "private, only the editors can use it"
+	case VisibilityShared:
+		shared := strings.TrimSpace(p.getUsernamesFromUserIDs(sharedWith) + " " + p.getGroupNamesFromIDs(sharedGroups))
+		if shared == "" {
+			return "shared with nobody yet"
+		}
+		return "shared with " + shared
+	}
+
+	return "public"
+}
+
+func (p *Plugin) getVisibilityDialogElements(v Visibility, sharedWith, sharedGroups []string) []model.DialogElement {
+	if v == "" {
+		v = VisibilityPublic
+	}
+
+	return []model.DialogElement{
+		{
+			DisplayName: "Visibility",
+			Name:        DialogSubmissionFieldVisibility,
+			Type:        DialogTypeSelect,
+			Default:     string(v),
+			HelpText:    "Private: only the editors can use it. Shared: only the users and groups below can use it. Public: everyone in its scope can use it.",
+			Options: []*model.PostActionOptions{
+				{Text: "Private", Value: string(VisibilityPrivate)},
+				{Text: "Shared", Value: string(VisibilityShared)},
+				{Text: "Public", Value: string(VisibilityPublic)},
+			},
+		},
+		{
+			DisplayName: "Shared with users",
+			Name:        DialogSubmissionFieldSharedUsers,
+			Type:        DialogTypeText,
+			HelpText:    "Usernames separated by spaces, e.g. @alice @bob",
+			Default:     p.getUsernamesFromUserIDs(sharedWith),
+			Optional:    true,
+		},
+		{
+			DisplayName: "Shared with groups",
+			Name:        DialogSubmissionFieldSharedGroups,
+			Type:        DialogTypeText,
+			HelpText:    "Group names separated by spaces, e.g. @developers @support",
+			Default:     p.getGroupNamesFromIDs(sharedGroups),
+			Optional:    true,
+		},
+	}
+}
+
+// getVisibilityFromSubmission reads the visibility dialog. It returns the errors of the
+// fields when they are not valid.
+func (p *Plugin) getVisibilityFromSubmission(submission map[string]interface{}) (Visibility, []string, []string, map[string]string) {
+	value, _ := submission[DialogSubmissionFieldVisibility].(string)
+	v := Visibility(value)
+	if v == "" || !v.IsValid() {
+		return "", nil, nil, map[string]string{
+			DialogSubmissionFieldVisibility: "Invalid visibility",
+		}
+	}
+
+	usernames, _ := submission[DialogSubmissionFieldSharedUsers].(string)
+	sharedWith, err := p.getUserIDsFromUsernames(usernames)
+	if err != nil {
+		return "", nil, nil, map[string]string{
+			DialogSubmissionFieldSharedUsers: err.Error(),
+		}
+	}
+
+	groupNames, _ := submission[DialogSubmissionFieldSharedGroups].(string)
+	sharedGroups, err := p.getGroupIDsFromNames(groupNames)
+	if err != nil {
+		return "", nil, nil, map[string]string{
+			DialogSubmissionFieldSharedGroups: err.Error(),
+		}
+	}
+
+	return v, sharedWith, sharedGroups, nil
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/mattermost/mattermost-server/v5/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsVisibleTo(t *testing.T) {
+	p, api := newTestPlugin()
+	api.On("GetGroupsForUser", "developer").Return([]*model.Group{{Id: "developers"}}, nil)
+	api.On("GetGroupsForUser", "manager").Return([]*model.Group{}, nil)
+
+	for _, tc := range []struct {
+		name         string
+		visibility   Visibility
+		sharedWith   []string
+		sharedGroups []string
+		userID       string
+		expected     bool
+	}{
+		{"saved before visibilities", "", nil, nil, "manager", true},
+		{"public", VisibilityPublic, nil, nil, "manager", true},
+		{"private", VisibilityPrivate, []string{"manager"}, nil, "manager", false},
+		{"shared with the user", VisibilityShared, []string{"manager"}, nil, "manager", true},
+		{"shared with a group of the user", VisibilityShared, nil, []string{"developers"}, "developer", true},
+		{"shared with other people", VisibilityShared, []string{"developer"}, []string{"developers"}, "manager", false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, p.newUserMemberships(tc.userID).isVisibleTo(tc.visibility, tc.sharedWith, tc.sharedGroups))
+		})
+	}
+}
+
+func TestCanStartQuizVisibility(t *testing.T) {
+	p, api := newPermissionsTestPlugin(t)
+	api.On("GetGroupsForUser", "player").Return([]*model.Group{}, nil)
+
+	q, err := p.store.GetQuiz(testQuizID)
+	require.NoError(t, err)
+	q.Visibility = VisibilityPrivate
+	require.NoError(t, p.store.StoreQuiz(q))
+	require.NoError(t, p.store.AddAvailableQuiz(q))
+
+	assert.True(t, p.canStartQuiz(q, "editor", "", ""))
+	assert.False(t, p.canStartQuiz(q, "player", "", ""))
+	assert.Empty(t, p.getAvailableQuizzes("player", ""))
+
+	q.Visibility = VisibilityShared
+	q.SharedWith = []string{"friend"}
+	require.NoError(t, p.store.StoreQuiz(q))
+	assert.True(t, p.canStartQuiz(q, "friend", "", ""))
+	assert.False(t, p.canStartQuiz(q, "player", "", ""))
+	assert.Len(t, p.getAvailableQuizzes("friend", ""), 1)
+}
+
+func TestGetAvailableQuizzesListsGroupsOnce(t *testing.T) {
+	p, api := newTestPlugin()
+	api.On("GetGroupsForUser", "developer").Return([]*model.Group{{Id: "developers"}}, nil)
+
+	for _, q := range []*Quiz{
+		{ID: "developers", Name: "Developers", Visibility: VisibilityShared, SharedGroups: []string{"developers"}},
+		{ID: "support", Name: "Support", Visibility: VisibilityShared, SharedGroups: []string{"support"}},
+	} {
+		require.NoError(t, p.store.StoreQuiz(q))
+		require.NoError(t, p.store.AddAvailableQuiz(q))
+	}
+
+	quizzes := p.getAvailableQuizzes("developer", "")
+	require.Len(t, quizzes, 1)
+	assert.Equal(t, "developers", quizzes[0].ID)
+	api.AssertNumberOfCalls(t, "GetGroupsForUser", 1)
+}